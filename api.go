@@ -0,0 +1,214 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+var (
+	contextType   = reflect.TypeOf((*context.Context)(nil)).Elem()
+	errorType     = reflect.TypeOf((*error)(nil)).Elem()
+	byteSliceType = reflect.TypeOf([]byte(nil))
+	responseType  = reflect.TypeOf((*http.Response)(nil))
+)
+
+// NewAPI populates the func fields of the struct pointed to by api that are
+// tagged `http:"METHOD /path/{name}"` with functions that make the request.
+//
+// Each request is a clone of base, which supplies the client, scheme, host,
+// header, and a path prefix. The functions may accept a context.Context
+// followed by a parameter struct bound with Request.Bind, and must return an
+// error, optionally preceded by a value decoded from the response body. A value
+// of type []byte receives the raw response body, and a value of type
+// *http.Response receives the undecoded response.
+//
+// Request bodies default to a "Content-Type" of "application/json", and decoded
+// response bodies to an "Accept" of "application/json".
+func NewAPI(api interface{}, base *Request) error {
+	rv := reflect.ValueOf(api)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("must provide pointer to struct")
+	}
+	if base == nil {
+		base = NewRequest()
+	}
+
+	rv = rv.Elem()
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+
+		tag, ok := field.Tag.Lookup("http")
+		if !ok {
+			continue
+		}
+		if field.PkgPath != "" {
+			return fmt.Errorf("field %s must be exported", field.Name)
+		}
+
+		endpoint, err := newAPIEndpoint(field.Type, tag)
+		if err != nil {
+			return fmt.Errorf("error creating %s: %w", field.Name, err)
+		}
+
+		rv.Field(i).Set(reflect.MakeFunc(field.Type, func(args []reflect.Value) []reflect.Value {
+			return endpoint.call(base, args)
+		}))
+	}
+
+	return nil
+}
+
+type apiEndpoint struct {
+	fnType      reflect.Type
+	method      string
+	path        string
+	ctxIndex    int
+	paramsIndex int
+	resultType  reflect.Type
+}
+
+func newAPIEndpoint(fnType reflect.Type, tag string) (*apiEndpoint, error) {
+	if fnType.Kind() != reflect.Func {
+		return nil, fmt.Errorf("must be func, got %s", fnType.Kind())
+	}
+
+	parts := strings.Fields(tag)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("tag must be \"METHOD /path\", got %q", tag)
+	}
+
+	e := &apiEndpoint{
+		fnType:      fnType,
+		method:      parts[0],
+		path:        parts[1],
+		ctxIndex:    -1,
+		paramsIndex: -1,
+	}
+
+	if fnType.IsVariadic() {
+		return nil, fmt.Errorf("must not be variadic")
+	}
+	for i := 0; i < fnType.NumIn(); i++ {
+		in := fnType.In(i)
+		switch {
+		case in == contextType && i == 0:
+			e.ctxIndex = i
+		case e.paramsIndex == -1 && isStructOrStructPointer(in):
+			e.paramsIndex = i
+		default:
+			return nil, fmt.Errorf("unsupported argument %d of type %s", i, in)
+		}
+	}
+
+	switch fnType.NumOut() {
+	case 1:
+	case 2:
+		e.resultType = fnType.Out(0)
+	default:
+		return nil, fmt.Errorf("must return an error, optionally preceded by a value")
+	}
+	if fnType.Out(fnType.NumOut()-1) != errorType {
+		return nil, fmt.Errorf("last return value must be error")
+	}
+
+	return e, nil
+}
+
+func isStructOrStructPointer(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct
+}
+
+func (e *apiEndpoint) call(base *Request, args []reflect.Value) []reflect.Value {
+	ctx := context.Background()
+	if e.ctxIndex >= 0 && !args[e.ctxIndex].IsNil() {
+		ctx = args[e.ctxIndex].Interface().(context.Context)
+	}
+
+	var params interface{}
+	if e.paramsIndex >= 0 {
+		params = args[e.paramsIndex].Interface()
+	}
+
+	path := strings.TrimSuffix(base.Path, "/") + e.path
+	o, err := base.Clone().WithMethod(e.method).WithPath(path).Bind(params)
+	if err != nil {
+		return e.results(reflect.Value{}, fmt.Errorf("error binding parameters: %w", err))
+	}
+
+	if _, ok := o.RequestBody.([]byte); o.RequestBody != nil && !ok {
+		o.ensureHeader()
+		if o.Header.Get("Content-Type") == "" {
+			o.Header.Set("Content-Type", "application/json")
+		}
+	}
+
+	if e.resultType == responseType {
+		resp, err := o.DoContext(ctx)
+		return e.results(reflect.ValueOf(resp), err)
+	}
+
+	var data []byte
+	o.WithResponseBody(&data)
+	if e.resultType != nil && e.resultType != byteSliceType {
+		o.ensureHeader()
+		if o.Header.Get("Accept") == "" {
+			o.Header.Set("Accept", "application/json")
+		}
+	}
+
+	resp, err := o.DoContext(ctx)
+	if resp != nil {
+		defer resp.Body.Close()
+	}
+	if err != nil {
+		return e.results(reflect.Value{}, err)
+	}
+
+	switch {
+	case e.resultType == nil:
+		return e.results(reflect.Value{}, nil)
+	case e.resultType == byteSliceType:
+		return e.results(reflect.ValueOf(data), nil)
+	}
+
+	elemType := e.resultType
+	if elemType.Kind() == reflect.Ptr {
+		elemType = elemType.Elem()
+	}
+	result := reflect.New(elemType)
+	if len(data) > 0 {
+		if err := decode(o.inferResponseEncoding(resp), bytes.NewReader(data), result.Interface()); err != nil {
+			return e.results(reflect.Value{}, fmt.Errorf("error decoding response body: %w", err))
+		}
+	}
+	if e.resultType.Kind() != reflect.Ptr {
+		result = result.Elem()
+	}
+
+	return e.results(result, nil)
+}
+
+func (e *apiEndpoint) results(value reflect.Value, err error) []reflect.Value {
+	out := make([]reflect.Value, e.fnType.NumOut())
+	if e.resultType != nil {
+		out[0] = reflect.Zero(e.resultType)
+		if value.IsValid() {
+			out[0] = value
+		}
+	}
+
+	out[len(out)-1] = reflect.Zero(errorType)
+	if err != nil {
+		out[len(out)-1] = reflect.ValueOf(&err).Elem()
+	}
+
+	return out
+}
@@ -0,0 +1,210 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+type mockAPIParams struct {
+	ID    string    `path:"id"`
+	Limit int       `query:"limit,omitempty"`
+	Body  *mockBody `body:""`
+}
+
+type mockAPI struct {
+	GetUser    func(ctx context.Context, params *mockAPIParams) (*mockBody, error) `http:"GET /users/{id}"`
+	GetUserRaw func(params mockAPIParams) ([]byte, error)                          `http:"GET /users/{id}"`
+	PutUser    func(ctx context.Context, params *mockAPIParams) (mockBody, error)  `http:"PUT /users/{id}"`
+	DeleteUser func(params *mockAPIParams) error                                   `http:"DELETE /users/{id}"`
+	ListUsers  func() (*http.Response, error)                                      `http:"GET /users"`
+	Untagged   func() error
+}
+
+func TestNewAPI(t *testing.T) {
+	type args struct {
+		api interface{}
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr bool
+	}{
+		{
+			name: "success",
+			args: args{
+				api: &mockAPI{},
+			},
+		},
+		{
+			name: "error not pointer",
+			args: args{
+				api: mockAPI{},
+			},
+			wantErr: true,
+		},
+		{
+			name: "error not func",
+			args: args{
+				api: &struct {
+					Foo string `http:"GET /foo"`
+				}{},
+			},
+			wantErr: true,
+		},
+		{
+			name: "error invalid tag",
+			args: args{
+				api: &struct {
+					Foo func() error `http:"/foo"`
+				}{},
+			},
+			wantErr: true,
+		},
+		{
+			name: "error unsupported argument",
+			args: args{
+				api: &struct {
+					Foo func(id string) error `http:"GET /foo"`
+				}{},
+			},
+			wantErr: true,
+		},
+		{
+			name: "error no error result",
+			args: args{
+				api: &struct {
+					Foo func() *mockBody `http:"GET /foo"`
+				}{},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := NewAPI(tt.args.api, nil); (err != nil) != tt.wantErr {
+				t.Errorf("NewAPI() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewAPI_Call(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v1/users/123":
+			if got := r.URL.Query().Get("limit"); got != "5" {
+				t.Errorf("http.Request.URL.Query().Get(\"limit\") = %v, want %v", got, "5")
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"name":"bob","age":3}`))
+		case r.Method == http.MethodPut && r.URL.Path == "/api/v1/users/123":
+			if got := r.Header.Get("Content-Type"); got != "application/json" {
+				t.Errorf("http.Request.Header.Get(\"Content-Type\") = %v, want %v", got, "application/json")
+			}
+			body, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			w.Write(body)
+		case r.Method == http.MethodDelete && r.URL.Path == "/api/v1/users/123":
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v1/users":
+			w.Write([]byte("[]"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	base, err := NewRequest().FromURLString(server.URL + "/api/v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	api := &mockAPI{}
+	if err := NewAPI(api, base); err != nil {
+		t.Fatal(err)
+	}
+
+	if api.Untagged != nil {
+		t.Errorf("mockAPI.Untagged is set, want nil")
+	}
+
+	t.Run("success decoded pointer", func(t *testing.T) {
+		got, err := api.GetUser(context.Background(), &mockAPIParams{ID: "123", Limit: 5})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := (&mockBody{Name: "bob", Age: 3}); !reflect.DeepEqual(got, want) {
+			t.Errorf("mockAPI.GetUser() = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("success raw", func(t *testing.T) {
+		got, err := api.GetUserRaw(mockAPIParams{ID: "123", Limit: 5})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := []byte(`{"name":"bob","age":3}`); !reflect.DeepEqual(got, want) {
+			t.Errorf("mockAPI.GetUserRaw() = %s, want %s", got, want)
+		}
+	})
+
+	t.Run("success decoded value with body", func(t *testing.T) {
+		body := &mockBody{Name: "alice", Age: 4}
+		got, err := api.PutUser(context.Background(), &mockAPIParams{ID: "123", Body: body})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, *body) {
+			t.Errorf("mockAPI.PutUser() = %v, want %v", got, *body)
+		}
+	})
+
+	t.Run("success no content", func(t *testing.T) {
+		if err := api.DeleteUser(&mockAPIParams{ID: "123"}); err != nil {
+			t.Errorf("mockAPI.DeleteUser() error = %v", err)
+		}
+	})
+
+	t.Run("success response", func(t *testing.T) {
+		resp, err := api.ListUsers()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("mockAPI.ListUsers() status code = %v, want %v", resp.StatusCode, http.StatusOK)
+		}
+	})
+
+	t.Run("error status code", func(t *testing.T) {
+		got, err := api.GetUser(context.Background(), &mockAPIParams{ID: "456"})
+		var statusCodeErr *StatusCodeError
+		if !errors.As(err, &statusCodeErr) || statusCodeErr.StatusCode != http.StatusNotFound {
+			t.Errorf("mockAPI.GetUser() error = %v, want %v", err, &StatusCodeError{StatusCode: http.StatusNotFound})
+		}
+		if got != nil {
+			t.Errorf("mockAPI.GetUser() = %v, want nil", got)
+		}
+	})
+
+	t.Run("error missing path variable", func(t *testing.T) {
+		if _, err := api.GetUser(context.Background(), &mockAPIParams{}); err == nil {
+			t.Errorf("mockAPI.GetUser() error = %v, wantErr %v", err, true)
+		}
+	})
+
+	t.Run("error cancelled context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if _, err := api.GetUser(ctx, &mockAPIParams{ID: "123"}); !errors.Is(err, context.Canceled) {
+			t.Errorf("mockAPI.GetUser() error = %v, want %v", err, context.Canceled)
+		}
+	})
+}
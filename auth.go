@@ -0,0 +1,231 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Authenticator adds credentials to HTTP requests.
+//
+// The Authenticator of a Request is applied to each HTTP request that it
+// makes, rather than stored in its header, so that credentials are shared by
+// the clones of a base Request and are not exposed by its header.
+type Authenticator interface {
+	Authenticate(req *http.Request) error
+}
+
+// AuthenticatorFunc is a function that implements Authenticator.
+type AuthenticatorFunc func(req *http.Request) error
+
+// Authenticate calls f(req).
+func (f AuthenticatorFunc) Authenticate(req *http.Request) error {
+	return f(req)
+}
+
+// ChainAuth returns an Authenticator that applies each of the authenticators
+// in order, such as a bearer token followed by a message signature.
+func ChainAuth(auths ...Authenticator) Authenticator {
+	return chainAuth(auths)
+}
+
+type chainAuth []Authenticator
+
+func (o chainAuth) Authenticate(req *http.Request) error {
+	for _, auth := range o {
+		if err := auth.Authenticate(req); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (o chainAuth) Refresh(resp *http.Response) bool {
+	refreshed := false
+	for _, auth := range o {
+		if refresher, ok := auth.(Refresher); ok && refresher.Refresh(resp) {
+			refreshed = true
+		}
+	}
+	return refreshed
+}
+
+func (o chainAuth) SensitiveNames() (header, query []string) {
+	for _, auth := range o {
+		if s, ok := auth.(Sensitive); ok {
+			h, q := s.SensitiveNames()
+			header = append(header, h...)
+			query = append(query, q...)
+		}
+	}
+	return header, query
+}
+
+// BasicAuth authenticates with a username and password.
+type BasicAuth struct {
+	Username string
+	Password Secret
+}
+
+// Authenticate sets the "Authorization" header of the request.
+func (o *BasicAuth) Authenticate(req *http.Request) error {
+	req.SetBasicAuth(o.Username, string(o.Password))
+	return nil
+}
+
+// APIKeyLocation is where an API key is sent.
+type APIKeyLocation string
+
+const (
+	APIKeyInHeader APIKeyLocation = "header"
+	APIKeyInQuery  APIKeyLocation = "query"
+)
+
+// APIKey authenticates with an API key in a header or query parameter.
+type APIKey struct {
+	In    APIKeyLocation
+	Name  string
+	Value Secret
+}
+
+// Authenticate sets the header or query parameter of the request.
+func (o *APIKey) Authenticate(req *http.Request) error {
+	switch o.In {
+	case APIKeyInHeader:
+		req.Header.Set(o.Name, string(o.Value))
+	case APIKeyInQuery:
+		query := req.URL.Query()
+		query.Set(o.Name, string(o.Value))
+		req.URL.RawQuery = query.Encode()
+	default:
+		return fmt.Errorf("unsupported API key location %q", o.In)
+	}
+	return nil
+}
+
+// SensitiveNames returns the name of the API key.
+func (o *APIKey) SensitiveNames() (header, query []string) {
+	if o.In == APIKeyInQuery {
+		return nil, []string{o.Name}
+	}
+	return []string{o.Name}, nil
+}
+
+// Token is an access token.
+type Token struct {
+	// Type is the type of the token, "Bearer" if empty.
+	Type  string
+	Value Secret
+
+	// Expiry is when the token expires, or zero if it does not.
+	Expiry time.Time
+}
+
+// Valid returns whether the token has a value and has not expired.
+func (o *Token) Valid() bool {
+	return o != nil && o.Value != "" && (o.Expiry.IsZero() || time.Now().Before(o.Expiry))
+}
+
+// TokenSource supplies access tokens.
+type TokenSource interface {
+	Token(ctx context.Context) (*Token, error)
+}
+
+// TokenSourceFunc is a function that implements TokenSource.
+type TokenSourceFunc func(ctx context.Context) (*Token, error)
+
+// Token calls f(ctx).
+func (f TokenSourceFunc) Token(ctx context.Context) (*Token, error) {
+	return f(ctx)
+}
+
+// StaticTokenSource returns a TokenSource that always supplies the bearer
+// token.
+func StaticTokenSource(token string) TokenSource {
+	t := &Token{Value: Secret(token)}
+	return TokenSourceFunc(func(context.Context) (*Token, error) {
+		return t, nil
+	})
+}
+
+// TokenInvalidator is implemented by a TokenSource whose tokens may be
+// rejected before they expire, so that it supplies a fresh token next.
+type TokenInvalidator interface {
+	Invalidate(token *Token)
+}
+
+// Refresher is implemented by an Authenticator whose credentials may be
+// rejected with a 401 status code, or that must answer a challenge. Do calls
+// Refresh with the 401 response, and retries the request once if it returns
+// true.
+type Refresher interface {
+	Refresh(resp *http.Response) bool
+}
+
+// TokenAuth authenticates with tokens from a TokenSource, which is asked for a
+// token for each request.
+type TokenAuth struct {
+	Source TokenSource
+}
+
+// Authenticate sets the "Authorization" header of the request.
+func (o *TokenAuth) Authenticate(req *http.Request) error {
+	token, err := o.Source.Token(req.Context())
+	if err != nil {
+		return fmt.Errorf("error getting token: %w", err)
+	}
+
+	tokenType := token.Type
+	if tokenType == "" {
+		tokenType = "Bearer"
+	}
+	req.Header.Set("Authorization", tokenType+" "+string(token.Value))
+	return nil
+}
+
+// Refresh invalidates the token of the rejected request, if the TokenSource
+// is a TokenInvalidator.
+func (o *TokenAuth) Refresh(resp *http.Response) bool {
+	invalidator, ok := o.Source.(TokenInvalidator)
+	if !ok {
+		return false
+	}
+
+	parts := strings.SplitN(resp.Request.Header.Get("Authorization"), " ", 2)
+	if len(parts) != 2 {
+		return false
+	}
+	invalidator.Invalidate(&Token{Type: parts[0], Value: Secret(parts[1])})
+	return true
+}
+
+// WithAuth sets the authenticator of the Request.
+func (o *Request) WithAuth(auth Authenticator) *Request {
+	o.Auth = auth
+	return o
+}
+
+// WithBasicAuth sets the authenticator of the Request to basic auth.
+func (o *Request) WithBasicAuth(username, password string) *Request {
+	return o.WithAuth(&BasicAuth{Username: username, Password: Secret(password)})
+}
+
+// WithBearerToken sets the authenticator of the Request to a static bearer
+// token.
+func (o *Request) WithBearerToken(token string) *Request {
+	return o.WithTokenSource(StaticTokenSource(token))
+}
+
+// WithTokenSource sets the authenticator of the Request to tokens from the
+// TokenSource.
+func (o *Request) WithTokenSource(source TokenSource) *Request {
+	return o.WithAuth(&TokenAuth{Source: source})
+}
+
+// WithAPIKey sets the authenticator of the Request to an API key in the header
+// or query parameter name.
+func (o *Request) WithAPIKey(in APIKeyLocation, name, value string) *Request {
+	return o.WithAuth(&APIKey{In: in, Name: name, Value: Secret(value)})
+}
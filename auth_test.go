@@ -0,0 +1,144 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRequest_Do_auth(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get("Authorization") + "|" + r.Header.Get("X-Api-Key") + "|" + r.URL.Query().Get("api_key")))
+	}))
+	defer server.Close()
+
+	type args struct {
+		auth Authenticator
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "success basic",
+			args: args{
+				auth: &BasicAuth{Username: "foo", Password: "bar"},
+			},
+			want: "Basic Zm9vOmJhcg==||",
+		},
+		{
+			name: "success bearer",
+			args: args{
+				auth: &TokenAuth{Source: StaticTokenSource("abc")},
+			},
+			want: "Bearer abc||",
+		},
+		{
+			name: "success token type",
+			args: args{
+				auth: &TokenAuth{Source: TokenSourceFunc(func(context.Context) (*Token, error) {
+					return &Token{Type: "MAC", Value: "abc"}, nil
+				})},
+			},
+			want: "MAC abc||",
+		},
+		{
+			name: "success api key in header",
+			args: args{
+				auth: &APIKey{In: APIKeyInHeader, Name: "X-Api-Key", Value: "abc"},
+			},
+			want: "|abc|",
+		},
+		{
+			name: "success api key in query",
+			args: args{
+				auth: &APIKey{In: APIKeyInQuery, Name: "api_key", Value: "abc"},
+			},
+			want: "||abc",
+		},
+		{
+			name: "error token source",
+			args: args{
+				auth: &TokenAuth{Source: TokenSourceFunc(func(context.Context) (*Token, error) {
+					return nil, errors.New("no token")
+				})},
+			},
+			wantErr: true,
+		},
+		{
+			name: "error api key location",
+			args: args{
+				auth: &APIKey{In: "cookie", Name: "api_key", Value: "abc"},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base, err := NewRequest().WithAuth(tt.args.auth).FromURLString(server.URL + "/")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var body []byte
+			o := base.Clone().WithMethod(http.MethodGet).WithResponseBody(&body)
+			_, err = o.Do()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Request.Do() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if string(body) != tt.want {
+				t.Errorf("Request.Do() body = %q, want %q", body, tt.want)
+			}
+			if len(o.Header) != 0 || len(o.Query) != 0 {
+				t.Errorf("Request.Do() changed header %v or query %v", o.Header, o.Query)
+			}
+		})
+	}
+}
+
+func TestToken_Valid(t *testing.T) {
+	tests := []struct {
+		name  string
+		token *Token
+		want  bool
+	}{
+		{
+			name:  "success no expiry",
+			token: &Token{Value: "abc"},
+			want:  true,
+		},
+		{
+			name:  "success not expired",
+			token: &Token{Value: "abc", Expiry: time.Now().Add(time.Hour)},
+			want:  true,
+		},
+		{
+			name:  "error expired",
+			token: &Token{Value: "abc", Expiry: time.Now().Add(-time.Hour)},
+			want:  false,
+		},
+		{
+			name:  "error empty",
+			token: &Token{},
+			want:  false,
+		},
+		{
+			name:  "error nil",
+			token: nil,
+			want:  false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.token.Valid(); got != tt.want {
+				t.Errorf("Token.Valid() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,358 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const defaultEjectDuration = 30 * time.Second
+
+// BalanceStrategy is how a LoadBalancer chooses an endpoint.
+type BalanceStrategy string
+
+const (
+	BalanceRoundRobin       BalanceStrategy = "round-robin"
+	BalanceRandom           BalanceStrategy = "random"
+	BalanceLeastOutstanding BalanceStrategy = "least-outstanding"
+	BalanceWeighted         BalanceStrategy = "weighted"
+)
+
+// Endpoint is a replica of a service.
+type Endpoint struct {
+	// Host is the host, with an optional port, of the endpoint.
+	Host string
+
+	// Scheme, if set, replaces the scheme of requests to the endpoint.
+	Scheme string
+
+	// Weight is the relative share of requests of the endpoint with the
+	// weighted strategy, 1 if zero.
+	Weight int
+}
+
+// EndpointError is the error of a request to an endpoint.
+type EndpointError struct {
+	Endpoint string
+	Err      error
+}
+
+func (o *EndpointError) Error() string {
+	return fmt.Sprintf("endpoint %s: %v", o.Endpoint, o.Err)
+}
+
+func (o *EndpointError) Unwrap() error {
+	return o.Err
+}
+
+// LoadBalancer sends each request to one of several endpoints, replacing the
+// host of the request. It is safe for concurrent use.
+//
+// A request that fails to connect is sent to another endpoint, as is an
+// idempotent request with a response status code in FailoverStatusCodes, up
+// to MaxAttempts endpoints. Failures are tracked passively: an endpoint that
+// fails EjectAfter times in a row is not chosen for EjectDuration, unless all
+// endpoints are ejected. Cancelled requests, such as the losing attempts of
+// hedged requests, are not failures. A request is outstanding until its
+// response body is read to the end or closed.
+//
+// The host is replaced after the request is authenticated, so the LoadBalancer
+// cannot be combined with authenticators that sign the host, such as SigV4 and
+// MessageSigner.
+//
+// The chosen endpoint is the host of the URL of the response's request, and is
+// reported by ResponseEndpoint and by an EndpointError on failure.
+type LoadBalancer struct {
+	Endpoints []Endpoint
+	Strategy  BalanceStrategy
+
+	FailoverStatusCodes []int
+
+	// MaxAttempts is the number of endpoints to try, all if zero.
+	MaxAttempts int
+
+	// EjectAfter is the number of consecutive failures to eject an endpoint
+	// after, 1 if zero.
+	EjectAfter int
+
+	// EjectDuration defaults to 30 seconds.
+	EjectDuration time.Duration
+
+	// Now returns the current time, time.Now if nil.
+	Now func() time.Time
+
+	mu     sync.Mutex
+	states []endpointState
+	next   int
+	rand   *rand.Rand
+}
+
+type endpointState struct {
+	inFlight     int
+	failures     int
+	ejectedUntil time.Time
+}
+
+// EndpointState is the state of an endpoint of a LoadBalancer.
+type EndpointState struct {
+	Endpoint     Endpoint
+	InFlight     int
+	Failures     int
+	EjectedUntil time.Time
+}
+
+// Middleware balances the requests of a client.
+func (o *LoadBalancer) Middleware(next http.RoundTripper) http.RoundTripper {
+	return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if len(o.Endpoints) == 0 {
+			return nil, errors.New("load balancer has no endpoints")
+		}
+
+		maxAttempts := o.MaxAttempts
+		if maxAttempts < 1 || maxAttempts > len(o.Endpoints) {
+			maxAttempts = len(o.Endpoints)
+		}
+
+		tried := make([]bool, len(o.Endpoints))
+		for attempt := 1; ; attempt++ {
+			i := o.choose(tried)
+			tried[i] = true
+			endpoint := o.Endpoints[i]
+
+			attemptReq, err := endpointRequest(req, endpoint, attempt > 1)
+			if err != nil {
+				o.done(i, false)
+				return nil, &EndpointError{Endpoint: endpoint.Host, Err: err}
+			}
+
+			resp, err := next.RoundTrip(attemptReq)
+			if err != nil {
+				if req.Context().Err() != nil {
+					o.release(i)
+					return nil, &EndpointError{Endpoint: endpoint.Host, Err: err}
+				}
+				o.done(i, false)
+				if attempt < maxAttempts && connectError(err) {
+					continue
+				}
+				return nil, &EndpointError{Endpoint: endpoint.Host, Err: err}
+			}
+
+			failed := containsStatusCode(o.FailoverStatusCodes, resp.StatusCode)
+			if failed && attempt < maxAttempts && idempotent(req.Method) && (req.Body == nil || req.GetBody != nil) {
+				resp.Body.Close()
+				o.done(i, false)
+				continue
+			}
+
+			// The request is in flight until its response body is done.
+			resp.Body = &doneBody{ReadCloser: resp.Body, done: func(err error) {
+				if err != nil && req.Context().Err() != nil {
+					o.release(i)
+					return
+				}
+				o.done(i, !failed && err == nil)
+			}}
+			resp.Request = attemptReq
+			return resp, nil
+		}
+	})
+}
+
+// State returns the state of each endpoint.
+func (o *LoadBalancer) State() []EndpointState {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.ensureStates()
+	states := make([]EndpointState, len(o.Endpoints))
+	for i, state := range o.states {
+		states[i] = EndpointState{
+			Endpoint:     o.Endpoints[i],
+			InFlight:     state.inFlight,
+			Failures:     state.failures,
+			EjectedUntil: state.ejectedUntil,
+		}
+	}
+	return states
+}
+
+// choose returns the index of the endpoint for the next attempt, preferring
+// endpoints that are not ejected, and marks it in flight.
+func (o *LoadBalancer) choose(tried []bool) int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.ensureStates()
+	now := o.now()
+
+	var candidates []int
+	for i, state := range o.states {
+		if !tried[i] && !now.Before(state.ejectedUntil) {
+			candidates = append(candidates, i)
+		}
+	}
+	if len(candidates) == 0 {
+		for i := range o.states {
+			if !tried[i] {
+				candidates = append(candidates, i)
+			}
+		}
+	}
+
+	var chosen int
+	switch o.Strategy {
+	case BalanceRandom:
+		chosen = candidates[o.rand.Intn(len(candidates))]
+	case BalanceLeastOutstanding:
+		chosen = candidates[0]
+		for _, i := range candidates[1:] {
+			if o.states[i].inFlight < o.states[chosen].inFlight {
+				chosen = i
+			}
+		}
+	case BalanceWeighted:
+		total := 0
+		for _, i := range candidates {
+			total += o.weight(i)
+		}
+		n := o.rand.Intn(total)
+		for _, i := range candidates {
+			n -= o.weight(i)
+			if n < 0 {
+				chosen = i
+				break
+			}
+		}
+	default:
+		chosen = candidates[0]
+		for _, i := range candidates {
+			if i >= o.next%len(o.Endpoints) {
+				chosen = i
+				break
+			}
+		}
+		o.next = chosen + 1
+	}
+
+	o.states[chosen].inFlight++
+	return chosen
+}
+
+// done records the outcome of a request to the endpoint.
+func (o *LoadBalancer) done(i int, ok bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	state := &o.states[i]
+	state.inFlight--
+	if ok {
+		state.failures = 0
+		return
+	}
+
+	state.failures++
+	ejectAfter := o.EjectAfter
+	if ejectAfter < 1 {
+		ejectAfter = 1
+	}
+	if state.failures >= ejectAfter {
+		ejectDuration := o.EjectDuration
+		if ejectDuration <= 0 {
+			ejectDuration = defaultEjectDuration
+		}
+		state.ejectedUntil = o.now().Add(ejectDuration)
+	}
+}
+
+// release ends a request to the endpoint without an outcome, such as when
+// the request is cancelled.
+func (o *LoadBalancer) release(i int) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.states[i].inFlight--
+}
+
+func (o *LoadBalancer) weight(i int) int {
+	if o.Endpoints[i].Weight < 1 {
+		return 1
+	}
+	return o.Endpoints[i].Weight
+}
+
+func (o *LoadBalancer) ensureStates() {
+	if len(o.states) != len(o.Endpoints) {
+		o.states = make([]endpointState, len(o.Endpoints))
+	}
+	if o.rand == nil {
+		o.rand = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+}
+
+func (o *LoadBalancer) now() time.Time {
+	if o.Now != nil {
+		return o.Now()
+	}
+	return time.Now()
+}
+
+// endpointRequest returns a copy of the request to the endpoint, with a new
+// body if it is a retry.
+func endpointRequest(req *http.Request, endpoint Endpoint, retry bool) (*http.Request, error) {
+	r := req.Clone(req.Context())
+	r.URL.Host = endpoint.Host
+	if endpoint.Scheme != "" {
+		r.URL.Scheme = endpoint.Scheme
+	}
+	r.Host = ""
+
+	if retry && req.Body != nil && req.Body != http.NoBody {
+		if req.GetBody == nil {
+			return nil, errors.New("request body cannot be sent again")
+		}
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, fmt.Errorf("error getting request body: %w", err)
+		}
+		r.Body = body
+	}
+
+	return r, nil
+}
+
+// connectError reports whether the error is a failure to connect, so the
+// request was not sent.
+func connectError(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+func containsStatusCode(statusCodes []int, statusCode int) bool {
+	for _, s := range statusCodes {
+		if s == statusCode {
+			return true
+		}
+	}
+	return false
+}
+
+// ResponseEndpoint returns the host of the endpoint that the response is
+// from.
+func ResponseEndpoint(resp *http.Response) string {
+	if resp == nil || resp.Request == nil {
+		return ""
+	}
+	return resp.Request.URL.Host
+}
+
+// WithLoadBalancer sends the requests of the Request's client to the endpoints
+// of the LoadBalancer. It cannot be combined with authenticators that sign the
+// host.
+func (o *Request) WithLoadBalancer(balancer *LoadBalancer) *Request {
+	return o.WithMiddleware(balancer.Middleware)
+}
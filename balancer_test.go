@@ -0,0 +1,228 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestLoadBalancer_choose(t *testing.T) {
+	tests := []struct {
+		name      string
+		balancer  *LoadBalancer
+		inFlight  []int
+		ejected   []bool
+		tried     []bool
+		wantOrder []int
+	}{
+		{
+			name: "success round-robin",
+			balancer: &LoadBalancer{
+				Endpoints: []Endpoint{{Host: "a"}, {Host: "b"}, {Host: "c"}},
+			},
+			wantOrder: []int{0, 1, 2, 0, 1},
+		},
+		{
+			name: "success round-robin skips ejected",
+			balancer: &LoadBalancer{
+				Endpoints: []Endpoint{{Host: "a"}, {Host: "b"}, {Host: "c"}},
+			},
+			ejected:   []bool{false, true, false},
+			wantOrder: []int{0, 2, 0},
+		},
+		{
+			name: "success all ejected",
+			balancer: &LoadBalancer{
+				Endpoints: []Endpoint{{Host: "a"}, {Host: "b"}},
+			},
+			ejected:   []bool{true, true},
+			wantOrder: []int{0, 1},
+		},
+		{
+			name: "success skips tried",
+			balancer: &LoadBalancer{
+				Endpoints: []Endpoint{{Host: "a"}, {Host: "b"}, {Host: "c"}},
+			},
+			tried:     []bool{true, false, false},
+			wantOrder: []int{1, 2, 1},
+		},
+		{
+			name: "success least outstanding",
+			balancer: &LoadBalancer{
+				Endpoints: []Endpoint{{Host: "a"}, {Host: "b"}, {Host: "c"}},
+				Strategy:  BalanceLeastOutstanding,
+			},
+			inFlight:  []int{2, 0, 1},
+			wantOrder: []int{1, 1, 2},
+		},
+		{
+			name: "success weighted",
+			balancer: &LoadBalancer{
+				Endpoints: []Endpoint{{Host: "a", Weight: 1000000}, {Host: "b", Weight: 0}},
+				Strategy:  BalanceWeighted,
+			},
+			ejected:   []bool{false, false},
+			wantOrder: []int{0, 0, 0},
+		},
+		{
+			name: "success random",
+			balancer: &LoadBalancer{
+				Endpoints: []Endpoint{{Host: "a"}, {Host: "b"}},
+				Strategy:  BalanceRandom,
+			},
+			ejected:   []bool{true, false},
+			wantOrder: []int{1, 1, 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Unix(1600000000, 0)
+			o := tt.balancer
+			o.Now = func() time.Time { return now }
+			o.rand = rand.New(rand.NewSource(1))
+			o.ensureStates()
+			for i := range o.Endpoints {
+				if tt.inFlight != nil {
+					o.states[i].inFlight = tt.inFlight[i]
+				}
+				if tt.ejected != nil && tt.ejected[i] {
+					o.states[i].ejectedUntil = now.Add(time.Second)
+				}
+			}
+
+			tried := tt.tried
+			if tried == nil {
+				tried = make([]bool, len(o.Endpoints))
+			}
+			var got []int
+			for range tt.wantOrder {
+				got = append(got, o.choose(tried))
+			}
+			if !reflect.DeepEqual(got, tt.wantOrder) {
+				t.Errorf("LoadBalancer.choose() = %v, want %v", got, tt.wantOrder)
+			}
+		})
+	}
+}
+
+func TestRequest_WithLoadBalancer(t *testing.T) {
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		if r.URL.Path == "/unavailable" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+	down := httptest.NewServer(http.NotFoundHandler())
+	downHost := down.Listener.Addr().String()
+	down.Close()
+	up := server.Listener.Addr().String()
+
+	now := time.Unix(1600000000, 0)
+	balancer := &LoadBalancer{
+		Endpoints:           []Endpoint{{Host: downHost}, {Host: up}},
+		FailoverStatusCodes: []int{http.StatusServiceUnavailable},
+		EjectDuration:       time.Minute,
+		Now:                 func() time.Time { return now },
+	}
+	base, err := NewRequest().WithMethod(http.MethodPut).WithLoadBalancer(balancer).FromURLString("http://service.internal/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := base.Clone().WithRequestBody([]byte("body")).Do()
+	if err != nil {
+		t.Fatalf("Request.Do() error = %v", err)
+	}
+	if got := ResponseEndpoint(resp); got != up {
+		t.Errorf("ResponseEndpoint() = %v, want %v", got, up)
+	}
+	if !reflect.DeepEqual(bodies, []string{"body"}) {
+		t.Errorf("server got bodies %q, want %q", bodies, []string{"body"})
+	}
+	if got := balancer.State()[0].EjectedUntil; !got.Equal(now.Add(time.Minute)) {
+		t.Errorf("LoadBalancer.State() ejected until = %v, want %v", got, now.Add(time.Minute))
+	}
+
+	// Only the ejected endpoint is left after a failover status.
+	_, err = base.Clone().WithPath("/unavailable").Do()
+	var endpointErr *EndpointError
+	if !errors.As(err, &endpointErr) || endpointErr.Endpoint != downHost {
+		t.Errorf("Request.Do() error = %v, want %T for %v", err, endpointErr, downHost)
+	}
+}
+
+func TestLoadBalancer_Middleware_body(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		<-release
+	}))
+	defer server.Close()
+
+	balancer := &LoadBalancer{
+		Endpoints: []Endpoint{{Host: server.Listener.Addr().String()}},
+	}
+	req, err := http.NewRequest(http.MethodGet, "http://service.internal/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := balancer.Middleware(http.DefaultTransport).RoundTrip(req)
+	if err != nil {
+		t.Fatalf("LoadBalancer.Middleware() error = %v", err)
+	}
+	if got := balancer.State()[0].InFlight; got != 1 {
+		t.Errorf("LoadBalancer.State() in flight = %v while reading body, want 1", got)
+	}
+
+	close(release)
+	if _, err := ioutil.ReadAll(resp.Body); err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if got := balancer.State()[0].InFlight; got != 0 {
+		t.Errorf("LoadBalancer.State() in flight = %v after reading body, want 0", got)
+	}
+}
+
+func TestLoadBalancer_Middleware_cancel(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	balancer := &LoadBalancer{
+		Endpoints: []Endpoint{{Host: server.Listener.Addr().String()}},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://service.internal/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := balancer.Middleware(http.DefaultTransport).RoundTrip(req); err == nil {
+		t.Fatalf("LoadBalancer.Middleware() error = nil, want context error")
+	}
+
+	// A cancelled request is not a failure of the endpoint.
+	state := balancer.State()[0]
+	if state.InFlight != 0 || state.Failures != 0 || !state.EjectedUntil.IsZero() {
+		t.Errorf("LoadBalancer.State() = %+v, want no failures", state)
+	}
+}
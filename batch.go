@@ -0,0 +1,175 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+)
+
+const defaultBatchConcurrency = 10
+
+// BatchOptions are options to use when making a batch of HTTP requests.
+type BatchOptions struct {
+	// Concurrency is the number of requests made at once, 10 if zero.
+	Concurrency int
+
+	// FailFast cancels the remaining requests after the first error, rather
+	// than making all of them.
+	FailFast bool
+
+	// Progress, if set, is called serially after each request with the number
+	// of completed requests and the total, -1 if unknown.
+	Progress func(completed, total int)
+
+	// Options are the options of each request.
+	Options []*DoOptions
+}
+
+// BatchResult is the result of a request of a batch.
+type BatchResult struct {
+	Request  *Request
+	Response *http.Response
+	Err      error
+}
+
+// BatchError represents the failed requests of a batch.
+type BatchError struct {
+	// Failed are the indexes of the failed requests.
+	Failed []int
+	Total  int
+
+	// Err is the first error to occur.
+	Err error
+}
+
+func (o *BatchError) Error() string {
+	return fmt.Sprintf("%d of %d requests failed: %v", len(o.Failed), o.Total, o.Err)
+}
+
+func (o *BatchError) Unwrap() error {
+	return o.Err
+}
+
+// DoBatch makes the HTTP requests concurrently, returning their results in the
+// same order and a *BatchError if any failed.
+//
+// Each request must be distinct, such as clones of a base Request. The body of
+// each response is closed after it is decoded into the response body of its
+// request. Requests not made due to cancellation fail with the context error.
+func DoBatch(ctx context.Context, requests []*Request, options *BatchOptions) ([]BatchResult, error) {
+	i := 0
+	next := func(ctx context.Context) (*Request, bool) {
+		if i == len(requests) {
+			return nil, false
+		}
+		i++
+		return requests[i-1], true
+	}
+
+	return doBatch(ctx, next, len(requests), options)
+}
+
+// DoBatchChan is DoBatch for requests received from a channel until it is
+// closed or the context is done.
+func DoBatchChan(ctx context.Context, requests <-chan *Request, options *BatchOptions) ([]BatchResult, error) {
+	next := func(ctx context.Context) (*Request, bool) {
+		select {
+		case req, ok := <-requests:
+			return req, ok
+		case <-ctx.Done():
+			return nil, false
+		}
+	}
+
+	return doBatch(ctx, next, -1, options)
+}
+
+type batchJob struct {
+	index   int
+	request *Request
+}
+
+func doBatch(ctx context.Context, next func(ctx context.Context) (*Request, bool), total int, options *BatchOptions) ([]BatchResult, error) {
+	if options == nil {
+		options = &BatchOptions{}
+	}
+	concurrency := options.Concurrency
+	if concurrency < 1 {
+		concurrency = defaultBatchConcurrency
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var mu sync.Mutex
+	var results []BatchResult
+	var batchErr *BatchError
+	completed := 0
+
+	jobs := make(chan batchJob)
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				result := doBatchRequest(ctx, job.request, options.Options)
+
+				mu.Lock()
+				results[job.index] = result
+				completed++
+				if result.Err != nil {
+					if batchErr == nil {
+						batchErr = &BatchError{Err: result.Err}
+						if options.FailFast {
+							cancel()
+						}
+					}
+					batchErr.Failed = append(batchErr.Failed, job.index)
+				}
+				if options.Progress != nil {
+					options.Progress(completed, total)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for i := 0; ; i++ {
+		req, ok := next(ctx)
+		if !ok {
+			break
+		}
+
+		mu.Lock()
+		results = append(results, BatchResult{})
+		mu.Unlock()
+
+		jobs <- batchJob{index: i, request: req}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if batchErr != nil {
+		sort.Ints(batchErr.Failed)
+		batchErr.Total = len(results)
+		return results, batchErr
+	}
+	return results, nil
+}
+
+func doBatchRequest(ctx context.Context, req *Request, options []*DoOptions) BatchResult {
+	result := BatchResult{Request: req}
+	if err := ctx.Err(); err != nil {
+		result.Err = err
+		return result
+	}
+
+	result.Response, result.Err = req.DoContext(ctx, options...)
+	if result.Response != nil {
+		result.Response.Body.Close()
+	}
+	return result
+}
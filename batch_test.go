@@ -0,0 +1,167 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDoBatch(t *testing.T) {
+	var inFlight, maxInFlight int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			m := atomic.LoadInt32(&maxInFlight)
+			if n <= m || atomic.CompareAndSwapInt32(&maxInFlight, m, n) {
+				break
+			}
+		}
+
+		id := strings.TrimPrefix(r.URL.Path, "/users/")
+		if id == "3" || id == "5" {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{}`)
+			return
+		}
+		// Later requests finish first.
+		i, _ := strconv.Atoi(id)
+		time.Sleep(time.Duration(10-i) * time.Millisecond)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"id":%q}`, id)
+	}))
+	defer server.Close()
+
+	base, err := NewRequest().WithMethod(http.MethodGet).AddHeader("Accept", "application/json").FromURLString(server.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type user struct {
+		ID string `json:"id"`
+	}
+	tests := []struct {
+		name       string
+		options    *BatchOptions
+		wantUsers  []string
+		wantFailed []int
+		wantMax    int32
+	}{
+		{
+			name:       "success collect all",
+			options:    &BatchOptions{Concurrency: 3},
+			wantUsers:  []string{"0", "1", "2", "", "4", "", "6", "7"},
+			wantFailed: []int{3, 5},
+			wantMax:    3,
+		},
+		{
+			name:       "success fail fast",
+			options:    &BatchOptions{Concurrency: 1, FailFast: true},
+			wantUsers:  []string{"0", "1", "2", "", "", "", "", ""},
+			wantFailed: []int{3, 4, 5, 6, 7},
+			wantMax:    1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			atomic.StoreInt32(&maxInFlight, 0)
+
+			users := make([]user, 8)
+			var requests []*Request
+			for i := range users {
+				requests = append(requests, base.Clone().WithPath(fmt.Sprintf("/users/%d", i)).WithResponseBody(&users[i]))
+			}
+			var progress []int
+			tt.options.Progress = func(completed, total int) {
+				if total != len(requests) {
+					t.Errorf("BatchOptions.Progress() total = %v, want %v", total, len(requests))
+				}
+				progress = append(progress, completed)
+			}
+
+			results, err := DoBatch(context.Background(), requests, tt.options)
+
+			var batchErr *BatchError
+			if !errors.As(err, &batchErr) {
+				t.Fatalf("DoBatch() error = %v, want %T", err, batchErr)
+			}
+			if !reflect.DeepEqual(batchErr.Failed, tt.wantFailed) {
+				t.Errorf("DoBatch() failed = %v, want %v", batchErr.Failed, tt.wantFailed)
+			}
+			var statusCodeErr *StatusCodeError
+			if !errors.As(err, &statusCodeErr) || statusCodeErr.StatusCode != http.StatusNotFound {
+				t.Errorf("DoBatch() error = %v, want %T", err, statusCodeErr)
+			}
+			if len(results) != len(requests) {
+				t.Fatalf("DoBatch() results = %v, want %v", len(results), len(requests))
+			}
+			for i, result := range results {
+				if result.Request != requests[i] {
+					t.Errorf("DoBatch() result %d has request of another index", i)
+				}
+				if users[i].ID != tt.wantUsers[i] {
+					t.Errorf("DoBatch() user %d = %q, want %q", i, users[i].ID, tt.wantUsers[i])
+				}
+			}
+			if tt.options.FailFast && !errors.Is(results[7].Err, context.Canceled) {
+				t.Errorf("DoBatch() result error = %v, want %v", results[7].Err, context.Canceled)
+			}
+			if got := atomic.LoadInt32(&maxInFlight); got > tt.wantMax {
+				t.Errorf("DoBatch() concurrency = %v, want at most %v", got, tt.wantMax)
+			}
+			if len(progress) != len(requests) || progress[len(progress)-1] != len(requests) {
+				t.Errorf("BatchOptions.Progress() = %v", progress)
+			}
+		})
+	}
+}
+
+func TestDoBatchChan(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	base, err := NewRequest().WithMethod(http.MethodGet).FromURLString(server.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	requests := make(chan *Request)
+	go func() {
+		defer close(requests)
+		for i := 0; i < 5; i++ {
+			requests <- base.Clone()
+		}
+	}()
+
+	var totals []int
+	results, err := DoBatchChan(context.Background(), requests, &BatchOptions{
+		Progress: func(completed, total int) {
+			totals = append(totals, total)
+		},
+	})
+	if err != nil {
+		t.Fatalf("DoBatchChan() error = %v", err)
+	}
+	if len(results) != 5 {
+		t.Errorf("DoBatchChan() results = %v, want 5", len(results))
+	}
+	if !reflect.DeepEqual(totals, []int{-1, -1, -1, -1, -1}) {
+		t.Errorf("BatchOptions.Progress() totals = %v", totals)
+	}
+
+	// A cancelled batch stops receiving requests.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	results, err = DoBatchChan(ctx, make(chan *Request), nil)
+	if err != nil || len(results) != 0 {
+		t.Errorf("DoBatchChan() = %v, %v, want no results", results, err)
+	}
+}
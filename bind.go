@@ -0,0 +1,265 @@
+package http
+
+import (
+	"encoding"
+	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var pathVariableRegexp = regexp.MustCompile(`\{([^{}/]+)\}`)
+
+// Bind sets the path, query, header, and request body of the Request from the
+// tagged fields of v, which must be a struct or a pointer to a struct.
+//
+// Fields tagged `path:"name"` replace "{name}" in the path of the Request.
+// Fields tagged `query:"name"` and `header:"name"` are added to the query and
+// header of the Request, and are skipped when zero if the tag has the
+// ",omitempty" option. The field tagged `body:""` becomes the request body; a
+// non-empty tag value such as `body:"application/json"` also sets the
+// "Content-Type" of the Request.
+//
+// Path variables are escaped as a single path segment, so a value containing
+// "/" sets the raw path of the Request. An error is returned if a path
+// variable is missing from v, has no value, or is "." or "..". The Request is
+// left unchanged on error.
+func (o *Request) Bind(v interface{}) (*Request, error) {
+	c := o.Clone()
+	if err := c.bind(v); err != nil {
+		return nil, err
+	}
+
+	*o = *c
+	return o, nil
+}
+
+func (o *Request) bind(v interface{}) error {
+	pathValues := map[string]string{}
+
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	switch rv.Kind() {
+	case reflect.Invalid, reflect.Ptr:
+	case reflect.Struct:
+		if err := o.bindStruct(rv, pathValues); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("must provide struct, got %s", rv.Kind())
+	}
+
+	var missing []string
+	used := map[string]bool{}
+	var path, rawPath strings.Builder
+	last := 0
+	for _, loc := range pathVariableRegexp.FindAllStringSubmatchIndex(o.Path, -1) {
+		literal := o.Path[last:loc[0]]
+		path.WriteString(literal)
+		rawPath.WriteString((&url.URL{Path: literal}).EscapedPath())
+		last = loc[1]
+
+		name := o.Path[loc[2]:loc[3]]
+		value, ok := pathValues[name]
+		if !ok {
+			missing = append(missing, name)
+			continue
+		}
+		used[name] = true
+		path.WriteString(value)
+		rawPath.WriteString(url.PathEscape(value))
+	}
+	path.WriteString(o.Path[last:])
+	rawPath.WriteString((&url.URL{Path: o.Path[last:]}).EscapedPath())
+
+	if len(missing) > 0 {
+		return fmt.Errorf("missing path variable %q", missing[0])
+	}
+	for name := range pathValues {
+		if !used[name] {
+			return fmt.Errorf("path variable %q not found in path %q", name, o.Path)
+		}
+	}
+
+	o.WithPath(path.String())
+	if rawPath.String() != (&url.URL{Path: o.Path}).EscapedPath() {
+		o.RawPath = rawPath.String()
+	}
+	return nil
+}
+
+func (o *Request) bindStruct(rv reflect.Value, pathValues map[string]string) error {
+	rt := rv.Type()
+
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		value := rv.Field(i)
+
+		if field.PkgPath != "" && !field.Anonymous {
+			continue
+		}
+
+		if tag, ok := field.Tag.Lookup("path"); ok {
+			name, _ := parseBindTag(tag, field.Name)
+			if name == "-" {
+				continue
+			}
+			values, err := formatBindValue(value)
+			if err != nil {
+				return fmt.Errorf("error formatting path variable %q: %w", name, err)
+			}
+			if len(values) != 1 || values[0] == "" {
+				return fmt.Errorf("missing value for path variable %q", name)
+			}
+			if values[0] == "." || values[0] == ".." {
+				return fmt.Errorf("invalid value %q for path variable %q", values[0], name)
+			}
+			pathValues[name] = values[0]
+			continue
+		}
+
+		if tag, ok := field.Tag.Lookup("query"); ok {
+			name, omitEmpty := parseBindTag(tag, field.Name)
+			if name == "-" || (omitEmpty && value.IsZero()) {
+				continue
+			}
+			values, err := formatBindValue(value)
+			if err != nil {
+				return fmt.Errorf("error formatting query %q: %w", name, err)
+			}
+			for _, v := range values {
+				o.AddQuery(name, v)
+			}
+			continue
+		}
+
+		if tag, ok := field.Tag.Lookup("header"); ok {
+			name, omitEmpty := parseBindTag(tag, field.Name)
+			if name == "-" || (omitEmpty && value.IsZero()) {
+				continue
+			}
+			values, err := formatBindValue(value)
+			if err != nil {
+				return fmt.Errorf("error formatting header %q: %w", name, err)
+			}
+			for _, v := range values {
+				o.AddHeader(name, v)
+			}
+			continue
+		}
+
+		if contentType, ok := field.Tag.Lookup("body"); ok {
+			if value.Kind() == reflect.Ptr && value.IsNil() {
+				continue
+			}
+			if !value.CanInterface() {
+				return fmt.Errorf("cannot bind unexported body field %q", field.Name)
+			}
+			o.WithRequestBody(value.Interface())
+			if contentType != "" {
+				o.ensureHeader()
+				o.Header.Set("Content-Type", contentType)
+			}
+			continue
+		}
+
+		if field.Anonymous {
+			for value.Kind() == reflect.Ptr {
+				if value.IsNil() {
+					break
+				}
+				value = value.Elem()
+			}
+			if value.Kind() == reflect.Struct {
+				if err := o.bindStruct(value, pathValues); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func parseBindTag(tag, fieldName string) (string, bool) {
+	parts := strings.Split(tag, ",")
+
+	name := parts[0]
+	if name == "" {
+		name = fieldName
+	}
+
+	omitEmpty := false
+	for _, option := range parts[1:] {
+		if option == "omitempty" {
+			omitEmpty = true
+		}
+	}
+
+	return name, omitEmpty
+}
+
+var textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+func formatBindValue(v reflect.Value) ([]string, error) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil, nil
+		}
+		if v.Type().Implements(textMarshalerType) {
+			break
+		}
+		v = v.Elem()
+	}
+
+	if v.Type().Implements(textMarshalerType) {
+		if !v.CanInterface() {
+			return nil, fmt.Errorf("cannot marshal unexported field of type %s", v.Type())
+		}
+		text, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return nil, err
+		}
+		return []string{string(text)}, nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return []string{v.String()}, nil
+	case reflect.Bool:
+		return []string{strconv.FormatBool(v.Bool())}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return []string{strconv.FormatInt(v.Int(), 10)}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return []string{strconv.FormatUint(v.Uint(), 10)}, nil
+	case reflect.Float32:
+		return []string{strconv.FormatFloat(v.Float(), 'f', -1, 32)}, nil
+	case reflect.Float64:
+		return []string{strconv.FormatFloat(v.Float(), 'f', -1, 64)}, nil
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 && v.Kind() == reflect.Slice {
+			return []string{string(v.Bytes())}, nil
+		}
+		var values []string
+		for i := 0; i < v.Len(); i++ {
+			elem, err := formatBindValue(v.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, elem...)
+		}
+		return values, nil
+	default:
+		return nil, fmt.Errorf("unsupported type %s", v.Type())
+	}
+}
+
+// NewRequestFrom creates a new Request with the method and path template,
+// bound from the tagged fields of v.
+func NewRequestFrom(method, pathTemplate string, v interface{}) (*Request, error) {
+	return NewRequest().WithMethod(method).WithPath(pathTemplate).Bind(v)
+}
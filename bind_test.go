@@ -0,0 +1,377 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type mockBindParams struct {
+	ID      string     `path:"id"`
+	Limit   int        `query:"limit,omitempty"`
+	Tags    []string   `query:"tag"`
+	Since   *time.Time `query:"since"`
+	Trace   string     `header:"X-Trace,omitempty"`
+	Body    *mockBody  `body:"application/json"`
+	ignored string
+}
+
+type mockBindEmbedded struct {
+	mockBindVersion
+	Name string `path:"name"`
+}
+
+type mockBindVersion struct {
+	Version string `path:"version"`
+}
+
+type mockBindID string
+
+func (o mockBindID) MarshalText() ([]byte, error) {
+	return []byte(o), nil
+}
+
+func TestRequest_Bind(t *testing.T) {
+	since := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	type fields struct {
+		Path   string
+		Query  url.Values
+		Header http.Header
+	}
+	type args struct {
+		v interface{}
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    *Request
+		wantErr bool
+	}{
+		{
+			name: "success path",
+			fields: fields{
+				Path: "/api/v1/users/{id}",
+			},
+			args: args{
+				v: &mockBindParams{
+					ID: "123",
+				},
+			},
+			want: &Request{
+				Path: "/api/v1/users/123",
+			},
+		},
+		{
+			name: "success query header and body",
+			fields: fields{
+				Path: "/api/v1/users/{id}",
+			},
+			args: args{
+				v: mockBindParams{
+					ID:    "123",
+					Limit: 10,
+					Tags:  []string{"foo", "bar"},
+					Since: &since,
+					Trace: "abc",
+					Body: &mockBody{
+						Name: "bob",
+					},
+				},
+			},
+			want: &Request{
+				Path: "/api/v1/users/123",
+				Query: url.Values{
+					"limit": []string{"10"},
+					"tag":   []string{"foo", "bar"},
+					"since": []string{"2020-01-02T03:04:05Z"},
+				},
+				Header: http.Header{
+					"X-Trace":      []string{"abc"},
+					"Content-Type": []string{"application/json"},
+				},
+				RequestBody: &mockBody{
+					Name: "bob",
+				},
+			},
+		},
+		{
+			name: "success existing query",
+			fields: fields{
+				Path: "/api/v1/users/{id}",
+				Query: url.Values{
+					"foo": []string{"bar"},
+				},
+			},
+			args: args{
+				v: &mockBindParams{
+					ID:    "123",
+					Limit: 10,
+				},
+			},
+			want: &Request{
+				Path: "/api/v1/users/123",
+				Query: url.Values{
+					"foo":   []string{"bar"},
+					"limit": []string{"10"},
+				},
+			},
+		},
+		{
+			name: "success embedded struct",
+			fields: fields{
+				Path: "/api/{version}/users/{name}",
+			},
+			args: args{
+				v: &mockBindEmbedded{
+					mockBindVersion: mockBindVersion{
+						Version: "v1",
+					},
+					Name: "bob",
+				},
+			},
+			want: &Request{
+				Path: "/api/v1/users/bob",
+			},
+		},
+		{
+			name: "success nil without path variables",
+			fields: fields{
+				Path: "/api/v1/users",
+			},
+			want: &Request{
+				Path: "/api/v1/users",
+			},
+		},
+		{
+			name: "error missing path variable value",
+			fields: fields{
+				Path: "/api/v1/users/{id}",
+			},
+			args: args{
+				v: &mockBindParams{},
+			},
+			wantErr: true,
+		},
+		{
+			name: "success path variable escaped",
+			fields: fields{
+				Path: "/api/v1/users/{id}/profile",
+			},
+			args: args{
+				v: &mockBindParams{
+					ID: "../admin/x",
+				},
+			},
+			want: &Request{
+				Path:    "/api/v1/users/../admin/x/profile",
+				RawPath: "/api/v1/users/..%2Fadmin%2Fx/profile",
+			},
+		},
+		{
+			name: "success path variable with space",
+			fields: fields{
+				Path: "/api/v1/users/{id}",
+			},
+			args: args{
+				v: &mockBindParams{
+					ID: "a b",
+				},
+			},
+			want: &Request{
+				Path: "/api/v1/users/a b",
+			},
+		},
+		{
+			name: "error path variable dot segment",
+			fields: fields{
+				Path: "/api/v1/users/{id}/profile",
+			},
+			args: args{
+				v: &mockBindParams{
+					ID: "..",
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "error path variable not in struct",
+			fields: fields{
+				Path: "/api/v1/users/{id}/{other}",
+			},
+			args: args{
+				v: &mockBindParams{
+					ID: "123",
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "error path variable not in path",
+			fields: fields{
+				Path: "/api/v1/users",
+			},
+			args: args{
+				v: &mockBindParams{
+					ID: "123",
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "error nil with path variables",
+			fields: fields{
+				Path: "/api/v1/users/{id}",
+			},
+			wantErr: true,
+		},
+		{
+			name: "error unexported embedded text marshaler",
+			fields: fields{
+				Path: "/api/v1/users/{id}",
+			},
+			args: args{
+				v: struct {
+					mockBindID `path:"id"`
+				}{"123"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "error unexported embedded body",
+			fields: fields{
+				Path: "/api/v1/users",
+			},
+			args: args{
+				v: struct {
+					*mockBody `body:""`
+				}{&mockBody{Name: "bob"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "error not struct",
+			fields: fields{
+				Path: "/api/v1/users",
+			},
+			args: args{
+				v: "foo",
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := &Request{
+				Path:   tt.fields.Path,
+				Query:  tt.fields.Query,
+				Header: tt.fields.Header,
+			}
+			got, err := o.Bind(tt.args.v)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Request.Bind() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Request.Bind() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequest_Bind_unchanged(t *testing.T) {
+	o := NewRequest().WithPath("/api/v1/users")
+	want := o.Clone()
+
+	if _, err := o.Bind(&mockBindParams{ID: "123", Limit: 10, Trace: "abc"}); err == nil {
+		t.Fatalf("Request.Bind() error = nil, want path variable error")
+	}
+	if !reflect.DeepEqual(o, want) {
+		t.Errorf("Request.Bind() changed Request to %v, want %v", o, want)
+	}
+}
+
+func TestRequest_Bind_escapedURL(t *testing.T) {
+	var gotPath string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.EscapedPath()
+	}))
+	defer server.Close()
+
+	o, err := NewRequest().WithMethod(http.MethodGet).FromURLString(server.URL + "/users/{id}/profile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o, err = o.Bind(&mockBindParams{ID: "../admin/x"}); err != nil {
+		t.Fatalf("Request.Bind() error = %v", err)
+	}
+
+	u, err := o.URL()
+	if err != nil {
+		t.Fatalf("Request.URL() error = %v", err)
+	}
+	if got, want := u.String(), server.URL+"/users/..%2Fadmin%2Fx/profile"; got != want {
+		t.Errorf("Request.URL() = %v, want %v", got, want)
+	}
+
+	if _, err := o.Do(); err != nil {
+		t.Fatalf("Request.Do() error = %v", err)
+	}
+	if want := "/users/..%2Fadmin%2Fx/profile"; gotPath != want {
+		t.Errorf("server got path %v, want %v", gotPath, want)
+	}
+}
+
+func TestNewRequestFrom(t *testing.T) {
+	type args struct {
+		method       string
+		pathTemplate string
+		v            interface{}
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    *Request
+		wantErr bool
+	}{
+		{
+			name: "success",
+			args: args{
+				method:       http.MethodGet,
+				pathTemplate: "/api/v1/users/{id}",
+				v: &mockBindParams{
+					ID: "123",
+				},
+			},
+			want: &Request{
+				Method: http.MethodGet,
+				Path:   "/api/v1/users/123",
+			},
+		},
+		{
+			name: "error missing path variable",
+			args: args{
+				method:       http.MethodGet,
+				pathTemplate: "/api/v1/users/{id}",
+				v:            &mockBindParams{},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewRequestFrom(tt.args.method, tt.args.pathTemplate, tt.args.v)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewRequestFrom() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NewRequestFrom() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,337 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned for requests rejected by an open CircuitBreaker.
+var ErrCircuitOpen = errors.New("circuit open")
+
+// CircuitState is the state of a circuit of a CircuitBreaker.
+type CircuitState int
+
+const (
+	CircuitClosed CircuitState = iota
+	CircuitOpen
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+const (
+	defaultCircuitWindow      = 10 * time.Second
+	defaultCircuitOpenTimeout = 30 * time.Second
+	circuitWindowBuckets      = 10
+)
+
+// CircuitBreaker stops requests to a failing downstream, with a circuit for
+// each key, which is the host of the request by default. It is safe for
+// concurrent use.
+//
+// A closed circuit opens after ConsecutiveFailures failures in a row, or once
+// the failure rate over the rolling Window reaches FailureRate with at least
+// MinRequests requests. An open circuit rejects requests with ErrCircuitOpen
+// until OpenTimeout has passed, and then half-opens to allow HalfOpenProbes
+// requests, closing if they all succeed and opening again if any fails.
+type CircuitBreaker struct {
+	// Key returns the key of the request's circuit, its host if nil.
+	Key func(req *http.Request) string
+
+	ConsecutiveFailures int
+	FailureRate         float64
+	MinRequests         int
+	Window              time.Duration
+	OpenTimeout         time.Duration
+	HalfOpenProbes      int
+
+	// IsFailure returns whether the outcome of a request is a failure, where
+	// err is a transport error, a *StatusCodeError for a response with a
+	// status code above 2xx, or nil. If nil, transport errors and 5xx status
+	// codes are failures. Cancelled requests have no outcome.
+	IsFailure func(err error) bool
+
+	// OnStateChange is called when a circuit changes state.
+	OnStateChange func(key string, from, to CircuitState)
+
+	// Now returns the current time, time.Now if nil.
+	Now func() time.Time
+
+	mu       sync.Mutex
+	circuits map[string]*circuit
+}
+
+type circuit struct {
+	state       CircuitState
+	generation  int
+	consecutive int
+	buckets     []circuitBucket
+	openedAt    time.Time
+	probes      int
+	successes   int
+}
+
+type circuitBucket struct {
+	start    time.Time
+	total    int
+	failures int
+}
+
+// State returns the state of the circuit of the key.
+func (o *CircuitBreaker) State(key string) CircuitState {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if c, ok := o.circuits[key]; ok {
+		if c.state == CircuitOpen && !o.now().Before(c.openedAt.Add(o.openTimeout())) {
+			return CircuitHalfOpen
+		}
+		return c.state
+	}
+	return CircuitClosed
+}
+
+// Allow returns ErrCircuitOpen if a request with the key is rejected, or a
+// function to report the outcome of the request otherwise. Reporting
+// context.Canceled reports no outcome, and gives back the probe of a half-open
+// circuit.
+func (o *CircuitBreaker) Allow(key string) (func(err error), error) {
+	o.mu.Lock()
+	c := o.circuit(key)
+	now := o.now()
+
+	var changes []CircuitState
+	if c.state == CircuitOpen && !now.Before(c.openedAt.Add(o.openTimeout())) {
+		changes = append(changes, c.state, CircuitHalfOpen)
+		o.transition(c, CircuitHalfOpen, now)
+	}
+
+	switch {
+	case c.state == CircuitOpen, c.state == CircuitHalfOpen && c.probes >= o.halfOpenProbes():
+		o.mu.Unlock()
+		o.notify(key, changes)
+		return nil, ErrCircuitOpen
+	case c.state == CircuitHalfOpen:
+		c.probes++
+	}
+
+	generation := c.generation
+	o.mu.Unlock()
+	o.notify(key, changes)
+
+	return func(err error) {
+		if errors.Is(err, context.Canceled) {
+			o.release(key, generation)
+			return
+		}
+		o.report(key, generation, o.isFailure(err))
+	}, nil
+}
+
+// Middleware stops the requests of a client while their circuit is open.
+func (o *CircuitBreaker) Middleware(next http.RoundTripper) http.RoundTripper {
+	return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		done, err := o.Allow(o.key(req))
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := next.RoundTrip(req)
+		switch {
+		case err != nil:
+			done(err)
+		case resp.StatusCode/100 > 2:
+			done(&StatusCodeError{StatusCode: resp.StatusCode})
+		default:
+			done(nil)
+		}
+		return resp, err
+	})
+}
+
+func (o *CircuitBreaker) report(key string, generation int, failure bool) {
+	o.mu.Lock()
+	c := o.circuit(key)
+	now := o.now()
+
+	// Outcomes of requests allowed in an earlier state are ignored.
+	if c.generation != generation {
+		o.mu.Unlock()
+		return
+	}
+
+	var changes []CircuitState
+	switch c.state {
+	case CircuitHalfOpen:
+		if failure {
+			changes = append(changes, c.state, CircuitOpen)
+			o.transition(c, CircuitOpen, now)
+			break
+		}
+		c.successes++
+		if c.successes >= o.halfOpenProbes() {
+			changes = append(changes, c.state, CircuitClosed)
+			o.transition(c, CircuitClosed, now)
+		}
+	case CircuitClosed:
+		b := o.record(c, now)
+		b.total++
+		if failure {
+			b.failures++
+			c.consecutive++
+		} else {
+			c.consecutive = 0
+		}
+
+		if o.shouldTrip(c) {
+			changes = append(changes, c.state, CircuitOpen)
+			o.transition(c, CircuitOpen, now)
+		}
+	}
+	o.mu.Unlock()
+
+	o.notify(key, changes)
+}
+
+// release ends a request that was cancelled before it had an outcome.
+func (o *CircuitBreaker) release(key string, generation int) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	c := o.circuit(key)
+	if c.generation == generation && c.state == CircuitHalfOpen {
+		c.probes--
+	}
+}
+
+func (o *CircuitBreaker) shouldTrip(c *circuit) bool {
+	if o.ConsecutiveFailures > 0 && c.consecutive >= o.ConsecutiveFailures {
+		return true
+	}
+	if o.FailureRate <= 0 {
+		return false
+	}
+
+	total, failures := 0, 0
+	for _, b := range c.buckets {
+		total += b.total
+		failures += b.failures
+	}
+	return total > 0 && total >= o.MinRequests && float64(failures)/float64(total) >= o.FailureRate
+}
+
+// record returns the current bucket of the rolling window, dropping buckets
+// that have left the window.
+func (o *CircuitBreaker) record(c *circuit, now time.Time) *circuitBucket {
+	window := o.Window
+	if window <= 0 {
+		window = defaultCircuitWindow
+	}
+	width := window / circuitWindowBuckets
+
+	i := 0
+	for ; i < len(c.buckets) && !now.Before(c.buckets[i].start.Add(window)); i++ {
+	}
+	c.buckets = c.buckets[i:]
+
+	if n := len(c.buckets); n == 0 || !now.Before(c.buckets[n-1].start.Add(width)) {
+		c.buckets = append(c.buckets, circuitBucket{start: now})
+	}
+	return &c.buckets[len(c.buckets)-1]
+}
+
+func (o *CircuitBreaker) transition(c *circuit, state CircuitState, now time.Time) {
+	c.state = state
+	c.generation++
+	c.consecutive = 0
+	c.buckets = nil
+	c.probes = 0
+	c.successes = 0
+	if state == CircuitOpen {
+		c.openedAt = now
+	}
+}
+
+func (o *CircuitBreaker) notify(key string, changes []CircuitState) {
+	if o.OnStateChange == nil {
+		return
+	}
+	for i := 0; i+1 < len(changes); i += 2 {
+		o.OnStateChange(key, changes[i], changes[i+1])
+	}
+}
+
+func (o *CircuitBreaker) circuit(key string) *circuit {
+	if o.circuits == nil {
+		o.circuits = map[string]*circuit{}
+	}
+	c, ok := o.circuits[key]
+	if !ok {
+		c = &circuit{}
+		o.circuits[key] = c
+	}
+	return c
+}
+
+func (o *CircuitBreaker) isFailure(err error) bool {
+	if o.IsFailure != nil {
+		return o.IsFailure(err)
+	}
+	if err == nil {
+		return false
+	}
+
+	var statusCodeErr *StatusCodeError
+	if errors.As(err, &statusCodeErr) {
+		return statusCodeErr.StatusCode/100 == 5
+	}
+	return true
+}
+
+func (o *CircuitBreaker) key(req *http.Request) string {
+	if o.Key != nil {
+		return o.Key(req)
+	}
+	return requestHost(req)
+}
+
+func (o *CircuitBreaker) openTimeout() time.Duration {
+	if o.OpenTimeout <= 0 {
+		return defaultCircuitOpenTimeout
+	}
+	return o.OpenTimeout
+}
+
+func (o *CircuitBreaker) halfOpenProbes() int {
+	if o.HalfOpenProbes < 1 {
+		return 1
+	}
+	return o.HalfOpenProbes
+}
+
+func (o *CircuitBreaker) now() time.Time {
+	if o.Now != nil {
+		return o.Now()
+	}
+	return time.Now()
+}
+
+// WithCircuitBreaker stops the requests of the Request's client with the
+// CircuitBreaker.
+func (o *Request) WithCircuitBreaker(breaker *CircuitBreaker) *Request {
+	return o.WithMiddleware(breaker.Middleware)
+}
@@ -0,0 +1,228 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker_Allow(t *testing.T) {
+	errTransport := errors.New("connection refused")
+
+	type step struct {
+		advance time.Duration
+		err     error
+		want    CircuitState
+		wantErr error
+	}
+	tests := []struct {
+		name    string
+		breaker *CircuitBreaker
+		steps   []step
+	}{
+		{
+			name:    "success consecutive failures",
+			breaker: &CircuitBreaker{ConsecutiveFailures: 2, OpenTimeout: time.Second},
+			steps: []step{
+				{err: errTransport, want: CircuitClosed},
+				{err: nil, want: CircuitClosed},
+				{err: errTransport, want: CircuitClosed},
+				{err: &StatusCodeError{StatusCode: 503}, want: CircuitOpen},
+				{wantErr: ErrCircuitOpen, want: CircuitOpen},
+				{advance: time.Second, err: nil, want: CircuitClosed},
+			},
+		},
+		{
+			name:    "success client errors are not failures",
+			breaker: &CircuitBreaker{ConsecutiveFailures: 1},
+			steps: []step{
+				{err: &StatusCodeError{StatusCode: 404}, want: CircuitClosed},
+				{err: &StatusCodeError{StatusCode: 500}, want: CircuitOpen},
+			},
+		},
+		{
+			name: "success failure rate",
+			breaker: &CircuitBreaker{
+				FailureRate: 0.5,
+				MinRequests: 4,
+				Window:      10 * time.Second,
+			},
+			steps: []step{
+				{err: errTransport, want: CircuitClosed},
+				{err: nil, want: CircuitClosed},
+				{err: errTransport, want: CircuitClosed},
+				{advance: 11 * time.Second, err: nil, want: CircuitClosed},
+				{err: nil, want: CircuitClosed},
+				{err: errTransport, want: CircuitClosed},
+				{err: errTransport, want: CircuitOpen},
+			},
+		},
+		{
+			name: "success half-open probe fails",
+			breaker: &CircuitBreaker{
+				ConsecutiveFailures: 1,
+				OpenTimeout:         time.Second,
+				HalfOpenProbes:      2,
+			},
+			steps: []step{
+				{err: errTransport, want: CircuitOpen},
+				{advance: time.Second, err: nil, want: CircuitHalfOpen},
+				{err: errTransport, want: CircuitOpen},
+				{advance: time.Second, err: nil, want: CircuitHalfOpen},
+				{err: nil, want: CircuitClosed},
+			},
+		},
+		{
+			name: "success custom failures",
+			breaker: &CircuitBreaker{
+				ConsecutiveFailures: 1,
+				IsFailure: func(err error) bool {
+					var statusCodeErr *StatusCodeError
+					return errors.As(err, &statusCodeErr) && statusCodeErr.StatusCode == http.StatusTooManyRequests
+				},
+			},
+			steps: []step{
+				{err: errTransport, want: CircuitClosed},
+				{err: &StatusCodeError{StatusCode: 429}, want: CircuitOpen},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Unix(1600000000, 0)
+			tt.breaker.Now = func() time.Time { return now }
+
+			for i, s := range tt.steps {
+				now = now.Add(s.advance)
+				done, err := tt.breaker.Allow("a")
+				if err != s.wantErr {
+					t.Fatalf("step %d: CircuitBreaker.Allow() error = %v, wantErr %v", i, err, s.wantErr)
+				}
+				if done != nil {
+					done(s.err)
+				}
+				if got := tt.breaker.State("a"); got != s.want {
+					t.Fatalf("step %d: CircuitBreaker.State() = %v, want %v", i, got, s.want)
+				}
+			}
+		})
+	}
+}
+
+func TestCircuitBreaker_Allow_halfOpenProbes(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	o := &CircuitBreaker{
+		ConsecutiveFailures: 1,
+		HalfOpenProbes:      1,
+		OpenTimeout:         time.Second,
+		Now:                 func() time.Time { return now },
+	}
+
+	done, _ := o.Allow("a")
+	// A request allowed while closed reports after the circuit opened.
+	late, _ := o.Allow("a")
+	done(errors.New("timeout"))
+	late(nil)
+	if got := o.State("a"); got != CircuitOpen {
+		t.Fatalf("CircuitBreaker.State() = %v, want %v", got, CircuitOpen)
+	}
+
+	now = now.Add(time.Second)
+	probe, err := o.Allow("a")
+	if err != nil {
+		t.Fatalf("CircuitBreaker.Allow() error = %v", err)
+	}
+	if _, err := o.Allow("a"); err != ErrCircuitOpen {
+		t.Errorf("CircuitBreaker.Allow() error = %v, want %v beyond probes", err, ErrCircuitOpen)
+	}
+	probe(nil)
+	if got := o.State("a"); got != CircuitClosed {
+		t.Errorf("CircuitBreaker.State() = %v, want %v", got, CircuitClosed)
+	}
+}
+
+func TestCircuitBreaker_Allow_cancel(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	o := &CircuitBreaker{
+		ConsecutiveFailures: 2,
+		HalfOpenProbes:      1,
+		OpenTimeout:         time.Second,
+		Now:                 func() time.Time { return now },
+	}
+
+	// A cancelled request does not reset the consecutive failures.
+	for _, err := range []error{errors.New("timeout"), context.Canceled, errors.New("timeout")} {
+		done, _ := o.Allow("a")
+		done(err)
+	}
+	if got := o.State("a"); got != CircuitOpen {
+		t.Fatalf("CircuitBreaker.State() = %v, want %v", got, CircuitOpen)
+	}
+
+	// A cancelled probe neither closes the circuit nor uses up the probe.
+	now = now.Add(time.Second)
+	probe, err := o.Allow("a")
+	if err != nil {
+		t.Fatalf("CircuitBreaker.Allow() error = %v", err)
+	}
+	probe(fmt.Errorf("error making http request: %w", context.Canceled))
+	if got := o.State("a"); got != CircuitHalfOpen {
+		t.Errorf("CircuitBreaker.State() = %v, want %v", got, CircuitHalfOpen)
+	}
+	probe, err = o.Allow("a")
+	if err != nil {
+		t.Fatalf("CircuitBreaker.Allow() error = %v after cancelled probe", err)
+	}
+	probe(errors.New("timeout"))
+	if got := o.State("a"); got != CircuitOpen {
+		t.Errorf("CircuitBreaker.State() = %v, want %v", got, CircuitOpen)
+	}
+}
+
+func TestRequest_WithCircuitBreaker(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	var changes []string
+	breaker := &CircuitBreaker{
+		Key:                 RouteKey,
+		ConsecutiveFailures: 2,
+		OnStateChange: func(key string, from, to CircuitState) {
+			changes = append(changes, fmt.Sprintf("%s: %s -> %s", key, from, to))
+		},
+	}
+	base, err := NewRequest().WithMethod(http.MethodGet).WithCircuitBreaker(breaker).FromURLString(server.URL + "/a")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		_, err = base.Clone().Do()
+	}
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Request.Do() error = %v, want %v", err, ErrCircuitOpen)
+	}
+	if calls != 2 {
+		t.Errorf("server called %d times, want 2", calls)
+	}
+
+	// Other routes have their own circuit.
+	var statusCodeErr *StatusCodeError
+	if _, err := base.Clone().WithPath("/b").Do(); !errors.As(err, &statusCodeErr) {
+		t.Errorf("Request.Do() error = %v, want %T", err, statusCodeErr)
+	}
+
+	want := []string{fmt.Sprintf("GET %s/a: closed -> open", server.Listener.Addr())}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("CircuitBreaker.OnStateChange() = %v, want %v", changes, want)
+	}
+}
@@ -0,0 +1,257 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+const defaultBulkheadMaxConcurrent = 10
+
+// BulkheadError is returned for requests rejected by a Bulkhead.
+type BulkheadError struct {
+	Key string
+
+	// Timeout is set if the request waited in the queue for too long, rather
+	// than finding the queue full.
+	Timeout bool
+}
+
+func (o *BulkheadError) Error() string {
+	if o.Timeout {
+		return fmt.Sprintf("bulkhead %s: timed out waiting in queue", o.Key)
+	}
+	return fmt.Sprintf("bulkhead %s: queue full", o.Key)
+}
+
+// Bulkhead limits the number of concurrent requests for each key, which is
+// the host of the request by default, queueing requests beyond the limit. It
+// is safe for concurrent use.
+//
+// If Adaptive is set, the limit of each key starts at MaxConcurrent and
+// follows AIMD: it halves, down to MinConcurrent, when a request fails with a
+// 503 or 429 status code or a timeout, or takes longer than LatencyThreshold,
+// and grows by one each time a full limit of requests succeed.
+type Bulkhead struct {
+	// Key returns the key of the request, its host if nil.
+	Key func(req *http.Request) string
+
+	MaxConcurrent int
+	MinConcurrent int
+
+	// MaxQueue is the number of requests that may wait for the limit, with
+	// further requests rejected immediately.
+	MaxQueue int
+
+	// QueueTimeout, if set, rejects requests that wait longer.
+	QueueTimeout time.Duration
+
+	Adaptive         bool
+	LatencyThreshold time.Duration
+
+	mu   sync.Mutex
+	keys map[string]*bulkheadKey
+}
+
+type bulkheadKey struct {
+	limit    float64
+	inFlight int
+	queue    []chan struct{}
+}
+
+// BulkheadState is the state of a key of a Bulkhead.
+type BulkheadState struct {
+	Key      string
+	Limit    int
+	InFlight int
+	Queued   int
+}
+
+// Acquire waits for a slot for a request with the key, and returns a function
+// to release it with the outcome of the request.
+func (o *Bulkhead) Acquire(ctx context.Context, key string) (func(resp *http.Response, err error, latency time.Duration), error) {
+	o.mu.Lock()
+	k := o.key(key)
+
+	if k.inFlight < int(k.limit) && len(k.queue) == 0 {
+		k.inFlight++
+		o.mu.Unlock()
+		return o.releaser(key), nil
+	}
+	if len(k.queue) >= o.MaxQueue {
+		o.mu.Unlock()
+		return nil, &BulkheadError{Key: key}
+	}
+
+	ready := make(chan struct{})
+	k.queue = append(k.queue, ready)
+	o.mu.Unlock()
+
+	var timeout <-chan time.Time
+	if o.QueueTimeout > 0 {
+		timer := time.NewTimer(o.QueueTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	var err error
+	select {
+	case <-ready:
+		return o.releaser(key), nil
+	case <-timeout:
+		err = &BulkheadError{Key: key, Timeout: true}
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	for i, ch := range k.queue {
+		if ch == ready {
+			k.queue = append(k.queue[:i], k.queue[i+1:]...)
+			return nil, err
+		}
+	}
+
+	// The slot was granted while giving up, so pass it on.
+	k.inFlight--
+	o.admit(k)
+	return nil, err
+}
+
+// Middleware limits the concurrent requests of a client. A request holds its
+// slot until its response body is read to the end or closed.
+func (o *Bulkhead) Middleware(next http.RoundTripper) http.RoundTripper {
+	return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		release, err := o.Acquire(req.Context(), o.requestKey(req))
+		if err != nil {
+			return nil, err
+		}
+
+		start := time.Now()
+		resp, err := next.RoundTrip(req)
+		if err != nil {
+			release(nil, err, time.Since(start))
+			return nil, err
+		}
+
+		resp.Body = &doneBody{ReadCloser: resp.Body, done: func(err error) {
+			release(resp, err, time.Since(start))
+		}}
+		return resp, nil
+	})
+}
+
+// State returns the state of each key, sorted by key.
+func (o *Bulkhead) State() []BulkheadState {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	states := make([]BulkheadState, 0, len(o.keys))
+	for key, k := range o.keys {
+		states = append(states, BulkheadState{
+			Key:      key,
+			Limit:    int(k.limit),
+			InFlight: k.inFlight,
+			Queued:   len(k.queue),
+		})
+	}
+	sort.Slice(states, func(i, j int) bool {
+		return states[i].Key < states[j].Key
+	})
+
+	return states
+}
+
+func (o *Bulkhead) releaser(key string) func(resp *http.Response, err error, latency time.Duration) {
+	var once sync.Once
+	return func(resp *http.Response, err error, latency time.Duration) {
+		once.Do(func() {
+			o.mu.Lock()
+			defer o.mu.Unlock()
+
+			k := o.key(key)
+			k.inFlight--
+			if o.Adaptive {
+				o.adapt(k, resp, err, latency)
+			}
+			o.admit(k)
+		})
+	}
+}
+
+func (o *Bulkhead) adapt(k *bulkheadKey, resp *http.Response, err error, latency time.Duration) {
+	overloaded := o.LatencyThreshold > 0 && latency > o.LatencyThreshold
+	if resp != nil && (resp.StatusCode == http.StatusServiceUnavailable || resp.StatusCode == http.StatusTooManyRequests) {
+		overloaded = true
+	}
+	var netErr net.Error
+	if err != nil && (errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout())) {
+		overloaded = true
+	}
+
+	if overloaded {
+		k.limit = math.Max(k.limit/2, float64(o.minConcurrent()))
+	} else if err == nil {
+		k.limit = math.Min(k.limit+1/k.limit, float64(o.maxConcurrent()))
+	}
+}
+
+// admit grants slots to queued requests while the limit allows.
+func (o *Bulkhead) admit(k *bulkheadKey) {
+	for len(k.queue) > 0 && k.inFlight < int(k.limit) {
+		ready := k.queue[0]
+		k.queue = k.queue[1:]
+		k.inFlight++
+		close(ready)
+	}
+}
+
+func (o *Bulkhead) key(key string) *bulkheadKey {
+	if o.keys == nil {
+		o.keys = map[string]*bulkheadKey{}
+	}
+	k, ok := o.keys[key]
+	if !ok {
+		k = &bulkheadKey{limit: float64(o.maxConcurrent())}
+		o.keys[key] = k
+	}
+	return k
+}
+
+func (o *Bulkhead) requestKey(req *http.Request) string {
+	if o.Key != nil {
+		return o.Key(req)
+	}
+	return requestHost(req)
+}
+
+func (o *Bulkhead) maxConcurrent() int {
+	if o.MaxConcurrent < 1 {
+		return defaultBulkheadMaxConcurrent
+	}
+	return o.MaxConcurrent
+}
+
+func (o *Bulkhead) minConcurrent() int {
+	if o.MinConcurrent < 1 {
+		return 1
+	}
+	if o.MinConcurrent > o.maxConcurrent() {
+		return o.maxConcurrent()
+	}
+	return o.MinConcurrent
+}
+
+// WithBulkhead limits the concurrent requests of the Request's client with the
+// Bulkhead.
+func (o *Request) WithBulkhead(bulkhead *Bulkhead) *Request {
+	return o.WithMiddleware(bulkhead.Middleware)
+}
@@ -0,0 +1,211 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestBulkhead_Acquire(t *testing.T) {
+	o := &Bulkhead{
+		MaxConcurrent: 1,
+		MaxQueue:      1,
+		QueueTimeout:  10 * time.Millisecond,
+	}
+
+	release, err := o.Acquire(context.Background(), "a")
+	if err != nil {
+		t.Fatalf("Bulkhead.Acquire() error = %v", err)
+	}
+
+	var bulkheadErr *BulkheadError
+	if _, err := o.Acquire(context.Background(), "a"); !errors.As(err, &bulkheadErr) || !bulkheadErr.Timeout {
+		t.Errorf("Bulkhead.Acquire() error = %v, want queue timeout", err)
+	}
+
+	queued := make(chan error)
+	go func() {
+		release, err := o.Acquire(context.Background(), "a")
+		if err == nil {
+			release(nil, nil, 0)
+		}
+		queued <- err
+	}()
+	for len(o.State()) == 0 || o.State()[0].Queued == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	if _, err := o.Acquire(context.Background(), "a"); !errors.As(err, &bulkheadErr) || bulkheadErr.Timeout {
+		t.Errorf("Bulkhead.Acquire() error = %v, want queue full", err)
+	}
+	if _, err := o.Acquire(context.Background(), "b"); err != nil {
+		t.Errorf("Bulkhead.Acquire() other key error = %v", err)
+	}
+
+	release(nil, nil, 0)
+	if err := <-queued; err != nil {
+		t.Errorf("Bulkhead.Acquire() queued error = %v", err)
+	}
+
+	want := BulkheadState{Key: "a", Limit: 1}
+	if got := o.State()[0]; !reflect.DeepEqual(got, want) {
+		t.Errorf("Bulkhead.State() = %+v, want %+v", got, want)
+	}
+}
+
+func TestBulkhead_Acquire_cancel(t *testing.T) {
+	o := &Bulkhead{MaxConcurrent: 1, MaxQueue: 1}
+
+	if _, err := o.Acquire(context.Background(), "a"); err != nil {
+		t.Fatalf("Bulkhead.Acquire() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := o.Acquire(ctx, "a"); err != context.DeadlineExceeded {
+		t.Errorf("Bulkhead.Acquire() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if got := o.State()[0].Queued; got != 0 {
+		t.Errorf("Bulkhead.State() queued = %v, want 0", got)
+	}
+}
+
+func TestBulkhead_adaptive(t *testing.T) {
+	unavailable := &http.Response{StatusCode: http.StatusServiceUnavailable}
+	ok := &http.Response{StatusCode: http.StatusOK}
+
+	type step struct {
+		resp    *http.Response
+		latency time.Duration
+		want    int
+	}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "success decrease on 503",
+			steps: []step{
+				{resp: unavailable, want: 4},
+				{resp: unavailable, want: 2},
+				{resp: unavailable, want: 2},
+			},
+		},
+		{
+			name: "success decrease on latency",
+			steps: []step{
+				{resp: ok, latency: time.Second, want: 4},
+			},
+		},
+		{
+			name: "success increase when healthy",
+			steps: []step{
+				{resp: unavailable, want: 4},
+				{resp: ok, want: 4},
+				{resp: ok, want: 4},
+				{resp: ok, want: 4},
+				{resp: ok, want: 4},
+				{resp: ok, want: 5},
+			},
+		},
+		{
+			name: "success capped at max",
+			steps: []step{
+				{resp: ok, want: 8},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := &Bulkhead{
+				MaxConcurrent:    8,
+				MinConcurrent:    2,
+				Adaptive:         true,
+				LatencyThreshold: 100 * time.Millisecond,
+			}
+			for i, step := range tt.steps {
+				release, err := o.Acquire(context.Background(), "a")
+				if err != nil {
+					t.Fatalf("Bulkhead.Acquire() error = %v", err)
+				}
+				release(step.resp, nil, step.latency)
+				if got := o.State()[0].Limit; got != step.want {
+					t.Errorf("step %d: Bulkhead.State() limit = %v, want %v", i, got, step.want)
+				}
+			}
+		})
+	}
+}
+
+func TestRequest_WithBulkhead(t *testing.T) {
+	started := make(chan struct{})
+	unblock := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-unblock
+	}))
+	defer server.Close()
+
+	bulkhead := &Bulkhead{MaxConcurrent: 1}
+	base, err := NewRequest().WithMethod(http.MethodGet).WithBulkhead(bulkhead).FromURLString(server.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error)
+	go func() {
+		_, err := base.Clone().Do()
+		done <- err
+	}()
+	<-started
+
+	var bulkheadErr *BulkheadError
+	var statusCodeErr *StatusCodeError
+	_, err = base.Clone().Do()
+	if !errors.As(err, &bulkheadErr) || errors.As(err, &statusCodeErr) {
+		t.Errorf("Request.Do() error = %v, want %T", err, bulkheadErr)
+	}
+
+	close(unblock)
+	if err := <-done; err != nil {
+		t.Errorf("Request.Do() error = %v", err)
+	}
+}
+
+func TestBulkhead_Middleware_body(t *testing.T) {
+	unblock := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("head"))
+		w.(http.Flusher).Flush()
+		<-unblock
+		w.Write([]byte("tail"))
+	}))
+	defer server.Close()
+	defer close(unblock)
+
+	bulkhead := &Bulkhead{MaxConcurrent: 1}
+	base, err := NewRequest().WithMethod(http.MethodGet).WithBulkhead(bulkhead).FromURLString(server.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := base.Clone().Do()
+	if err != nil {
+		t.Fatalf("Request.Do() error = %v", err)
+	}
+
+	// The slot is held while the body is streaming.
+	var bulkheadErr *BulkheadError
+	if _, err := base.Clone().Do(); !errors.As(err, &bulkheadErr) {
+		t.Errorf("Request.Do() error = %v, want %T", err, bulkheadErr)
+	}
+
+	resp.Body.Close()
+	if got := bulkhead.State()[0].InFlight; got != 0 {
+		t.Errorf("Bulkhead.State() in flight = %v, want 0", got)
+	}
+}
@@ -0,0 +1,505 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultCacheEntries = 1000
+
+// Cache is a HTTP cache per RFC 9111 for the GET and HEAD requests of a
+// client. It is safe for concurrent use.
+//
+// Fresh responses are served from the Storage. Stale responses are revalidated
+// with If-None-Match and If-Modified-Since, a 304 response being replaced with
+// the stored response, or served while revalidating or on error as allowed by
+// stale-while-revalidate and stale-if-error. Successful unsafe requests
+// invalidate the stored responses of their URL.
+//
+// Requests with their own conditional or Range headers bypass the cache.
+// Stored responses are only served to requests with the same Credentials as
+// the request they were stored for.
+type Cache struct {
+	// Storage stores responses, a MemoryStorage of 1000 entries if nil.
+	Storage Storage
+
+	// Shared makes the cache a shared cache, which does not store private
+	// responses and prefers s-maxage.
+	Shared bool
+
+	// Credentials returns the credentials of the request, or "" if it has
+	// none. If nil, they are the Authorization, Proxy-Authorization, and
+	// Cookie headers, and the headers set by the authenticator of a Request.
+	Credentials func(req *http.Request) string
+
+	// Now returns the current time, time.Now if nil.
+	Now func() time.Time
+
+	once    sync.Once
+	storage Storage
+}
+
+// cacheEntry is a stored response.
+type cacheEntry struct {
+	StatusCode int         `json:"statusCode"`
+	Header     http.Header `json:"header"`
+	Body       []byte      `json:"body"`
+
+	// Vary are the request headers named by the Vary header of the response.
+	Vary http.Header `json:"vary,omitempty"`
+
+	// Credentials is a hash of the credentials of the request.
+	Credentials string `json:"credentials,omitempty"`
+
+	RequestTime  time.Time `json:"requestTime"`
+	ResponseTime time.Time `json:"responseTime"`
+}
+
+// Middleware caches the responses of a client.
+func (o *Cache) Middleware(next http.RoundTripper) http.RoundTripper {
+	return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if req.Method != http.MethodGet && req.Method != http.MethodHead {
+			resp, err := next.RoundTrip(req)
+			if err == nil && !safeMethod(req.Method) && resp.StatusCode < 400 {
+				o.getStorage().Delete(cacheKey(http.MethodGet, req.URL))
+				o.getStorage().Delete(cacheKey(http.MethodHead, req.URL))
+			}
+			return resp, err
+		}
+
+		reqCC := parseCacheControl(req.Header)
+		if len(req.Header["Cache-Control"]) == 0 && req.Header.Get("Pragma") == "no-cache" {
+			reqCC["no-cache"] = ""
+		}
+		if _, ok := reqCC["no-store"]; ok || conditionalRequest(req) {
+			return next.RoundTrip(req)
+		}
+
+		key := cacheKey(req.Method, req.URL)
+		entry := o.load(key, req)
+		if entry == nil {
+			if _, ok := reqCC["only-if-cached"]; ok {
+				return gatewayTimeout(req), nil
+			}
+			return o.fetch(next, key, req)
+		}
+
+		cc := parseCacheControl(entry.Header)
+		age := entry.age(o.now())
+		lifetime := o.lifetime(entry, cc)
+
+		fresh := age < lifetime
+		if maxAge, ok := cacheSeconds(reqCC, "max-age"); ok && age > maxAge {
+			fresh = false
+		}
+		_, reqNoCache := reqCC["no-cache"]
+		_, noCache := cc["no-cache"]
+		if fresh && !reqNoCache && !noCache {
+			return entry.response(req, age), nil
+		}
+		if _, ok := reqCC["only-if-cached"]; ok {
+			return gatewayTimeout(req), nil
+		}
+
+		_, mustRevalidate := cc["must-revalidate"]
+		if _, ok := cc["proxy-revalidate"]; ok && o.Shared {
+			mustRevalidate = true
+		}
+		mayServeStale := !mustRevalidate && !noCache
+		staleness := age - lifetime
+
+		if swr, ok := cacheSeconds(cc, "stale-while-revalidate"); ok && mayServeStale && !reqNoCache && staleness <= swr {
+			go func() {
+				resp, err := o.revalidate(next, key, req.Clone(context.Background()), entry)
+				if err == nil {
+					resp.Body.Close()
+				}
+			}()
+			return entry.response(req, age), nil
+		}
+
+		resp, err := o.revalidate(next, key, req, entry)
+		if err == nil && resp.StatusCode < 500 {
+			return resp, nil
+		}
+
+		sie, ok := cacheSeconds(cc, "stale-if-error")
+		if reqSIE, reqOK := cacheSeconds(reqCC, "stale-if-error"); reqOK {
+			sie, ok = reqSIE, true
+		}
+		if ok && mayServeStale && staleness <= sie {
+			if resp != nil {
+				resp.Body.Close()
+			}
+			return entry.response(req, age), nil
+		}
+		return resp, err
+	})
+}
+
+// fetch makes the request and stores the response if it is cacheable.
+func (o *Cache) fetch(next http.RoundTripper, key string, req *http.Request) (*http.Response, error) {
+	requestTime := o.now()
+	resp, err := next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	return o.storeResponse(key, req, resp, requestTime)
+}
+
+// storeResponse stores the response if it is cacheable, reading its body into
+// memory.
+func (o *Cache) storeResponse(key string, req *http.Request, resp *http.Response, requestTime time.Time) (*http.Response, error) {
+	if !o.cacheable(req, resp) {
+		return resp, nil
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("error reading response body: %w", err)
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	entry := &cacheEntry{
+		StatusCode:   resp.StatusCode,
+		Header:       resp.Header.Clone(),
+		Body:         body,
+		RequestTime:  requestTime,
+		ResponseTime: o.now(),
+		Credentials:  o.credentialsHash(req),
+	}
+	for _, name := range headerTokens(resp.Header, "Vary") {
+		if entry.Vary == nil {
+			entry.Vary = http.Header{}
+		}
+		name = http.CanonicalHeaderKey(name)
+		entry.Vary[name] = req.Header[name]
+	}
+	o.store(key, entry)
+
+	return resp, nil
+}
+
+// revalidate makes a conditional request for the stored response, returning
+// the stored response if it is not modified.
+func (o *Cache) revalidate(next http.RoundTripper, key string, req *http.Request, entry *cacheEntry) (*http.Response, error) {
+	r := req.Clone(req.Context())
+	if etag := entry.Header.Get("ETag"); etag != "" {
+		r.Header.Set("If-None-Match", etag)
+	}
+	if lastModified := entry.Header.Get("Last-Modified"); lastModified != "" {
+		r.Header.Set("If-Modified-Since", lastModified)
+	}
+
+	requestTime := o.now()
+	resp, err := next.RoundTrip(r)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusNotModified {
+		resp.Request = req
+		if resp.StatusCode >= 500 {
+			return resp, nil
+		}
+		o.getStorage().Delete(key)
+		return o.storeResponse(key, req, resp, requestTime)
+	}
+	resp.Body.Close()
+
+	updated := *entry
+	updated.Header = entry.Header.Clone()
+	for name, values := range resp.Header {
+		if name != "Content-Length" {
+			updated.Header[name] = values
+		}
+	}
+	updated.RequestTime = requestTime
+	updated.ResponseTime = o.now()
+	o.store(key, &updated)
+
+	return updated.response(req, updated.age(o.now())), nil
+}
+
+// cacheable reports whether the response to the request may be stored.
+func (o *Cache) cacheable(req *http.Request, resp *http.Response) bool {
+	if !heuristicallyCacheable(resp.StatusCode) {
+		return false
+	}
+
+	cc := parseCacheControl(resp.Header)
+	if _, ok := cc["no-store"]; ok {
+		return false
+	}
+	for _, name := range headerTokens(resp.Header, "Vary") {
+		if name == "*" {
+			return false
+		}
+	}
+
+	_, public := cc["public"]
+	_, sMaxAge := cc["s-maxage"]
+	if o.Shared {
+		if _, ok := cc["private"]; ok {
+			return false
+		}
+		_, mustRevalidate := cc["must-revalidate"]
+		if o.credentials(req) != "" && !public && !sMaxAge && !mustRevalidate {
+			return false
+		}
+	}
+
+	_, maxAge := cc["max-age"]
+	return public || maxAge || (o.Shared && sMaxAge) ||
+		resp.Header.Get("Expires") != "" ||
+		resp.Header.Get("ETag") != "" ||
+		resp.Header.Get("Last-Modified") != ""
+}
+
+// lifetime returns the freshness lifetime of the stored response.
+func (o *Cache) lifetime(entry *cacheEntry, cc map[string]string) time.Duration {
+	if o.Shared {
+		if d, ok := cacheSeconds(cc, "s-maxage"); ok {
+			return d
+		}
+	}
+	if d, ok := cacheSeconds(cc, "max-age"); ok {
+		return d
+	}
+
+	date := entry.date()
+	if expires := entry.Header.Get("Expires"); expires != "" {
+		t, err := http.ParseTime(expires)
+		if err != nil {
+			return 0
+		}
+		return t.Sub(date)
+	}
+
+	// Heuristic freshness of a tenth of the time since modification.
+	if t, err := http.ParseTime(entry.Header.Get("Last-Modified")); err == nil && t.Before(date) {
+		return date.Sub(t) / 10
+	}
+	return 0
+}
+
+// age returns the current age of the stored response.
+func (o *cacheEntry) age(now time.Time) time.Duration {
+	apparentAge := o.ResponseTime.Sub(o.date())
+	if apparentAge < 0 {
+		apparentAge = 0
+	}
+
+	var ageValue time.Duration
+	if seconds, err := strconv.ParseInt(o.Header.Get("Age"), 10, 64); err == nil && seconds > 0 {
+		ageValue = time.Duration(seconds) * time.Second
+	}
+	correctedAge := ageValue + o.ResponseTime.Sub(o.RequestTime)
+
+	initialAge := apparentAge
+	if correctedAge > initialAge {
+		initialAge = correctedAge
+	}
+	return initialAge + now.Sub(o.ResponseTime)
+}
+
+func (o *cacheEntry) date() time.Time {
+	if t, err := http.ParseTime(o.Header.Get("Date")); err == nil {
+		return t
+	}
+	return o.ResponseTime
+}
+
+// response returns the stored response for the request.
+func (o *cacheEntry) response(req *http.Request, age time.Duration) *http.Response {
+	header := o.Header.Clone()
+	header.Set("Age", strconv.FormatInt(int64(age/time.Second), 10))
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", o.StatusCode, http.StatusText(o.StatusCode)),
+		StatusCode:    o.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(o.Body)),
+		ContentLength: int64(len(o.Body)),
+		Request:       req,
+	}
+}
+
+func (o *Cache) load(key string, req *http.Request) *cacheEntry {
+	data, ok := o.getStorage().Get(key)
+	if !ok {
+		return nil
+	}
+
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil
+	}
+	if entry.Credentials != o.credentialsHash(req) {
+		return nil
+	}
+	for name, values := range entry.Vary {
+		if strings.Join(req.Header[name], ", ") != strings.Join(values, ", ") {
+			return nil
+		}
+	}
+
+	return &entry
+}
+
+func (o *Cache) store(key string, entry *cacheEntry) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	o.getStorage().Set(key, data)
+}
+
+func (o *Cache) getStorage() Storage {
+	o.once.Do(func() {
+		o.storage = o.Storage
+		if o.storage == nil {
+			o.storage = NewMemoryStorage(defaultCacheEntries)
+		}
+	})
+	return o.storage
+}
+
+func (o *Cache) now() time.Time {
+	if o.Now != nil {
+		return o.Now()
+	}
+	return time.Now()
+}
+
+func cacheKey(method string, u *url.URL) string {
+	return method + " " + u.String()
+}
+
+// credentials returns the credentials of the request, or "" if it has none.
+func (o *Cache) credentials(req *http.Request) string {
+	if o.Credentials != nil {
+		return o.Credentials(req)
+	}
+
+	var b strings.Builder
+	for _, name := range credentialHeaders(req) {
+		name = http.CanonicalHeaderKey(name)
+		if values := req.Header[name]; len(values) > 0 {
+			b.WriteString(name)
+			b.WriteString(": ")
+			b.WriteString(strings.Join(values, ", "))
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+// credentialsHash returns a hash of the credentials of the request, or "" if
+// it has none.
+func (o *Cache) credentialsHash(req *http.Request) string {
+	credentials := o.credentials(req)
+	if credentials == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(credentials))
+	return hex.EncodeToString(sum[:])
+}
+
+// parseCacheControl returns the directives of the Cache-Control header, with
+// lowercase names.
+func parseCacheControl(h http.Header) map[string]string {
+	cc := map[string]string{}
+	for _, directive := range headerTokens(h, "Cache-Control") {
+		name, value := directive, ""
+		if i := strings.Index(directive, "="); i >= 0 {
+			name, value = directive[:i], strings.Trim(strings.TrimSpace(directive[i+1:]), `"`)
+		}
+		cc[strings.ToLower(strings.TrimSpace(name))] = value
+	}
+	return cc
+}
+
+func cacheSeconds(cc map[string]string, name string) (time.Duration, bool) {
+	value, ok := cc[name]
+	if !ok {
+		return 0, false
+	}
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+	return time.Duration(seconds) * time.Second, true
+}
+
+// headerTokens returns the comma-separated values of the header.
+func headerTokens(h http.Header, name string) []string {
+	var tokens []string
+	for _, line := range h[http.CanonicalHeaderKey(name)] {
+		for _, token := range strings.Split(line, ",") {
+			if token = strings.TrimSpace(token); token != "" {
+				tokens = append(tokens, token)
+			}
+		}
+	}
+	return tokens
+}
+
+func heuristicallyCacheable(statusCode int) bool {
+	switch statusCode {
+	case 200, 203, 204, 300, 301, 308, 404, 405, 410, 414, 501:
+		return true
+	default:
+		return false
+	}
+}
+
+func conditionalRequest(req *http.Request) bool {
+	for _, name := range []string{"If-None-Match", "If-Modified-Since", "If-Match", "If-Unmodified-Since", "If-Range", "Range"} {
+		if req.Header.Get(name) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func safeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	default:
+		return false
+	}
+}
+
+func gatewayTimeout(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:     "504 Gateway Timeout",
+		StatusCode: http.StatusGatewayTimeout,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     http.Header{},
+		Body:       http.NoBody,
+		Request:    req,
+	}
+}
+
+// WithCache caches the responses of the Request's client with the Cache.
+func (o *Request) WithCache(cache *Cache) *Request {
+	return o.WithMiddleware(cache.Middleware)
+}
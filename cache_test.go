@@ -0,0 +1,373 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRequest_WithCache(t *testing.T) {
+	type step struct {
+		advance    time.Duration
+		method     string
+		header     http.Header
+		wantBody   string
+		wantStatus int
+		wantCalls  int32
+	}
+	tests := []struct {
+		name    string
+		shared  bool
+		respond func(w http.ResponseWriter, r *http.Request, call int32)
+		steps   []step
+	}{
+		{
+			name: "success max-age",
+			respond: func(w http.ResponseWriter, r *http.Request, call int32) {
+				w.Header().Set("Cache-Control", "max-age=60")
+				fmt.Fprintf(w, "v%d", call)
+			},
+			steps: []step{
+				{wantBody: "v1", wantCalls: 1},
+				{advance: 30 * time.Second, wantBody: "v1", wantCalls: 1},
+				{advance: 31 * time.Second, wantBody: "v2", wantCalls: 2},
+			},
+		},
+		{
+			name: "success credentials",
+			respond: func(w http.ResponseWriter, r *http.Request, call int32) {
+				w.Header().Set("Cache-Control", "max-age=60")
+				fmt.Fprintf(w, "v%d %s", call, r.Header.Get("Authorization"))
+			},
+			steps: []step{
+				{header: http.Header{"Authorization": {"Bearer a"}}, wantBody: "v1 Bearer a", wantCalls: 1},
+				{header: http.Header{"Authorization": {"Bearer a"}}, wantBody: "v1 Bearer a", wantCalls: 1},
+				{header: http.Header{"Authorization": {"Bearer b"}}, wantBody: "v2 Bearer b", wantCalls: 2},
+				{wantBody: "v3 ", wantCalls: 3},
+				{header: http.Header{"Cookie": {"session=a"}}, wantBody: "v4 ", wantCalls: 4},
+				{header: http.Header{"Proxy-Authorization": {"Basic a"}}, wantBody: "v5 ", wantCalls: 5},
+			},
+		},
+		{
+			name: "success request no-cache",
+			respond: func(w http.ResponseWriter, r *http.Request, call int32) {
+				w.Header().Set("Cache-Control", "max-age=60")
+				fmt.Fprintf(w, "v%d", call)
+			},
+			steps: []step{
+				{wantBody: "v1", wantCalls: 1},
+				{header: http.Header{"Cache-Control": {"no-cache"}}, wantBody: "v2", wantCalls: 2},
+				{header: http.Header{"Cache-Control": {"no-store"}}, wantBody: "v3", wantCalls: 3},
+				{wantBody: "v2", wantCalls: 3},
+			},
+		},
+		{
+			name: "success etag revalidation",
+			respond: func(w http.ResponseWriter, r *http.Request, call int32) {
+				w.Header().Set("Cache-Control", "no-cache")
+				w.Header().Set("ETag", `"a"`)
+				if r.Header.Get("If-None-Match") == `"a"` {
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+				fmt.Fprintf(w, "v%d", call)
+			},
+			steps: []step{
+				{wantBody: "v1", wantCalls: 1},
+				{wantBody: "v1", wantCalls: 2},
+			},
+		},
+		{
+			name: "success last-modified revalidation",
+			respond: func(w http.ResponseWriter, r *http.Request, call int32) {
+				w.Header().Set("Last-Modified", "Sun, 13 Sep 2020 00:00:00 GMT")
+				if r.Header.Get("If-Modified-Since") != "" {
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+				fmt.Fprintf(w, "v%d", call)
+			},
+			steps: []step{
+				{wantBody: "v1", wantCalls: 1},
+				// Heuristically fresh for a tenth of the time since modification.
+				{advance: time.Hour, wantBody: "v1", wantCalls: 1},
+				{advance: 24 * time.Hour, wantBody: "v1", wantCalls: 2},
+			},
+		},
+		{
+			name: "success expires",
+			respond: func(w http.ResponseWriter, r *http.Request, call int32) {
+				w.Header().Set("Expires", time.Unix(1600000010, 0).UTC().Format(http.TimeFormat))
+				fmt.Fprintf(w, "v%d", call)
+			},
+			steps: []step{
+				{wantBody: "v1", wantCalls: 1},
+				{advance: 5 * time.Second, wantBody: "v1", wantCalls: 1},
+				{advance: 5 * time.Second, wantBody: "v2", wantCalls: 2},
+			},
+		},
+		{
+			name: "success no-store",
+			respond: func(w http.ResponseWriter, r *http.Request, call int32) {
+				w.Header().Set("Cache-Control", "no-store, max-age=60")
+				fmt.Fprintf(w, "v%d", call)
+			},
+			steps: []step{
+				{wantBody: "v1", wantCalls: 1},
+				{wantBody: "v2", wantCalls: 2},
+			},
+		},
+		{
+			name: "success vary",
+			respond: func(w http.ResponseWriter, r *http.Request, call int32) {
+				w.Header().Set("Cache-Control", "max-age=60")
+				w.Header().Set("Vary", "Accept-Language")
+				fmt.Fprintf(w, "v%d %s", call, r.Header.Get("Accept-Language"))
+			},
+			steps: []step{
+				{header: http.Header{"Accept-Language": {"en"}}, wantBody: "v1 en", wantCalls: 1},
+				{header: http.Header{"Accept-Language": {"en"}}, wantBody: "v1 en", wantCalls: 1},
+				{header: http.Header{"Accept-Language": {"fr"}}, wantBody: "v2 fr", wantCalls: 2},
+			},
+		},
+		{
+			name: "success stale-if-error",
+			respond: func(w http.ResponseWriter, r *http.Request, call int32) {
+				if call > 1 {
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+				w.Header().Set("Cache-Control", "max-age=1, stale-if-error=60")
+				fmt.Fprintf(w, "v%d", call)
+			},
+			steps: []step{
+				{wantBody: "v1", wantCalls: 1},
+				{advance: 30 * time.Second, wantBody: "v1", wantCalls: 2},
+				{advance: 60 * time.Second, wantStatus: http.StatusServiceUnavailable, wantCalls: 3},
+			},
+		},
+		{
+			name: "success must-revalidate",
+			respond: func(w http.ResponseWriter, r *http.Request, call int32) {
+				if call > 1 {
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+				w.Header().Set("Cache-Control", "max-age=1, must-revalidate, stale-if-error=60")
+				fmt.Fprintf(w, "v%d", call)
+			},
+			steps: []step{
+				{wantBody: "v1", wantCalls: 1},
+				{advance: 30 * time.Second, wantStatus: http.StatusServiceUnavailable, wantCalls: 2},
+			},
+		},
+		{
+			name:   "success shared private",
+			shared: true,
+			respond: func(w http.ResponseWriter, r *http.Request, call int32) {
+				w.Header().Set("Cache-Control", "private, max-age=60")
+				fmt.Fprintf(w, "v%d", call)
+			},
+			steps: []step{
+				{wantBody: "v1", wantCalls: 1},
+				{wantBody: "v2", wantCalls: 2},
+			},
+		},
+		{
+			name:   "success shared credentials",
+			shared: true,
+			respond: func(w http.ResponseWriter, r *http.Request, call int32) {
+				w.Header().Set("Cache-Control", "max-age=60")
+				fmt.Fprintf(w, "v%d", call)
+			},
+			steps: []step{
+				{header: http.Header{"Proxy-Authorization": {"Basic a"}}, wantBody: "v1", wantCalls: 1},
+				{header: http.Header{"Proxy-Authorization": {"Basic a"}}, wantBody: "v2", wantCalls: 2},
+			},
+		},
+		{
+			name:   "success shared s-maxage",
+			shared: true,
+			respond: func(w http.ResponseWriter, r *http.Request, call int32) {
+				w.Header().Set("Cache-Control", "max-age=0, s-maxage=60")
+				fmt.Fprintf(w, "v%d", call)
+			},
+			steps: []step{
+				{wantBody: "v1", wantCalls: 1},
+				{wantBody: "v1", wantCalls: 1},
+			},
+		},
+		{
+			name: "success unsafe method invalidates",
+			respond: func(w http.ResponseWriter, r *http.Request, call int32) {
+				w.Header().Set("Cache-Control", "max-age=60")
+				fmt.Fprintf(w, "v%d", call)
+			},
+			steps: []step{
+				{wantBody: "v1", wantCalls: 1},
+				{method: http.MethodPost, wantBody: "v2", wantCalls: 2},
+				{wantBody: "v3", wantCalls: 3},
+			},
+		},
+		{
+			name: "success only-if-cached",
+			respond: func(w http.ResponseWriter, r *http.Request, call int32) {
+				fmt.Fprintf(w, "v%d", call)
+			},
+			steps: []step{
+				{header: http.Header{"Cache-Control": {"only-if-cached"}}, wantStatus: http.StatusGatewayTimeout},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Unix(1600000000, 0)
+			var calls int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Date", now.UTC().Format(http.TimeFormat))
+				tt.respond(w, r, atomic.AddInt32(&calls, 1))
+			}))
+			defer server.Close()
+
+			cache := &Cache{
+				Shared: tt.shared,
+				Now:    func() time.Time { return now },
+			}
+			base, err := NewRequest().WithCache(cache).FromURLString(server.URL + "/resource")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for i, step := range tt.steps {
+				now = now.Add(step.advance)
+
+				method := step.method
+				if method == "" {
+					method = http.MethodGet
+				}
+				var body []byte
+				req := base.Clone().WithMethod(method).WithResponseBody(&body)
+				for name, values := range step.header {
+					req.AddHeader(name, values[0])
+				}
+
+				_, err := req.Do()
+				var statusCodeErr *StatusCodeError
+				if step.wantStatus != 0 {
+					if !errors.As(err, &statusCodeErr) || statusCodeErr.StatusCode != step.wantStatus {
+						t.Errorf("step %d: Request.Do() error = %v, want status %v", i, err, step.wantStatus)
+					}
+				} else if err != nil {
+					t.Errorf("step %d: Request.Do() error = %v", i, err)
+				} else if string(body) != step.wantBody {
+					t.Errorf("step %d: Request.Do() body = %q, want %q", i, body, step.wantBody)
+				}
+				if got := atomic.LoadInt32(&calls); got != step.wantCalls {
+					t.Errorf("step %d: server called %d times, want %d", i, got, step.wantCalls)
+				}
+			}
+		})
+	}
+}
+
+func TestCache_credentials(t *testing.T) {
+	tests := []struct {
+		name        string
+		credentials func(req *http.Request) string
+		header      string
+	}{
+		{
+			name:   "success authenticator header",
+			header: "X-API-Key",
+		},
+		{
+			name: "success credentials func",
+			credentials: func(req *http.Request) string {
+				return req.Header.Get("X-Tenant")
+			},
+			header: "X-Tenant",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Cache-Control", "max-age=60")
+				fmt.Fprint(w, r.Header.Get(tt.header))
+			}))
+			defer server.Close()
+
+			cache := &Cache{Credentials: tt.credentials}
+			base, err := NewRequest().WithMethod(http.MethodGet).WithCache(cache).FromURLString(server.URL + "/")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, value := range []string{"a", "b", "a"} {
+				req := base.Clone()
+				if tt.credentials == nil {
+					req.WithAuth(&APIKey{In: APIKeyInHeader, Name: tt.header, Value: Secret(value)})
+				} else {
+					req.AddHeader(tt.header, value)
+				}
+
+				var body []byte
+				if _, err := req.WithResponseBody(&body).Do(); err != nil || string(body) != value {
+					t.Errorf("Request.Do() = %q, %v, want %q", body, err, value)
+				}
+			}
+		})
+	}
+}
+
+func TestCache_staleWhileRevalidate(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Date", time.Unix(1600000000, 0).UTC().Format(http.TimeFormat))
+		w.Header().Set("Cache-Control", "max-age=1, stale-while-revalidate=60")
+		fmt.Fprintf(w, "v%d", atomic.AddInt32(&calls, 1))
+	}))
+	defer server.Close()
+
+	cache := &Cache{
+		Storage: NewMemoryStorage(10),
+		Now:     func() time.Time { return now },
+	}
+	base, err := NewRequest().WithMethod(http.MethodGet).WithCache(cache).FromURLString(server.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var body []byte
+	if _, err := base.Clone().WithResponseBody(&body).Do(); err != nil || string(body) != "v1" {
+		t.Fatalf("Request.Do() = %q, %v, want %q", body, err, "v1")
+	}
+
+	now = now.Add(30 * time.Second)
+	if _, err := base.Clone().WithResponseBody(&body).Do(); err != nil || string(body) != "v1" {
+		t.Fatalf("Request.Do() = %q, %v, want stale %q", body, err, "v1")
+	}
+
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	for {
+		entry := cache.load(cacheKey(req.Method, req.URL), req)
+		if entry != nil && string(entry.Body) == "v2" {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			t.Fatalf("Cache did not revalidate in the background")
+		case <-time.After(time.Millisecond):
+		}
+	}
+}
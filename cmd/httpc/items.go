@@ -0,0 +1,211 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"mime/multipart"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	httpclient "github.com/kevinsnydercodes/go-http-client"
+)
+
+type itemKind int
+
+const (
+	itemHeader itemKind = iota
+	itemQuery
+	itemData
+	itemRawJSON
+	itemFile
+)
+
+// separators are checked in order at each position of an item, so that longer
+// separators take precedence over their prefixes.
+var separators = []struct {
+	sep  string
+	kind itemKind
+}{
+	{sep: ":=", kind: itemRawJSON},
+	{sep: "==", kind: itemQuery},
+	{sep: "=", kind: itemData},
+	{sep: ":", kind: itemHeader},
+	{sep: "@", kind: itemFile},
+}
+
+type item struct {
+	kind  itemKind
+	key   string
+	value string
+}
+
+// parseItem parses a request item such as "name=bob", "age:=3", "q==go",
+// "X-Tenant:acme" or "file@path". The earliest separator in the item wins.
+func parseItem(s string) (*item, error) {
+	for i := 0; i < len(s); i++ {
+		for _, sep := range separators {
+			if strings.HasPrefix(s[i:], sep.sep) {
+				if i == 0 {
+					return nil, fmt.Errorf("invalid item %q: missing key", s)
+				}
+				return &item{
+					kind:  sep.kind,
+					key:   s[:i],
+					value: s[i+len(sep.sep):],
+				}, nil
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("invalid item %q", s)
+}
+
+type bodyMode int
+
+const (
+	bodyJSON bodyMode = iota
+	bodyForm
+	bodyMultipart
+)
+
+// applyItems adds the items to the request, building its body according to the
+// mode. File items switch form and JSON modes to multipart.
+func applyItems(o *httpclient.Request, items []*item, mode bodyMode) error {
+	var data []*item
+	for _, it := range items {
+		switch it.kind {
+		case itemHeader:
+			o.AddHeader(it.key, it.value)
+		case itemQuery:
+			o.AddQuery(it.key, it.value)
+		case itemFile:
+			mode = bodyMultipart
+			data = append(data, it)
+		default:
+			data = append(data, it)
+		}
+	}
+
+	if mode == bodyJSON {
+		if o.Header.Get("Accept") == "" {
+			o.AddHeader("Accept", "application/json, */*;q=0.5")
+		}
+	}
+
+	if len(data) == 0 {
+		return nil
+	}
+
+	switch mode {
+	case bodyJSON:
+		body := map[string]interface{}{}
+		for _, it := range data {
+			if it.kind == itemRawJSON {
+				if !json.Valid([]byte(it.value)) {
+					return fmt.Errorf("invalid JSON in item %q", it.key)
+				}
+				body[it.key] = json.RawMessage(it.value)
+				continue
+			}
+			body[it.key] = it.value
+		}
+		setDefaultHeader(o, "Content-Type", "application/json")
+		o.WithRequestBody(body)
+	case bodyForm:
+		body := url.Values{}
+		for _, it := range data {
+			if it.kind == itemRawJSON {
+				return fmt.Errorf("raw JSON item %q requires JSON mode", it.key)
+			}
+			body.Add(it.key, it.value)
+		}
+		setDefaultHeader(o, "Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+		o.WithRequestBody(body)
+	case bodyMultipart:
+		body, contentType, err := multipartBody(data)
+		if err != nil {
+			return err
+		}
+		setDefaultHeader(o, "Content-Type", contentType)
+		o.WithRequestBody(body)
+	}
+
+	return nil
+}
+
+func setDefaultHeader(o *httpclient.Request, key, value string) {
+	if o.Header.Get(key) == "" {
+		o.AddHeader(key, value)
+	}
+}
+
+func multipartBody(items []*item) ([]byte, string, error) {
+	var b bytes.Buffer
+	w := multipart.NewWriter(&b)
+
+	for _, it := range items {
+		switch it.kind {
+		case itemFile:
+			content, err := ioutil.ReadFile(it.value)
+			if err != nil {
+				return nil, "", fmt.Errorf("error reading file of item %q: %w", it.key, err)
+			}
+			part, err := w.CreateFormFile(it.key, filepath.Base(it.value))
+			if err != nil {
+				return nil, "", err
+			}
+			if _, err := part.Write(content); err != nil {
+				return nil, "", err
+			}
+		case itemRawJSON:
+			return nil, "", fmt.Errorf("raw JSON item %q requires JSON mode", it.key)
+		default:
+			if err := w.WriteField(it.key, it.value); err != nil {
+				return nil, "", err
+			}
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, "", err
+	}
+
+	return b.Bytes(), w.FormDataContentType(), nil
+}
+
+// normalizeURL adds a default scheme to ref, and expands the ":3000/path"
+// shorthand for localhost.
+func normalizeURL(ref string) string {
+	if strings.HasPrefix(ref, ":") {
+		ref = "localhost" + ref
+	}
+	if !strings.Contains(ref, "://") {
+		ref = "http://" + ref
+	}
+	return ref
+}
+
+// isMethod returns whether s looks like a HTTP method rather than a URL.
+func isMethod(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	httpclient "github.com/kevinsnydercodes/go-http-client"
+)
+
+func TestParseItem(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    *item
+		wantErr bool
+	}{
+		{
+			name: "success data",
+			s:    "name=bob",
+			want: &item{kind: itemData, key: "name", value: "bob"},
+		},
+		{
+			name: "success data with separators in value",
+			s:    "url=http://example.com/?a=b",
+			want: &item{kind: itemData, key: "url", value: "http://example.com/?a=b"},
+		},
+		{
+			name: "success raw json",
+			s:    "age:=3",
+			want: &item{kind: itemRawJSON, key: "age", value: "3"},
+		},
+		{
+			name: "success query",
+			s:    "q==go",
+			want: &item{kind: itemQuery, key: "q", value: "go"},
+		},
+		{
+			name: "success header",
+			s:    "X-Tenant:acme",
+			want: &item{kind: itemHeader, key: "X-Tenant", value: "acme"},
+		},
+		{
+			name: "success file",
+			s:    "avatar@/tmp/a.png",
+			want: &item{kind: itemFile, key: "avatar", value: "/tmp/a.png"},
+		},
+		{
+			name:    "error no separator",
+			s:       "foo",
+			wantErr: true,
+		},
+		{
+			name:    "error no key",
+			s:       "=foo",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseItem(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseItem() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseItem() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyItems(t *testing.T) {
+	tests := []struct {
+		name            string
+		items           []*item
+		mode            bodyMode
+		wantQuery       url.Values
+		wantHeader      http.Header
+		wantRequestBody interface{}
+		wantErr         bool
+	}{
+		{
+			name: "success json",
+			items: []*item{
+				{kind: itemHeader, key: "X-Tenant", value: "acme"},
+				{kind: itemQuery, key: "q", value: "go"},
+				{kind: itemData, key: "name", value: "bob"},
+				{kind: itemRawJSON, key: "age", value: "3"},
+			},
+			mode:      bodyJSON,
+			wantQuery: url.Values{"q": []string{"go"}},
+			wantHeader: http.Header{
+				"X-Tenant":     []string{"acme"},
+				"Accept":       []string{"application/json, */*;q=0.5"},
+				"Content-Type": []string{"application/json"},
+			},
+			wantRequestBody: map[string]interface{}{
+				"name": "bob",
+				"age":  json.RawMessage("3"),
+			},
+		},
+		{
+			name: "success json without data",
+			mode: bodyJSON,
+			wantHeader: http.Header{
+				"Accept": []string{"application/json, */*;q=0.5"},
+			},
+		},
+		{
+			name: "success form",
+			items: []*item{
+				{kind: itemData, key: "name", value: "bob"},
+			},
+			mode: bodyForm,
+			wantHeader: http.Header{
+				"Content-Type": []string{"application/x-www-form-urlencoded; charset=utf-8"},
+			},
+			wantRequestBody: url.Values{"name": []string{"bob"}},
+		},
+		{
+			name: "error invalid raw json",
+			items: []*item{
+				{kind: itemRawJSON, key: "age", value: "{"},
+			},
+			mode:    bodyJSON,
+			wantErr: true,
+		},
+		{
+			name: "error raw json in form",
+			items: []*item{
+				{kind: itemRawJSON, key: "age", value: "3"},
+			},
+			mode:    bodyForm,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := httpclient.NewRequest().WithDefaultHeader()
+			err := applyItems(o, tt.items, tt.mode)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("applyItems() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(o.Query, tt.wantQuery) {
+				t.Errorf("applyItems() query = %v, want %v", o.Query, tt.wantQuery)
+			}
+			if !reflect.DeepEqual(o.Header, tt.wantHeader) {
+				t.Errorf("applyItems() header = %v, want %v", o.Header, tt.wantHeader)
+			}
+			if !reflect.DeepEqual(o.RequestBody, tt.wantRequestBody) {
+				t.Errorf("applyItems() request body = %v, want %v", o.RequestBody, tt.wantRequestBody)
+			}
+		})
+	}
+}
+
+func TestApplyItems_Multipart(t *testing.T) {
+	dir, err := ioutil.TempDir("", "httpc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "hello.txt")
+	if err := ioutil.WriteFile(path, []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	o := httpclient.NewRequest().WithDefaultHeader()
+	items := []*item{
+		{kind: itemData, key: "name", value: "bob"},
+		{kind: itemFile, key: "greeting", value: path},
+	}
+	if err := applyItems(o, items, bodyJSON); err != nil {
+		t.Fatal(err)
+	}
+
+	contentType := o.Header.Get("Content-Type")
+	if !strings.HasPrefix(contentType, "multipart/form-data; boundary=") {
+		t.Errorf("applyItems() Content-Type = %v, want multipart/form-data", contentType)
+	}
+	body := string(o.RequestBody.([]byte))
+	for _, want := range []string{`name="name"`, "bob", `name="greeting"; filename="hello.txt"`, "hello"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("applyItems() request body = %v, want to contain %v", body, want)
+		}
+	}
+}
+
+func TestNormalizeURL(t *testing.T) {
+	tests := []struct {
+		ref  string
+		want string
+	}{
+		{ref: "https://example.com/path", want: "https://example.com/path"},
+		{ref: "example.com/path", want: "http://example.com/path"},
+		{ref: ":3000/path", want: "http://localhost:3000/path"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			if got := normalizeURL(tt.ref); got != tt.want {
+				t.Errorf("normalizeURL() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsMethod(t *testing.T) {
+	tests := []struct {
+		s    string
+		want bool
+	}{
+		{s: "GET", want: true},
+		{s: "PROPFIND", want: true},
+		{s: "get", want: false},
+		{s: "example.com", want: false},
+		{s: "", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			if got := isMethod(tt.s); got != tt.want {
+				t.Errorf("isMethod() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,309 @@
+// Command httpc is a command-line HTTP client built on the Request of
+// github.com/kevinsnydercodes/go-http-client.
+//
+// Usage:
+//
+//	httpc [flags] [METHOD] URL [ITEM ...]
+//
+// Items describe the request:
+//
+//	Header:value   adds a request header
+//	name==value    adds a query parameter
+//	name=value     adds a string field to the JSON, form, or multipart body
+//	name:=json     adds a raw JSON field to the JSON body
+//	name@path      adds a file to the multipart body
+//
+// The method defaults to GET, or POST if the request has a body. The URL
+// defaults to the http scheme, and ":3000/path" is short for
+// "localhost:3000/path".
+//
+// Credentials given with --auth or --bearer are redacted when the request is
+// printed.
+//
+// The exit code is 0 on success, 1 on error, 2 on timeout, and 3, 4, or 5 for
+// responses with a 3xx, 4xx, or 5xx status code.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	httpclient "github.com/kevinsnydercodes/go-http-client"
+)
+
+// retryBackoff is the delay before the first retry, doubled for each retry
+// after it.
+var retryBackoff = 500 * time.Millisecond
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr, isTerminal(os.Stdout)))
+}
+
+type options struct {
+	form      bool
+	multipart bool
+	timeout   time.Duration
+	retries   int
+	follow    bool
+	verbose   bool
+	print     string
+	pretty    string
+	auth      string
+	bearer    string
+}
+
+func run(args []string, stdout, stderr io.Writer, tty bool) int {
+	opts := &options{}
+
+	flags := flag.NewFlagSet("httpc", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.BoolVar(&opts.form, "form", false, "send data items as a form")
+	flags.BoolVar(&opts.multipart, "multipart", false, "send data items as a multipart form")
+	flags.DurationVar(&opts.timeout, "timeout", 0, "timeout of each attempt of the request")
+	flags.IntVar(&opts.retries, "retries", 0, "number of retries on connection errors and 429 or 5xx responses")
+	flags.BoolVar(&opts.follow, "follow", false, "follow redirects")
+	flags.BoolVar(&opts.verbose, "verbose", false, "print the request as well as the response")
+	flags.StringVar(&opts.print, "print", "", "parts to print: H request headers, B request body, h response headers, b response body")
+	flags.StringVar(&opts.pretty, "pretty", "", "output processing: all, colors, format, or none")
+	flags.StringVar(&opts.auth, "auth", "", "basic auth credentials as USER:PASSWORD")
+	flags.StringVar(&opts.bearer, "bearer", "", "bearer token")
+	flags.Usage = func() {
+		fmt.Fprintf(stderr, "usage: httpc [flags] [METHOD] URL [ITEM ...]\n\nflags:\n")
+		flags.PrintDefaults()
+	}
+
+	positional, err := parseArgs(flags, args)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 1
+	}
+
+	if opts.print == "" {
+		switch {
+		case opts.verbose:
+			opts.print = "HBhb"
+		case tty:
+			opts.print = "hb"
+		default:
+			opts.print = "b"
+		}
+	}
+	if opts.pretty == "" {
+		opts.pretty = "none"
+		if tty {
+			opts.pretty = "all"
+		}
+	}
+
+	o, transport, err := buildRequest(positional, opts)
+	if err != nil {
+		fmt.Fprintf(stderr, "httpc: error: %v\n", err)
+		return 1
+	}
+
+	var body []byte
+	resp, err := doWithRetries(o.WithResponseBody(&body), opts.retries)
+	if resp != nil {
+		resp.Body.Close()
+	}
+
+	p := &printer{
+		w:      stdout,
+		format: opts.pretty == "all" || opts.pretty == "format",
+		colors: opts.pretty == "all" || opts.pretty == "colors",
+	}
+	if req := transport.req; req != nil && strings.ContainsAny(opts.print, "HB") {
+		if strings.Contains(opts.print, "H") {
+			p.printRequestHead(req)
+		}
+		if strings.Contains(opts.print, "B") {
+			p.printBody(req.Header.Get("Content-Type"), transport.body)
+		}
+		if resp != nil && strings.ContainsAny(opts.print, "hb") {
+			fmt.Fprintln(stdout)
+		}
+	}
+	if resp != nil {
+		if strings.Contains(opts.print, "h") {
+			p.printResponseHead(resp)
+		}
+		if strings.Contains(opts.print, "b") {
+			p.printBody(resp.Header.Get("Content-Type"), body)
+		}
+	}
+
+	var statusCodeErr *httpclient.StatusCodeError
+	switch {
+	case err == nil:
+	case errors.As(err, &statusCodeErr):
+		fmt.Fprintf(stderr, "httpc: warning: HTTP %s\n", resp.Status)
+	default:
+		fmt.Fprintf(stderr, "httpc: error: %v\n", err)
+	}
+
+	return exitCode(err)
+}
+
+// parseArgs parses flags before, between, and after the positional arguments.
+func parseArgs(flags *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+
+	for {
+		if err := flags.Parse(args); err != nil {
+			return nil, err
+		}
+		args = flags.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		if args[0] == "--" {
+			return append(positional, args[1:]...), nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+func buildRequest(positional []string, opts *options) (*httpclient.Request, *captureTransport, error) {
+	method := ""
+	if len(positional) > 0 && isMethod(positional[0]) {
+		method = positional[0]
+		positional = positional[1:]
+	}
+	if len(positional) == 0 {
+		return nil, nil, fmt.Errorf("must provide URL")
+	}
+
+	var items []*item
+	hasData := false
+	for _, s := range positional[1:] {
+		it, err := parseItem(s)
+		if err != nil {
+			return nil, nil, err
+		}
+		if it.kind != itemHeader && it.kind != itemQuery {
+			hasData = true
+		}
+		items = append(items, it)
+	}
+
+	if method == "" {
+		method = http.MethodGet
+		if hasData {
+			method = http.MethodPost
+		}
+	}
+
+	transport := &captureTransport{base: http.DefaultTransport}
+	client := &http.Client{
+		Timeout:   opts.timeout,
+		Transport: transport,
+	}
+	if !opts.follow {
+		client.CheckRedirect = func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+	}
+
+	o, err := httpclient.NewRequest().WithClient(client).WithMethod(method).WithDefaultHeader().FromURLString(normalizeURL(positional[0]))
+	if err != nil {
+		return nil, nil, err
+	}
+	if o.Path == "" {
+		o.WithPath("/")
+	}
+
+	mode := bodyJSON
+	switch {
+	case opts.multipart:
+		mode = bodyMultipart
+	case opts.form:
+		mode = bodyForm
+	}
+	if err := applyItems(o, items, mode); err != nil {
+		return nil, nil, err
+	}
+	setDefaultHeader(o, "User-Agent", "httpc")
+
+	switch {
+	case opts.auth != "":
+		parts := strings.SplitN(opts.auth, ":", 2)
+		if len(parts) != 2 {
+			return nil, nil, fmt.Errorf("auth must be USER:PASSWORD")
+		}
+		o.WithBasicAuth(parts[0], parts[1])
+	case opts.bearer != "":
+		o.WithBearerToken(opts.bearer)
+	}
+
+	return o, transport, nil
+}
+
+func doWithRetries(o *httpclient.Request, retries int) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := o.Do()
+		if attempt >= retries || !isRetryable(resp, err) {
+			return resp, err
+		}
+		if resp != nil {
+			resp.Body.Close()
+		}
+		time.Sleep(retryBackoff << uint(attempt))
+	}
+}
+
+func isRetryable(resp *http.Response, err error) bool {
+	if resp == nil {
+		return err != nil
+	}
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode/100 == 5
+}
+
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+
+	var statusCodeErr *httpclient.StatusCodeError
+	if errors.As(err, &statusCodeErr) {
+		return statusCodeErr.StatusCode / 100
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return 2
+	}
+
+	return 1
+}
+
+// captureTransport records the last request made through it, so that it can
+// be printed exactly as it was sent.
+type captureTransport struct {
+	base http.RoundTripper
+	req  *http.Request
+	body []byte
+}
+
+func (t *captureTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.req = req
+	t.body = nil
+	if req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			t.body, _ = ioutil.ReadAll(body)
+			body.Close()
+		}
+	}
+
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRun(t *testing.T) {
+	retryBackoff = time.Millisecond
+
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/users":
+			body, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"method":"` + r.Method + `","tenant":"` + r.Header.Get("X-Tenant") + `","q":"` + r.URL.Query().Get("q") + `","body":` + string(body) + `}`))
+		case "/flaky":
+			attempts++
+			if attempts < 3 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.Write([]byte("ok"))
+		case "/slow":
+			time.Sleep(100 * time.Millisecond)
+		case "/redirect":
+			http.Redirect(w, r, "/users", http.StatusFound)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte("not found"))
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name       string
+		args       []string
+		want       string
+		wantStderr string
+		wantCode   int
+	}{
+		{
+			name:     "success post json",
+			args:     []string{server.URL + "/users", "name=bob", "age:=3", "X-Tenant:acme", "q==go"},
+			want:     `{"method":"POST","tenant":"acme","q":"go","body":{"age":3,"name":"bob"}}` + "\n",
+			wantCode: 0,
+		},
+		{
+			name:     "success explicit method and form",
+			args:     []string{"--form", "PUT", server.URL + "/users", "name=bob"},
+			want:     `{"method":"PUT","tenant":"","q":"","body":name=bob}` + "\n",
+			wantCode: 0,
+		},
+		{
+			name:     "success pretty",
+			args:     []string{server.URL + "/users", "a=b", "--pretty=format"},
+			want:     "{\n    \"method\": \"POST\",\n    \"tenant\": \"\",\n    \"q\": \"\",\n    \"body\": {\n        \"a\": \"b\"\n    }\n}\n",
+			wantCode: 0,
+		},
+		{
+			name:     "success verbose",
+			args:     []string{"--verbose", server.URL + "/users", "name=bob"},
+			want:     "POST /users HTTP/1.1\n",
+			wantCode: 0,
+		},
+		{
+			name:     "success verbose redacts auth",
+			args:     []string{"--print=H", "--auth", "bob:secret", server.URL + "/users"},
+			want:     "GET /users HTTP/1.1\nAccept: application/json, */*;q=0.5\nAuthorization: REDACTED\n",
+			wantCode: 0,
+		},
+		{
+			name:     "success retries",
+			args:     []string{"--retries", "3", server.URL + "/flaky"},
+			want:     "ok\n",
+			wantCode: 0,
+		},
+		{
+			name:       "error status code",
+			args:       []string{server.URL + "/missing"},
+			want:       "not found\n",
+			wantStderr: "httpc: warning: HTTP 404 Not Found\n",
+			wantCode:   4,
+		},
+		{
+			name:       "error redirect not followed",
+			args:       []string{server.URL + "/redirect"},
+			wantStderr: "httpc: warning: HTTP 302 Found\n",
+			wantCode:   3,
+		},
+		{
+			name:     "success redirect followed",
+			args:     []string{"--follow", server.URL + "/redirect"},
+			want:     `{"method":"GET","tenant":"","q":"","body":}` + "\n",
+			wantCode: 0,
+		},
+		{
+			name:     "error timeout",
+			args:     []string{"--timeout", "10ms", server.URL + "/slow"},
+			wantCode: 2,
+		},
+		{
+			name:       "error no url",
+			args:       []string{"GET"},
+			wantStderr: "httpc: error: must provide URL\n",
+			wantCode:   1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			got := run(tt.args, &stdout, &stderr, false)
+			if got != tt.wantCode {
+				t.Errorf("run() = %v, want %v (stderr %q)", got, tt.wantCode, stderr.String())
+			}
+			if !strings.HasPrefix(stdout.String(), tt.want) {
+				t.Errorf("run() stdout = %q, want prefix %q", stdout.String(), tt.want)
+			}
+			if tt.wantStderr != "" && stderr.String() != tt.wantStderr {
+				t.Errorf("run() stderr = %q, want %q", stderr.String(), tt.wantStderr)
+			}
+		})
+	}
+}
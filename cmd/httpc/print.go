@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strings"
+
+	httpclient "github.com/kevinsnydercodes/go-http-client"
+)
+
+const (
+	colorReset   = "\x1b[0m"
+	colorRed     = "\x1b[31m"
+	colorGreen   = "\x1b[32m"
+	colorYellow  = "\x1b[33m"
+	colorBlue    = "\x1b[34m"
+	colorMagenta = "\x1b[35m"
+	colorCyan    = "\x1b[36m"
+)
+
+// printer writes requests and responses according to the --print and --pretty
+// flags.
+type printer struct {
+	w      io.Writer
+	format bool
+	colors bool
+}
+
+func (p *printer) paint(color, s string) string {
+	if !p.colors {
+		return s
+	}
+	return color + s + colorReset
+}
+
+func (p *printer) printRequestHead(req *http.Request) {
+	uri := req.URL.RequestURI()
+	fmt.Fprintf(p.w, "%s %s %s\n", p.paint(colorBlue, req.Method), p.paint(colorCyan, uri), "HTTP/1.1")
+
+	header := httpclient.RedactHeader(req.Header)
+	if header.Get("Host") == "" {
+		header.Set("Host", req.URL.Host)
+	}
+	p.printHeader(header)
+	fmt.Fprintln(p.w)
+}
+
+func (p *printer) printResponseHead(resp *http.Response) {
+	color := colorGreen
+	switch resp.StatusCode / 100 {
+	case 3:
+		color = colorYellow
+	case 4, 5:
+		color = colorRed
+	}
+	fmt.Fprintf(p.w, "%s %s\n", resp.Proto, p.paint(color, resp.Status))
+
+	p.printHeader(resp.Header)
+	fmt.Fprintln(p.w)
+}
+
+func (p *printer) printHeader(header http.Header) {
+	var keys []string
+	for key := range header {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		for _, value := range header[key] {
+			fmt.Fprintf(p.w, "%s: %s\n", p.paint(colorCyan, key), value)
+		}
+	}
+}
+
+func (p *printer) printBody(contentType string, body []byte) {
+	if len(body) == 0 {
+		return
+	}
+
+	if p.format && isJSON(contentType, body) {
+		var b bytes.Buffer
+		if err := json.Indent(&b, body, "", "    "); err == nil {
+			body = b.Bytes()
+			if p.colors {
+				body = []byte(colorizeJSON(string(body)))
+			}
+		}
+	}
+
+	p.w.Write(body)
+	if body[len(body)-1] != '\n' {
+		fmt.Fprintln(p.w)
+	}
+}
+
+func isJSON(contentType string, body []byte) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err == nil && (mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")) {
+		return true
+	}
+	return (err != nil || strings.HasPrefix(mediaType, "text/")) && json.Valid(body)
+}
+
+// colorizeJSON colors the keys, strings, numbers, and literals of indented JSON.
+func colorizeJSON(s string) string {
+	var b strings.Builder
+
+	for i := 0; i < len(s); {
+		switch c := s[i]; {
+		case c == '"':
+			end := i + 1
+			for end < len(s) && s[end] != '"' {
+				if s[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			end++
+			if end > len(s) {
+				end = len(s)
+			}
+
+			color := colorGreen
+			if rest := strings.TrimLeft(s[end:], " "); strings.HasPrefix(rest, ":") {
+				color = colorBlue
+			}
+			b.WriteString(color + s[i:end] + colorReset)
+			i = end
+		case c == '-' || (c >= '0' && c <= '9'):
+			end := i + 1
+			for end < len(s) && strings.IndexByte("0123456789.eE+-", s[end]) >= 0 {
+				end++
+			}
+			b.WriteString(colorMagenta + s[i:end] + colorReset)
+			i = end
+		case strings.HasPrefix(s[i:], "true"), strings.HasPrefix(s[i:], "null"):
+			b.WriteString(colorYellow + s[i:i+4] + colorReset)
+			i += 4
+		case strings.HasPrefix(s[i:], "false"):
+			b.WriteString(colorYellow + s[i:i+5] + colorReset)
+			i += 5
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+
+	return b.String()
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPrinter_printBody(t *testing.T) {
+	tests := []struct {
+		name        string
+		format      bool
+		colors      bool
+		contentType string
+		body        []byte
+		want        string
+	}{
+		{
+			name:        "success raw",
+			contentType: "application/json",
+			body:        []byte(`{"a":1}`),
+			want:        "{\"a\":1}\n",
+		},
+		{
+			name:        "success formatted json",
+			format:      true,
+			contentType: "application/json; charset=utf-8",
+			body:        []byte(`{"a":[1,true]}`),
+			want:        "{\n    \"a\": [\n        1,\n        true\n    ]\n}\n",
+		},
+		{
+			name:        "success formatted json without content type",
+			format:      true,
+			contentType: "",
+			body:        []byte(`{"a":1}`),
+			want:        "{\n    \"a\": 1\n}\n",
+		},
+		{
+			name:        "success colored json",
+			format:      true,
+			colors:      true,
+			contentType: "application/problem+json",
+			body:        []byte(`{"a":"b","c":null}`),
+			want:        "{\n    \x1b[34m\"a\"\x1b[0m: \x1b[32m\"b\"\x1b[0m,\n    \x1b[34m\"c\"\x1b[0m: \x1b[33mnull\x1b[0m\n}\n",
+		},
+		{
+			name:        "success text not formatted",
+			format:      true,
+			contentType: "text/html",
+			body:        []byte("<p>hi</p>\n"),
+			want:        "<p>hi</p>\n",
+		},
+		{
+			name: "success empty",
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b bytes.Buffer
+			p := &printer{w: &b, format: tt.format, colors: tt.colors}
+			p.printBody(tt.contentType, tt.body)
+			if got := b.String(); got != tt.want {
+				t.Errorf("printer.printBody() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestColorizeJSON(t *testing.T) {
+	tests := []struct {
+		s    string
+		want string
+	}{
+		{s: `"a\"b": -1.5e3`, want: "\x1b[34m\"a\\\"b\"\x1b[0m: \x1b[35m-1.5e3\x1b[0m"},
+		{s: `[false, "x"]`, want: "[\x1b[33mfalse\x1b[0m, \x1b[32m\"x\"\x1b[0m]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			if got := colorizeJSON(tt.s); got != tt.want {
+				t.Errorf("colorizeJSON() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+// Command httprun runs the requests of .http files in order and prints a pass
+// or fail summary with timings.
+//
+// Usage:
+//
+//	httprun [-env-file http-client.env.json] [-env dev] [-timeout 30s] FILE ...
+//
+// The exit code is 0 if every request passed, and 1 otherwise.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/kevinsnydercodes/go-http-client/httpfile"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("httprun", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	envFile := flags.String("env-file", "", "path of the JSON environment file")
+	envName := flags.String("env", "", "name of the environment to use from the environment file")
+	timeout := flags.Duration("timeout", 0, "timeout of each request")
+	flags.Usage = func() {
+		fmt.Fprintf(stderr, "usage: httprun [flags] FILE ...\n\nflags:\n")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 1
+	}
+
+	runner := &httpfile.Runner{
+		Client: &http.Client{Timeout: *timeout},
+	}
+	if *envFile != "" {
+		env, err := httpfile.LoadEnvironment(*envFile, *envName)
+		if err != nil {
+			fmt.Fprintf(stderr, "httprun: error: %v\n", err)
+			return 1
+		}
+		runner.Environment = env
+	}
+
+	code := 0
+	for _, path := range flags.Args() {
+		file, err := httpfile.ParseFile(path)
+		if err != nil {
+			fmt.Fprintf(stderr, "httprun: error: %v\n", err)
+			code = 1
+			continue
+		}
+
+		results := runner.Run(context.Background(), file)
+		fmt.Fprintf(stdout, "%s\n", path)
+		httpfile.WriteSummary(stdout, results)
+
+		for _, result := range results {
+			if !result.Passed() {
+				code = 1
+			}
+		}
+	}
+
+	return code
+}
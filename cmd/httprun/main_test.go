@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/users/bob":
+			if r.Header.Get("X-Tenant") != "acme" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.Write([]byte(`{}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "httprun")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"http-client.env.json": `{"$shared": {"tenant": "acme"}, "dev": {"host": "` + server.URL + `"}}`,
+		"users.http": strings.Join([]string{
+			"@user = bob",
+			"# @name user",
+			"GET {{host}}/users/{{user}}",
+			"X-Tenant: {{tenant}}",
+		}, "\n"),
+		"missing.http": strings.Join([]string{
+			"# @name missing",
+			"GET {{host}}/missing",
+		}, "\n"),
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	envFile := filepath.Join(dir, "http-client.env.json")
+
+	tests := []struct {
+		name       string
+		args       []string
+		want       []string
+		wantStderr string
+		wantCode   int
+	}{
+		{
+			name: "success variables",
+			args: []string{"-env-file", envFile, "-env", "dev", filepath.Join(dir, "users.http")},
+			want: []string{
+				"PASS user: GET " + server.URL + "/users/bob 200 ",
+				"1 passed, 0 failed ",
+			},
+			wantCode: 0,
+		},
+		{
+			name: "error status code",
+			args: []string{"-env-file", envFile, "-env", "dev", filepath.Join(dir, "missing.http")},
+			want: []string{
+				"FAIL missing: GET " + server.URL + "/missing 404 ",
+				"0 passed, 1 failed ",
+			},
+			wantCode: 1,
+		},
+		{
+			name:     "error undefined variable",
+			args:     []string{filepath.Join(dir, "users.http")},
+			want:     []string{`undefined variable "host"`, "0 passed, 1 failed "},
+			wantCode: 1,
+		},
+		{
+			name:     "error file not found",
+			args:     []string{filepath.Join(dir, "none.http")},
+			wantCode: 1,
+		},
+		{
+			name:       "error no files",
+			args:       []string{},
+			wantStderr: "usage: httprun [flags] FILE ...\n",
+			wantCode:   1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			got := run(tt.args, &stdout, &stderr)
+			if got != tt.wantCode {
+				t.Errorf("run() = %v, want %v (stderr %q)", got, tt.wantCode, stderr.String())
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(stdout.String(), want) {
+					t.Errorf("run() stdout = %q, want %q", stdout.String(), want)
+				}
+			}
+			if tt.wantStderr != "" && !strings.HasPrefix(stderr.String(), tt.wantStderr) {
+				t.Errorf("run() stderr = %q, want prefix %q", stderr.String(), tt.wantStderr)
+			}
+		})
+	}
+}
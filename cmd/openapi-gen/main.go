@@ -0,0 +1,73 @@
+// Command openapi-gen generates a typed client from an OpenAPI 3.0 or 3.1 JSON
+// document.
+//
+// The generated client builds and makes requests with the Request of
+// github.com/kevinsnydercodes/go-http-client.
+//
+// Usage:
+//
+//	openapi-gen -in openapi.json -out ./client -package client [-split]
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/kevinsnydercodes/go-http-client/internal/openapi"
+)
+
+func main() {
+	in := flag.String("in", "", "path of the OpenAPI JSON document")
+	out := flag.String("out", ".", "directory to write the generated files to")
+	pkg := flag.String("package", "client", "name of the generated package")
+	split := flag.Bool("split", false, "place the operations of each tag in their own file")
+	flag.Parse()
+
+	if err := run(*in, *out, openapi.Options{PackageName: *pkg, SplitByTags: *split}); err != nil {
+		fmt.Fprintf(os.Stderr, "openapi-gen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(in, out string, opts openapi.Options) error {
+	if in == "" {
+		return fmt.Errorf("must provide -in")
+	}
+
+	data, err := ioutil.ReadFile(in)
+	if err != nil {
+		return fmt.Errorf("error reading document: %w", err)
+	}
+
+	doc, err := openapi.Parse(data)
+	if err != nil {
+		return err
+	}
+
+	files, err := openapi.Generate(doc, opts)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(out, 0755); err != nil {
+		return fmt.Errorf("error creating output directory: %w", err)
+	}
+
+	var names []string
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err := ioutil.WriteFile(filepath.Join(out, name), files[name], 0644); err != nil {
+			return fmt.Errorf("error writing %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
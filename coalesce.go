@@ -0,0 +1,150 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultCoalesceTimeout = 30 * time.Second
+
+// DefaultCoalesceHeaders are the headers that distinguish coalesced requests
+// by default.
+var DefaultCoalesceHeaders = []string{"Accept", "Accept-Encoding", "Authorization", "Cookie"}
+
+// Coalescer shares one HTTP call between identical concurrent GET and HEAD
+// requests. It is safe for concurrent use.
+//
+// Requests are identical if their method, URL, and Headers match, as sent
+// after authentication. Requests with any other header, such as an API key,
+// are not coalesced, so that no request gets a response made with another's
+// credentials. Each request gets its own copy of the response, with the body
+// read into memory.
+//
+// The call runs on a context detached from the request that started it, up to
+// Timeout, and each request gives up waiting only when its own context is
+// done.
+type Coalescer struct {
+	// Headers are the headers that must match, DefaultCoalesceHeaders if nil.
+	// Requests with other headers are not coalesced.
+	Headers []string
+
+	// Timeout bounds each shared call, 30 seconds if zero.
+	Timeout time.Duration
+
+	mu    sync.Mutex
+	calls map[string]*coalescedCall
+}
+
+type coalescedCall struct {
+	done chan struct{}
+	resp *http.Response
+	body []byte
+	err  error
+}
+
+// Middleware coalesces the requests of a client.
+func (o *Coalescer) Middleware(next http.RoundTripper) http.RoundTripper {
+	return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if req.Method != http.MethodGet && req.Method != http.MethodHead {
+			return next.RoundTrip(req)
+		}
+
+		key, ok := o.key(req)
+		if !ok {
+			return next.RoundTrip(req)
+		}
+
+		o.mu.Lock()
+		if o.calls == nil {
+			o.calls = map[string]*coalescedCall{}
+		}
+		call, ok := o.calls[key]
+		if !ok {
+			call = &coalescedCall{done: make(chan struct{})}
+			o.calls[key] = call
+		}
+		o.mu.Unlock()
+
+		if !ok {
+			go o.call(next, key, req, call)
+		}
+
+		select {
+		case <-call.done:
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+		if call.err != nil {
+			return nil, call.err
+		}
+
+		resp := *call.resp
+		resp.Header = call.resp.Header.Clone()
+		resp.Body = ioutil.NopCloser(bytes.NewReader(call.body))
+		resp.Request = req
+		return &resp, nil
+	})
+}
+
+// call makes the call for the requests waiting on it.
+func (o *Coalescer) call(next http.RoundTripper, key string, req *http.Request, call *coalescedCall) {
+	timeout := o.Timeout
+	if timeout <= 0 {
+		timeout = defaultCoalesceTimeout
+	}
+	ctx, cancel := context.WithTimeout(detachedContext{req.Context()}, timeout)
+	defer cancel()
+
+	call.resp, call.err = next.RoundTrip(req.Clone(ctx))
+	if call.err == nil {
+		call.body, call.err = ioutil.ReadAll(call.resp.Body)
+		call.resp.Body.Close()
+	}
+
+	o.mu.Lock()
+	delete(o.calls, key)
+	o.mu.Unlock()
+	close(call.done)
+}
+
+// key returns the key of the request, or false if it has headers that are not
+// part of the key.
+func (o *Coalescer) key(req *http.Request) (string, bool) {
+	headers := o.Headers
+	if headers == nil {
+		headers = DefaultCoalesceHeaders
+	}
+
+	keyed := map[string]bool{}
+	for _, name := range headers {
+		keyed[http.CanonicalHeaderKey(name)] = true
+	}
+	for name := range req.Header {
+		if !keyed[http.CanonicalHeaderKey(name)] {
+			return "", false
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(req.Method)
+	b.WriteString(" ")
+	b.WriteString(req.URL.String())
+	for _, name := range headers {
+		b.WriteString("\n")
+		b.WriteString(http.CanonicalHeaderKey(name))
+		b.WriteString(": ")
+		b.WriteString(strings.Join(req.Header[http.CanonicalHeaderKey(name)], ", "))
+	}
+	return b.String(), true
+}
+
+// WithCoalescer shares HTTP calls between identical concurrent requests of the
+// Request's client with the Coalescer.
+func (o *Request) WithCoalescer(coalescer *Coalescer) *Request {
+	return o.WithMiddleware(coalescer.Middleware)
+}
@@ -0,0 +1,192 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRequest_WithCoalescer(t *testing.T) {
+	type config struct {
+		Name string `json:"name"`
+	}
+	tests := []struct {
+		name      string
+		method    string
+		key       string
+		header    func(i int) string
+		wantCalls int32
+	}{
+		{
+			name:      "success coalesced",
+			method:    http.MethodGet,
+			header:    func(i int) string { return "Bearer a" },
+			wantCalls: 1,
+		},
+		{
+			name:      "success different header",
+			method:    http.MethodGet,
+			header:    func(i int) string { return []string{"Bearer a", "Bearer b"}[i%2] },
+			wantCalls: 2,
+		},
+		{
+			name:      "success other header not coalesced",
+			method:    http.MethodGet,
+			key:       "X-API-Key",
+			header:    func(i int) string { return []string{"a", "b"}[i%2] },
+			wantCalls: 4,
+		},
+		{
+			name:      "success not coalesced",
+			method:    http.MethodPost,
+			header:    func(i int) string { return "Bearer a" },
+			wantCalls: 4,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int32
+			release := make(chan struct{})
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&calls, 1)
+				<-release
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(`{"name":"config"}`))
+			}))
+			defer server.Close()
+
+			base, err := NewRequest().WithMethod(tt.method).WithCoalescer(&Coalescer{}).AddHeader("Accept", "application/json").FromURLString(server.URL + "/config")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			configs := make([]config, 4)
+			var wg sync.WaitGroup
+			for i := range configs {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					key := tt.key
+					if key == "" {
+						key = "Authorization"
+					}
+					req := base.Clone().AddHeader(key, tt.header(i)).WithResponseBody(&configs[i])
+					resp, err := req.DoContext(context.Background())
+					if err != nil {
+						t.Errorf("Request.Do() error = %v", err)
+						return
+					}
+					resp.Body.Close()
+				}(i)
+			}
+			time.Sleep(50 * time.Millisecond)
+			close(release)
+			wg.Wait()
+
+			if got := atomic.LoadInt32(&calls); got != tt.wantCalls {
+				t.Errorf("server called %d times, want %d", got, tt.wantCalls)
+			}
+			for i := range configs {
+				if configs[i].Name != "config" {
+					t.Errorf("Request.Do() response body %d = %+v", i, configs[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCoalescer_Middleware_cancel(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	base, err := NewRequest().WithMethod(http.MethodGet).WithCoalescer(&Coalescer{}).FromURLString(server.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go base.Clone().Do()
+	time.Sleep(10 * time.Millisecond)
+
+	// A waiting request can give up without affecting the call.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := base.Clone().DoContext(ctx); err == nil {
+		t.Errorf("Request.DoContext() error = nil, want context error")
+	}
+}
+
+func TestCoalescer_Middleware_cancelFirst(t *testing.T) {
+	received := make(chan struct{})
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(received)
+		<-release
+		fmt.Fprint(w, "shared")
+	}))
+	defer server.Close()
+
+	base, err := NewRequest().WithMethod(http.MethodGet).WithCoalescer(&Coalescer{}).FromURLString(server.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error, 1)
+	go func() {
+		_, err := base.Clone().DoContext(ctx)
+		first <- err
+	}()
+	<-received
+
+	var body []byte
+	second := make(chan error, 1)
+	go func() {
+		_, err := base.Clone().WithResponseBody(&body).Do()
+		second <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	// The request that started the call can give up without affecting the
+	// others.
+	cancel()
+	if err := <-first; !errors.Is(err, context.Canceled) {
+		t.Errorf("Request.DoContext() error = %v, want %v", err, context.Canceled)
+	}
+
+	close(release)
+	if err := <-second; err != nil || string(body) != "shared" {
+		t.Errorf("Request.Do() = %q, %v, want %q", body, err, "shared")
+	}
+}
+
+func TestCoalescer_Middleware_timeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	base, err := NewRequest().WithMethod(http.MethodGet).WithCoalescer(&Coalescer{Timeout: 10 * time.Millisecond}).FromURLString(server.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The shared call times out even though the request has no deadline.
+	if _, err := base.Clone().Do(); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Request.Do() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
@@ -0,0 +1,62 @@
+package http
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"hash"
+	"net/http"
+	"strings"
+)
+
+// ContentDigest returns the value of a "Content-Digest" header for the body,
+// as defined by RFC 9530, using SHA-256.
+func ContentDigest(body []byte) string {
+	sum := sha256.Sum256(body)
+	return "sha-256=:" + base64.StdEncoding.EncodeToString(sum[:]) + ":"
+}
+
+// VerifyContentDigest checks the body against the "Content-Digest" header,
+// which must contain at least one sha-256 or sha-512 digest. All supported
+// digests of the header must match.
+func VerifyContentDigest(header http.Header, body []byte) error {
+	value := strings.Join(header[http.CanonicalHeaderKey("Content-Digest")], ", ")
+	if value == "" {
+		return fmt.Errorf("missing Content-Digest header")
+	}
+
+	members, err := parseDictionary(value)
+	if err != nil {
+		return fmt.Errorf("error parsing Content-Digest header: %w", err)
+	}
+
+	verified := 0
+	for _, member := range members {
+		var h hash.Hash
+		switch member.key {
+		case "sha-256":
+			h = sha256.New()
+		case "sha-512":
+			h = sha512.New()
+		default:
+			continue
+		}
+
+		want, err := parseByteSequence(member.value)
+		if err != nil {
+			return fmt.Errorf("error parsing %s digest: %w", member.key, err)
+		}
+		h.Write(body)
+		if subtle.ConstantTimeCompare(h.Sum(nil), want) != 1 {
+			return fmt.Errorf("%s digest does not match body", member.key)
+		}
+		verified++
+	}
+	if verified == 0 {
+		return fmt.Errorf("no supported digest algorithm in Content-Digest header")
+	}
+
+	return nil
+}
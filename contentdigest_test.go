@@ -0,0 +1,73 @@
+package http
+
+import (
+	"net/http"
+	"testing"
+)
+
+// The test vectors are from RFC 9530.
+
+func TestContentDigest(t *testing.T) {
+	want := "sha-256=:X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=:"
+	if got := ContentDigest([]byte(`{"hello": "world"}`)); got != want {
+		t.Errorf("ContentDigest() = %v, want %v", got, want)
+	}
+}
+
+func TestVerifyContentDigest(t *testing.T) {
+	type args struct {
+		header http.Header
+		body   string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr bool
+	}{
+		{
+			name: "success sha-256",
+			args: args{
+				header: http.Header{"Content-Digest": []string{"sha-256=:X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=:"}},
+				body:   `{"hello": "world"}`,
+			},
+		},
+		{
+			name: "success sha-512 and unknown algorithm",
+			args: args{
+				header: http.Header{"Content-Digest": []string{"md5=:AAAA:, sha-512=:WZDPaVn/7XgHaAy8pmojAkGWoRx2UFChF41A2svX+TaPm+AbwAgBWnrIiYllu7BNNyealdVLvRwEmTHWXvJwew==:"}},
+				body:   `{"hello": "world"}`,
+			},
+		},
+		{
+			name: "error mismatch",
+			args: args{
+				header: http.Header{"Content-Digest": []string{"sha-256=:X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=:"}},
+				body:   `{"hello": "there"}`,
+			},
+			wantErr: true,
+		},
+		{
+			name: "error unsupported algorithm",
+			args: args{
+				header: http.Header{"Content-Digest": []string{"md5=:AAAA:"}},
+				body:   `{"hello": "world"}`,
+			},
+			wantErr: true,
+		},
+		{
+			name: "error missing",
+			args: args{
+				header: http.Header{},
+				body:   `{"hello": "world"}`,
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := VerifyContentDigest(tt.args.header, []byte(tt.args.body)); (err != nil) != tt.wantErr {
+				t.Errorf("VerifyContentDigest() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,269 @@
+package http
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// DigestAuth authenticates with HTTP Digest authentication, as defined by RFC
+// 7616.
+//
+// The first request to each origin is sent without credentials. DigestAuth
+// answers the challenge of the 401 response, which Do retries with the request
+// body, and reuses the challenge for later requests to the origin, counting
+// its uses.
+type DigestAuth struct {
+	Username string
+	Password Secret
+
+	mu         sync.Mutex
+	challenges map[string]*digestChallenge
+
+	// cnonce returns the client nonce, a random value if nil.
+	cnonce func() string
+}
+
+type digestChallenge struct {
+	realm     string
+	nonce     string
+	opaque    string
+	algorithm string
+	qop       string
+
+	// count is the number of uses of the nonce.
+	count uint32
+}
+
+// Authenticate sets the "Authorization" header of the request, once a
+// challenge has been received from its origin.
+func (o *DigestAuth) Authenticate(req *http.Request) error {
+	o.mu.Lock()
+	challenge := o.challenges[digestOrigin(req)]
+	var count uint32
+	if challenge != nil {
+		challenge.count++
+		count = challenge.count
+	}
+	o.mu.Unlock()
+
+	if challenge == nil {
+		return nil
+	}
+
+	authorization, err := o.authorization(req, challenge, count)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", authorization)
+	return nil
+}
+
+// Refresh stores the challenge of the 401 response for the origin of its
+// request. It returns false if the response has no supported challenge, or
+// rejects credentials computed from the stored challenge of the origin whose
+// nonce is not stale.
+func (o *DigestAuth) Refresh(resp *http.Response) bool {
+	challenge, stale := parseDigestChallenge(resp.Header[http.CanonicalHeaderKey("WWW-Authenticate")])
+	if challenge == nil || resp.Request == nil {
+		return false
+	}
+	origin := digestOrigin(resp.Request)
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if stored := o.challenges[origin]; stored != nil && !stale && digestAnswers(resp.Request, stored) {
+		return false
+	}
+	if o.challenges == nil {
+		o.challenges = map[string]*digestChallenge{}
+	}
+	o.challenges[origin] = challenge
+	return true
+}
+
+func (o *DigestAuth) authorization(req *http.Request, c *digestChallenge, count uint32) (string, error) {
+	newHash := md5.New
+	algorithm := strings.ToUpper(c.algorithm)
+	if strings.HasPrefix(algorithm, "SHA-256") {
+		newHash = sha256.New
+	}
+	h := func(s string) string {
+		return hashHex(newHash(), s)
+	}
+
+	cnonce := ""
+	if o.cnonce != nil {
+		cnonce = o.cnonce()
+	} else {
+		b := make([]byte, 16)
+		if _, err := rand.Read(b); err != nil {
+			return "", err
+		}
+		cnonce = base64.RawURLEncoding.EncodeToString(b)
+	}
+	nc := fmt.Sprintf("%08x", count)
+	uri := req.URL.RequestURI()
+
+	ha1 := h(o.Username + ":" + c.realm + ":" + string(o.Password))
+	if strings.HasSuffix(algorithm, "-SESS") {
+		ha1 = h(ha1 + ":" + c.nonce + ":" + cnonce)
+	}
+
+	ha2 := h(req.Method + ":" + uri)
+	if c.qop == "auth-int" {
+		body, err := readBody(req)
+		if err != nil {
+			return "", fmt.Errorf("error reading body: %w", err)
+		}
+		ha2 = h(req.Method + ":" + uri + ":" + hashHex(newHash(), string(body)))
+	}
+
+	var response string
+	if c.qop == "" {
+		response = h(ha1 + ":" + c.nonce + ":" + ha2)
+	} else {
+		response = h(strings.Join([]string{ha1, c.nonce, nc, cnonce, c.qop, ha2}, ":"))
+	}
+
+	params := []string{
+		fmt.Sprintf("username=%q", o.Username),
+		fmt.Sprintf("realm=%q", c.realm),
+		fmt.Sprintf("uri=%q", uri),
+		fmt.Sprintf("algorithm=%s", c.algorithm),
+		fmt.Sprintf("nonce=%q", c.nonce),
+	}
+	if c.qop != "" {
+		params = append(params, fmt.Sprintf("nc=%s", nc), fmt.Sprintf("cnonce=%q", cnonce), fmt.Sprintf("qop=%s", c.qop))
+	}
+	params = append(params, fmt.Sprintf("response=%q", response))
+	if c.opaque != "" {
+		params = append(params, fmt.Sprintf("opaque=%q", c.opaque))
+	}
+
+	return "Digest " + strings.Join(params, ", "), nil
+}
+
+// digestOrigin returns the scheme and host of the request, which share a
+// challenge.
+func digestOrigin(req *http.Request) string {
+	return req.URL.Scheme + "://" + requestHost(req)
+}
+
+// digestAnswers reports whether the "Authorization" header of the request was
+// computed from the challenge.
+func digestAnswers(req *http.Request, c *digestChallenge) bool {
+	authorization := req.Header.Get("Authorization")
+	if len(authorization) < 7 || !strings.EqualFold(authorization[:7], "Digest ") {
+		return false
+	}
+	params := parseAuthParams(authorization[7:])
+	return params["nonce"] == c.nonce && params["realm"] == c.realm
+}
+
+// parseDigestChallenge returns the strongest supported Digest challenge of the
+// "WWW-Authenticate" header values, and whether it is marked stale.
+func parseDigestChallenge(values []string) (*digestChallenge, bool) {
+	var best *digestChallenge
+	stale := false
+
+	for _, value := range values {
+		if len(value) < 7 || !strings.EqualFold(value[:7], "Digest ") {
+			continue
+		}
+		params := parseAuthParams(value[7:])
+
+		c := &digestChallenge{
+			realm:     params["realm"],
+			nonce:     params["nonce"],
+			opaque:    params["opaque"],
+			algorithm: params["algorithm"],
+		}
+		if c.algorithm == "" {
+			c.algorithm = "MD5"
+		}
+		switch strings.ToUpper(c.algorithm) {
+		case "MD5", "MD5-SESS", "SHA-256", "SHA-256-SESS":
+		default:
+			continue
+		}
+		if qop, ok := params["qop"]; ok {
+			for _, option := range strings.Split(qop, ",") {
+				switch strings.TrimSpace(option) {
+				case "auth":
+					c.qop = "auth"
+				case "auth-int":
+					if c.qop == "" {
+						c.qop = "auth-int"
+					}
+				}
+			}
+			if c.qop == "" {
+				continue
+			}
+		}
+
+		if best == nil || (strings.HasPrefix(strings.ToUpper(c.algorithm), "SHA-256") && !strings.HasPrefix(strings.ToUpper(best.algorithm), "SHA-256")) {
+			best = c
+			stale = strings.EqualFold(params["stale"], "true")
+		}
+	}
+
+	return best, stale
+}
+
+// parseAuthParams parses comma-separated auth parameters, which may be quoted.
+func parseAuthParams(s string) map[string]string {
+	params := map[string]string{}
+
+	for {
+		s = strings.TrimLeft(s, " ,")
+		eq := strings.Index(s, "=")
+		if eq < 0 {
+			return params
+		}
+		key := strings.ToLower(strings.TrimSpace(s[:eq]))
+		s = strings.TrimLeft(s[eq+1:], " ")
+
+		var value strings.Builder
+		if strings.HasPrefix(s, `"`) {
+			i := 1
+			for ; i < len(s) && s[i] != '"'; i++ {
+				if s[i] == '\\' && i+1 < len(s) {
+					i++
+				}
+				value.WriteByte(s[i])
+			}
+			if i < len(s) {
+				i++
+			}
+			s = s[i:]
+		} else {
+			end := strings.Index(s, ",")
+			if end < 0 {
+				end = len(s)
+			}
+			value.WriteString(strings.TrimSpace(s[:end]))
+			s = s[end:]
+		}
+		params[key] = value.String()
+	}
+}
+
+func hashHex(h hash.Hash, s string) string {
+	h.Write([]byte(s))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// WithDigestAuth sets the authenticator of the Request to digest auth.
+func (o *Request) WithDigestAuth(username, password string) *Request {
+	return o.WithAuth(&DigestAuth{Username: username, Password: Secret(password)})
+}
@@ -0,0 +1,308 @@
+package http
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// The test vectors are from RFC 7616, section 3.9.1.
+
+func TestDigestAuth_authorization(t *testing.T) {
+	tests := []struct {
+		name      string
+		algorithm string
+		want      string
+	}{
+		{
+			name:      "success md5",
+			algorithm: "MD5",
+			want:      "8ca523f5e9506fed4657c9700eebdbec",
+		},
+		{
+			name:      "success sha-256",
+			algorithm: "SHA-256",
+			want:      "753927fa0e85d155564e2e272a28d1802ca10daf4496794697cf8db5856cb6c1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := &DigestAuth{
+				Username: "Mufasa",
+				Password: "Circle of Life",
+				cnonce: func() string {
+					return "f2/wE4q74E6zIJEtWaHKaf5wv/H5QzzpXusqGemxURZJ"
+				},
+			}
+			challenge := &digestChallenge{
+				realm:     "http-auth@example.org",
+				nonce:     "7ypf/xlj9XXwfDPEoM4URrv/xwf94BcCAzFZH4GiTo0v",
+				opaque:    "FQhe/qaU925kfnzjCev0ciny7QMkPqMAFRtzCUYo5tdS",
+				algorithm: tt.algorithm,
+				qop:       "auth",
+			}
+			req, err := http.NewRequest(http.MethodGet, "http://www.example.org/dir/index.html", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := o.authorization(req, challenge, 1)
+			if err != nil {
+				t.Fatalf("DigestAuth.authorization() error = %v", err)
+			}
+			if params := parseAuthParams(strings.TrimPrefix(got, "Digest ")); params["response"] != tt.want {
+				t.Errorf("DigestAuth.authorization() = %v, want response %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDigestChallenge(t *testing.T) {
+	tests := []struct {
+		name      string
+		values    []string
+		want      *digestChallenge
+		wantStale bool
+	}{
+		{
+			name: "success strongest algorithm",
+			values: []string{
+				`Basic realm="x"`,
+				`Digest realm="r", qop="auth, auth-int", algorithm=MD5, nonce="n1", opaque="o"`,
+				`Digest realm="r", qop="auth-int", algorithm=SHA-256, nonce="n2", stale=TRUE`,
+			},
+			want: &digestChallenge{
+				realm:     "r",
+				nonce:     "n2",
+				algorithm: "SHA-256",
+				qop:       "auth-int",
+			},
+			wantStale: true,
+		},
+		{
+			name:   "success default algorithm without qop",
+			values: []string{`Digest realm="a \"quoted\" realm", nonce="n"`},
+			want: &digestChallenge{
+				realm:     `a "quoted" realm`,
+				nonce:     "n",
+				algorithm: "MD5",
+			},
+		},
+		{
+			name:   "error unsupported algorithm",
+			values: []string{`Digest realm="r", nonce="n", algorithm=SHA-512-256`},
+			want:   nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, stale := parseDigestChallenge(tt.values)
+			if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+				t.Errorf("parseDigestChallenge() = %+v, want %+v", got, tt.want)
+			}
+			if stale != tt.wantStale {
+				t.Errorf("parseDigestChallenge() stale = %v, want %v", stale, tt.wantStale)
+			}
+		})
+	}
+}
+
+// mockDigestServer implements the server side of Digest authentication for a
+// single user.
+type mockDigestServer struct {
+	t         *testing.T
+	algorithm string
+	qop       string
+
+	// unknownNonce is the stale flag for a nonce the server did not issue.
+	unknownNonce bool
+
+	mu        sync.Mutex
+	nonce     int
+	lastCount int64
+	requests  int
+}
+
+func (s *mockDigestServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests++
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+
+	header := r.Header.Get("Authorization")
+	if !strings.HasPrefix(header, "Digest ") {
+		s.challenge(w, false)
+		return
+	}
+	params := parseAuthParams(strings.TrimPrefix(header, "Digest "))
+
+	if params["nonce"] != strconv.Itoa(s.nonce) {
+		s.challenge(w, s.unknownNonce)
+		return
+	}
+	count, err := strconv.ParseInt(params["nc"], 16, 64)
+	if err != nil || count <= s.lastCount {
+		s.t.Errorf("nonce count %q not increasing from %d", params["nc"], s.lastCount)
+	}
+	s.lastCount = count
+
+	var newHash func() hash.Hash = md5.New
+	if strings.HasPrefix(s.algorithm, "SHA-256") {
+		newHash = sha256.New
+	}
+	h := func(v string) string {
+		return fmt.Sprintf("%x", hashOf(newHash, v))
+	}
+
+	ha1 := h("bob:test:secret")
+	if strings.HasSuffix(s.algorithm, "-sess") {
+		ha1 = h(ha1 + ":" + params["nonce"] + ":" + params["cnonce"])
+	}
+	ha2 := h(r.Method + ":" + params["uri"])
+	if s.qop == "auth-int" {
+		ha2 = h(r.Method + ":" + params["uri"] + ":" + h(string(body)))
+	}
+	want := h(ha1 + ":" + params["nonce"] + ":" + params["nc"] + ":" + params["cnonce"] + ":" + params["qop"] + ":" + ha2)
+
+	if params["response"] != want || params["uri"] != r.URL.RequestURI() || params["opaque"] != "opaque" {
+		s.challenge(w, false)
+		return
+	}
+	fmt.Fprintf(w, "%s", body)
+}
+
+func (s *mockDigestServer) challenge(w http.ResponseWriter, stale bool) {
+	s.nonce++
+	s.lastCount = 0
+	w.Header().Add("WWW-Authenticate", fmt.Sprintf(`Digest realm="test", qop="%s", algorithm=%s, nonce="%d", opaque="opaque", stale=%t`, s.qop, s.algorithm, s.nonce, stale))
+	w.WriteHeader(http.StatusUnauthorized)
+}
+
+func hashOf(newHash func() hash.Hash, v string) []byte {
+	h := newHash()
+	h.Write([]byte(v))
+	return h.Sum(nil)
+}
+
+func TestRequest_Do_digestAuth(t *testing.T) {
+	tests := []struct {
+		name         string
+		algorithm    string
+		qop          string
+		password     string
+		wantRequests int
+		wantErr      bool
+	}{
+		{
+			name:         "success md5 auth",
+			algorithm:    "MD5",
+			qop:          "auth",
+			password:     "secret",
+			wantRequests: 4,
+		},
+		{
+			name:         "success sha-256 auth-int",
+			algorithm:    "SHA-256",
+			qop:          "auth-int",
+			password:     "secret",
+			wantRequests: 4,
+		},
+		{
+			name:         "success md5-sess",
+			algorithm:    "MD5-sess",
+			qop:          "auth",
+			password:     "secret",
+			wantRequests: 4,
+		},
+		{
+			name:         "success sha-256-sess auth-int",
+			algorithm:    "SHA-256-sess",
+			qop:          "auth-int",
+			password:     "secret",
+			wantRequests: 4,
+		},
+		{
+			name:         "error wrong password",
+			algorithm:    "SHA-256",
+			qop:          "auth",
+			password:     "wrong",
+			wantRequests: 2,
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := &mockDigestServer{t: t, algorithm: tt.algorithm, qop: tt.qop, unknownNonce: true}
+			server := httptest.NewServer(handler)
+			defer server.Close()
+
+			base, err := NewRequest().WithDigestAuth("bob", tt.password).FromURLString(server.URL + "/dir/index.html?a=b")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// The first request answers the challenge, and the rest reuse it.
+			calls := 3
+			if tt.wantErr {
+				calls = 1
+			}
+			for i := 0; i < calls; i++ {
+				var body []byte
+				_, err := base.Clone().
+					WithMethod(http.MethodPost).
+					WithRequestBody([]byte(fmt.Sprintf("body %d", i))).
+					WithResponseBody(&body).
+					Do()
+				if (err != nil) != tt.wantErr {
+					t.Fatalf("Request.Do() error = %v, wantErr %v", err, tt.wantErr)
+				}
+				if !tt.wantErr && string(body) != fmt.Sprintf("body %d", i) {
+					t.Errorf("Request.Do() body = %q, want replayed request body", body)
+				}
+			}
+
+			if handler.requests != tt.wantRequests {
+				t.Errorf("server received %d requests, want %d", handler.requests, tt.wantRequests)
+			}
+		})
+	}
+}
+
+func TestDigestAuth_origins(t *testing.T) {
+	a := &mockDigestServer{t: t, algorithm: "MD5", qop: "auth"}
+	serverA := httptest.NewServer(a)
+	defer serverA.Close()
+	// The other server does not mark nonces it did not issue as stale.
+	b := &mockDigestServer{t: t, algorithm: "SHA-256", qop: "auth"}
+	serverB := httptest.NewServer(b)
+	defer serverB.Close()
+
+	auth := &DigestAuth{Username: "bob", Password: "secret"}
+
+	// Each origin is answered with its own challenge.
+	for _, url := range []string{serverA.URL, serverB.URL, serverA.URL, serverB.URL} {
+		o, err := NewRequest().WithMethod(http.MethodGet).WithAuth(auth).FromURLString(url + "/")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := o.Do(); err != nil {
+			t.Errorf("Request.Do() %s error = %v", url, err)
+		}
+	}
+
+	if a.requests != 3 || b.requests != 3 {
+		t.Errorf("servers received %d and %d requests, want 3 and 3", a.requests, b.requests)
+	}
+}
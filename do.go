@@ -2,8 +2,9 @@ package http
 
 import (
 	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 )
@@ -12,6 +13,7 @@ import (
 type DoOptions struct {
 	WithRequestEncoding  Encoding
 	WithResponseEncoding Encoding
+	WithHedging          *HedgePolicy
 }
 
 func joinOptions(options ...*DoOptions) *DoOptions {
@@ -24,6 +26,9 @@ func joinOptions(options ...*DoOptions) *DoOptions {
 		if opts.WithResponseEncoding == "" {
 			opts.WithResponseEncoding = o.WithResponseEncoding
 		}
+		if opts.WithHedging == nil {
+			opts.WithHedging = o.WithHedging
+		}
 	}
 
 	return &opts
@@ -31,6 +36,11 @@ func joinOptions(options ...*DoOptions) *DoOptions {
 
 // Do makes the HTTP request.
 func (o *Request) Do(options ...*DoOptions) (*http.Response, error) {
+	return o.DoContext(context.Background(), options...)
+}
+
+// DoContext makes the HTTP request with the context.
+func (o *Request) DoContext(ctx context.Context, options ...*DoOptions) (*http.Response, error) {
 	opts := joinOptions(options...)
 
 	o.ensure()
@@ -44,68 +54,92 @@ func (o *Request) Do(options ...*DoOptions) (*http.Response, error) {
 		return nil, fmt.Errorf("error building URL: %w", err)
 	}
 
-	var reqBody []byte
-	if o.RequestBody != nil {
-		switch v := o.RequestBody.(type) {
-		case []byte:
-			reqBody = v
-		default:
-			encoding := opts.WithRequestEncoding
-			if encoding == "" {
-				encoding = o.inferRequestEncoding()
-			}
-
-			switch encoding {
-			case EncodingJSON:
-				var err error
-				reqBody, err = json.Marshal(o.RequestBody)
-				if err != nil {
-					return nil, fmt.Errorf("error encoding request body: %w", err)
-				}
-			default:
-				return nil, fmt.Errorf("unable to encode request body")
-			}
-		}
+	reqBody, err := o.encodeRequestBody(opts)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding request body: %w", err)
 	}
 
-	req, err := http.NewRequest(o.Method, u.String(), bytes.NewBuffer(reqBody))
-	req.Header = o.Header
+	resp, err := o.sendHedged(ctx, opts.WithHedging, u.String(), reqBody)
 	if err != nil {
-		return nil, fmt.Errorf("error creating http request: %w", err)
+		return resp, err
 	}
+	if refresher, ok := o.Auth.(Refresher); ok && resp.StatusCode == http.StatusUnauthorized && resp.Request != nil && refresher.Refresh(resp) {
+		resp.Body.Close()
 
-	resp, err := o.Client.Do(req)
-	if err != nil {
-		return resp, fmt.Errorf("error making http request: %w", err)
+		resp, err = o.sendHedged(ctx, opts.WithHedging, u.String(), reqBody)
+		if err != nil {
+			return resp, err
+		}
 	}
 	if resp.StatusCode/100 > 2 {
 		err = &StatusCodeError{StatusCode: resp.StatusCode}
 	}
 
-	if o.ResponseBody != nil {
-		switch v := o.ResponseBody.(type) {
-		case *[]byte:
-			data, err := ioutil.ReadAll(resp.Body)
-			if err != nil {
-				return resp, fmt.Errorf("error reading response body: %w", err)
-			}
-			*v = data
-		default:
-			encoding := opts.WithResponseEncoding
-			if encoding == "" {
-				encoding = o.inferResponseEncoding(resp)
-			}
-
-			switch encoding {
-			case EncodingJSON:
-				if err := json.NewDecoder(resp.Body).Decode(o.ResponseBody); err != nil {
-					return resp, fmt.Errorf("error decoding response body: %w", err)
-				}
-			default:
-				return resp, fmt.Errorf("unable to decode response body")
-			}
-		}
+	if decodeErr := o.decodeResponseBody(opts, resp, resp.Body); decodeErr != nil {
+		return resp, decodeErr
 	}
 
 	return resp, err
 }
+
+func (o *Request) decodeResponseBody(opts *DoOptions, resp *http.Response, r io.Reader) error {
+	if o.ResponseBody == nil {
+		return nil
+	}
+
+	switch v := o.ResponseBody.(type) {
+	case *[]byte:
+		data, err := ioutil.ReadAll(r)
+		if err != nil {
+			return fmt.Errorf("error reading response body: %w", err)
+		}
+		*v = data
+	default:
+		encoding := opts.WithResponseEncoding
+		if encoding == "" {
+			encoding = o.inferResponseEncoding(resp)
+		}
+
+		if err := decode(encoding, r, o.ResponseBody); err != nil {
+			return fmt.Errorf("error decoding response body: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (o *Request) send(ctx context.Context, ref string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(o.withSensitiveHeaders(ctx), o.Method, ref, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("error creating http request: %w", err)
+	}
+	req.Header = o.Header.Clone()
+	if o.Auth != nil {
+		if err := o.Auth.Authenticate(req); err != nil {
+			return nil, fmt.Errorf("error authenticating http request: %w", err)
+		}
+	}
+
+	resp, err := o.Client.Do(req)
+	if err != nil {
+		return resp, fmt.Errorf("error making http request: %w", o.redactError(err))
+	}
+
+	return resp, nil
+}
+
+func (o *Request) encodeRequestBody(opts *DoOptions) ([]byte, error) {
+	switch v := o.RequestBody.(type) {
+	case nil:
+		return nil, nil
+	case []byte:
+		return v, nil
+	default:
+		encoding := opts.WithRequestEncoding
+		if encoding == "" {
+			encoding = o.inferRequestEncoding()
+		}
+
+		return encode(encoding, v)
+	}
+}
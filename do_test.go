@@ -1,6 +1,8 @@
 package http
 
 import (
+	"context"
+	"errors"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
@@ -100,6 +102,22 @@ func TestRequest_Do(t *testing.T) {
 				wantRequestBody: []byte("{\"foo\":\"bar\"}"),
 			},
 		},
+		{
+			name: "success post with request body form",
+			fields: fields{
+				Method: http.MethodPost,
+				Path:   "/api/v1/path",
+				Header: http.Header{
+					"Content-Type": []string{"application/x-www-form-urlencoded; charset=utf-8"},
+				},
+				RequestBody: url.Values{
+					"foo": []string{"bar", "baz"},
+				},
+			},
+			server: server{
+				wantRequestBody: []byte("foo=bar&foo=baz"),
+			},
+		},
 		{
 			name: "success post with response body",
 			fields: fields{
@@ -215,3 +233,48 @@ func TestRequest_Do(t *testing.T) {
 		})
 	}
 }
+
+func TestRequest_DoContext(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("foo"))
+	}))
+	defer server.Close()
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	type args struct {
+		ctx context.Context
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr error
+	}{
+		{
+			name: "success",
+			args: args{
+				ctx: context.Background(),
+			},
+		},
+		{
+			name: "error cancelled context",
+			args: args{
+				ctx: cancelled,
+			},
+			wantErr: context.Canceled,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o, err := NewRequest().WithMethod(http.MethodGet).FromURLString(server.URL + "/api/v1/path")
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = o.DoContext(tt.args.ctx)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Request.DoContext() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
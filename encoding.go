@@ -1,7 +1,14 @@
 package http
 
 import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
 	"net/http"
+	"net/url"
+	"strings"
 )
 
 type Encoding string
@@ -9,12 +16,20 @@ type Encoding string
 const (
 	EncodingUNKNOWN = ""
 	EncodingJSON    = "JSON"
+	EncodingForm    = "FORM"
 )
 
 func inferEncoding(contentType string) Encoding {
-	switch contentType {
-	case "application/json":
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return EncodingUNKNOWN
+	}
+
+	switch {
+	case mediaType == "application/json", strings.HasSuffix(mediaType, "+json"):
 		return EncodingJSON
+	case mediaType == "application/x-www-form-urlencoded":
+		return EncodingForm
 	default:
 		return EncodingUNKNOWN
 	}
@@ -31,3 +46,55 @@ func (o *Request) inferResponseEncoding(r *http.Response) Encoding {
 
 	return inferEncoding(contentType)
 }
+
+func encode(encoding Encoding, v interface{}) ([]byte, error) {
+	switch encoding {
+	case EncodingJSON:
+		return json.Marshal(v)
+	case EncodingForm:
+		values, err := formValues(v)
+		if err != nil {
+			return nil, err
+		}
+		return []byte(values.Encode()), nil
+	default:
+		return nil, fmt.Errorf("unsupported encoding %q", encoding)
+	}
+}
+
+func decode(encoding Encoding, r io.Reader, v interface{}) error {
+	switch encoding {
+	case EncodingJSON:
+		return json.NewDecoder(r).Decode(v)
+	case EncodingForm:
+		values, ok := v.(*url.Values)
+		if !ok {
+			return fmt.Errorf("unsupported form value type %T", v)
+		}
+		data, err := ioutil.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		*values, err = url.ParseQuery(string(data))
+		return err
+	default:
+		return fmt.Errorf("unsupported encoding %q", encoding)
+	}
+}
+
+func formValues(v interface{}) (url.Values, error) {
+	switch v := v.(type) {
+	case url.Values:
+		return v, nil
+	case map[string][]string:
+		return url.Values(v), nil
+	case map[string]string:
+		values := url.Values{}
+		for key, value := range v {
+			values.Set(key, value)
+		}
+		return values, nil
+	default:
+		return nil, fmt.Errorf("unsupported form value type %T", v)
+	}
+}
@@ -0,0 +1,209 @@
+package http
+
+import (
+	"bytes"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func Test_inferEncoding(t *testing.T) {
+	type args struct {
+		contentType string
+	}
+	tests := []struct {
+		name string
+		args args
+		want Encoding
+	}{
+		{
+			name: "success json",
+			args: args{
+				contentType: "application/json",
+			},
+			want: EncodingJSON,
+		},
+		{
+			name: "success json with parameters",
+			args: args{
+				contentType: "application/json; charset=utf-8",
+			},
+			want: EncodingJSON,
+		},
+		{
+			name: "success json suffix",
+			args: args{
+				contentType: "application/merge-patch+json",
+			},
+			want: EncodingJSON,
+		},
+		{
+			name: "success form",
+			args: args{
+				contentType: "application/x-www-form-urlencoded",
+			},
+			want: EncodingForm,
+		},
+		{
+			name: "success unknown",
+			args: args{
+				contentType: "text/plain",
+			},
+			want: EncodingUNKNOWN,
+		},
+		{
+			name: "success empty",
+			want: EncodingUNKNOWN,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := inferEncoding(tt.args.contentType); got != tt.want {
+				t.Errorf("inferEncoding() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_encode(t *testing.T) {
+	type args struct {
+		encoding Encoding
+		v        interface{}
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    []byte
+		wantErr bool
+	}{
+		{
+			name: "success json",
+			args: args{
+				encoding: EncodingJSON,
+				v: &mockBody{
+					Name: "bob",
+					Age:  3,
+				},
+			},
+			want: []byte(`{"name":"bob","age":3}`),
+		},
+		{
+			name: "success form values",
+			args: args{
+				encoding: EncodingForm,
+				v: url.Values{
+					"name": []string{"bob"},
+					"age":  []string{"3"},
+				},
+			},
+			want: []byte("age=3&name=bob"),
+		},
+		{
+			name: "success form map",
+			args: args{
+				encoding: EncodingForm,
+				v: map[string]string{
+					"name": "bob",
+				},
+			},
+			want: []byte("name=bob"),
+		},
+		{
+			name: "error form unsupported type",
+			args: args{
+				encoding: EncodingForm,
+				v:        &mockBody{},
+			},
+			wantErr: true,
+		},
+		{
+			name: "error unknown encoding",
+			args: args{
+				encoding: EncodingUNKNOWN,
+				v:        &mockBody{},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := encode(tt.args.encoding, tt.args.v)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("encode() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("encode() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_decode(t *testing.T) {
+	type args struct {
+		encoding Encoding
+		data     []byte
+		v        interface{}
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    interface{}
+		wantErr bool
+	}{
+		{
+			name: "success json",
+			args: args{
+				encoding: EncodingJSON,
+				data:     []byte(`{"name":"bob","age":3}`),
+				v:        &mockBody{},
+			},
+			want: &mockBody{
+				Name: "bob",
+				Age:  3,
+			},
+		},
+		{
+			name: "success form",
+			args: args{
+				encoding: EncodingForm,
+				data:     []byte("age=3&name=bob"),
+				v:        &url.Values{},
+			},
+			want: &url.Values{
+				"name": []string{"bob"},
+				"age":  []string{"3"},
+			},
+		},
+		{
+			name: "error form unsupported type",
+			args: args{
+				encoding: EncodingForm,
+				data:     []byte("name=bob"),
+				v:        &mockBody{},
+			},
+			wantErr: true,
+		},
+		{
+			name: "error unknown encoding",
+			args: args{
+				encoding: EncodingUNKNOWN,
+				data:     []byte("foo"),
+				v:        &mockBody{},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := decode(tt.args.encoding, bytes.NewReader(tt.args.data), tt.args.v)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("decode() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !reflect.DeepEqual(tt.args.v, tt.want) {
+				t.Errorf("decode() = %v, want %v", tt.args.v, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// RecordVersion is the version of the Record format written by Export.
+const RecordVersion = 1
+
+// Record is the serialized form of a Request, as written by Export and read by
+// Import.
+//
+// The client, authenticator, and response body of a Request are not recorded,
+// and the request body is recorded as the exact bytes that would be sent.
+// Secrets are redacted unless exported with ExportOptions.IncludeSecrets.
+type Record struct {
+	Version   int         `json:"version"`
+	Method    string      `json:"method"`
+	URL       string      `json:"url"`
+	Query     url.Values  `json:"query,omitempty"`
+	Header    http.Header `json:"header,omitempty"`
+	Body      []byte      `json:"body,omitempty"`
+	MediaType string      `json:"mediaType,omitempty"`
+}
+
+// ExportOptions are options to use when exporting a Request.
+type ExportOptions struct {
+	// IncludeSecrets records the values of sensitive headers and query
+	// parameters, which are otherwise replaced by Redacted.
+	IncludeSecrets bool
+
+	// Options are the options used to encode the request body.
+	Options []*DoOptions
+}
+
+// Export returns the Record of the Request, encoding its request body as Do
+// would with the same options.
+//
+// The URL of the Record includes the scheme, host, and path of the Request,
+// and the query is recorded separately. Sensitive headers and query
+// parameters, including those named by the authenticator, are redacted as by
+// Redacted unless options.IncludeSecrets is set.
+func (o *Request) Export(options *ExportOptions) (*Record, error) {
+	if options == nil {
+		options = &ExportOptions{}
+	}
+	opts := joinOptions(options.Options...)
+
+	u := &url.URL{
+		Scheme:  o.Scheme,
+		Host:    o.Host,
+		Path:    o.Path,
+		RawPath: o.RawPath,
+	}
+
+	body, err := o.encodeRequestBody(opts)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding request body: %w", err)
+	}
+
+	c := o
+	if !options.IncludeSecrets {
+		c = o.Redacted()
+	}
+
+	r := &Record{
+		Version: RecordVersion,
+		Method:  o.Method,
+		URL:     u.String(),
+		Body:    body,
+	}
+	if len(c.Query) > 0 {
+		r.Query = c.Clone().Query
+	}
+	if len(c.Header) > 0 {
+		r.Header = c.Header.Clone()
+		r.MediaType = c.Header.Get("Content-Type")
+	}
+
+	return r, nil
+}
+
+// Import creates a Request from the Record.
+//
+// The request body of the Request is the recorded []byte, so it is sent as
+// recorded.
+func Import(r *Record) (*Request, error) {
+	if r.Version != RecordVersion {
+		return nil, fmt.Errorf("unsupported record version %d", r.Version)
+	}
+
+	o, err := NewRequest().WithMethod(r.Method).FromURLString(r.URL)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range r.Query {
+		for _, value := range values {
+			o.AddQuery(key, value)
+		}
+	}
+	if r.Header != nil {
+		o.WithHeader(r.Header.Clone())
+	}
+	if r.MediaType != "" && o.Header.Get("Content-Type") == "" {
+		o.AddHeader("Content-Type", r.MediaType)
+	}
+	if r.Body != nil {
+		o.WithRequestBody(r.Body)
+	}
+
+	return o, nil
+}
+
+// RecordWriter writes Records as JSON lines.
+type RecordWriter struct {
+	encoder *json.Encoder
+}
+
+// NewRecordWriter creates a new RecordWriter that writes to w.
+func NewRecordWriter(w io.Writer) *RecordWriter {
+	return &RecordWriter{
+		encoder: json.NewEncoder(w),
+	}
+}
+
+// Write writes the Record as a single line.
+func (o *RecordWriter) Write(r *Record) error {
+	if err := o.encoder.Encode(r); err != nil {
+		return fmt.Errorf("error encoding record: %w", err)
+	}
+	return nil
+}
+
+// WriteRequest exports the Request and writes its Record as a single line.
+func (o *RecordWriter) WriteRequest(req *Request, options *ExportOptions) error {
+	r, err := req.Export(options)
+	if err != nil {
+		return fmt.Errorf("error exporting request: %w", err)
+	}
+	return o.Write(r)
+}
+
+// RecordReader reads Records from JSON lines.
+type RecordReader struct {
+	decoder *json.Decoder
+}
+
+// NewRecordReader creates a new RecordReader that reads from r.
+func NewRecordReader(r io.Reader) *RecordReader {
+	return &RecordReader{
+		decoder: json.NewDecoder(r),
+	}
+}
+
+// Read reads the next Record, returning io.EOF when there are no more.
+func (o *RecordReader) Read() (*Record, error) {
+	r := &Record{}
+	if err := o.decoder.Decode(r); err != nil {
+		if err == io.EOF {
+			return nil, err
+		}
+		return nil, fmt.Errorf("error decoding record: %w", err)
+	}
+	return r, nil
+}
+
+// ReadRequest reads the next Record and imports it as a Request, returning
+// io.EOF when there are no more.
+func (o *RecordReader) ReadRequest() (*Request, error) {
+	r, err := o.Read()
+	if err != nil {
+		return nil, err
+	}
+	return Import(r)
+}
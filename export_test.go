@@ -0,0 +1,284 @@
+package http
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestRequest_Export(t *testing.T) {
+	type fields struct {
+		Auth        Authenticator
+		Method      string
+		Scheme      string
+		Host        string
+		Path        string
+		Query       url.Values
+		Header      http.Header
+		RequestBody interface{}
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		options *ExportOptions
+		want    *Record
+		wantErr bool
+	}{
+		{
+			name: "success get",
+			fields: fields{
+				Method: http.MethodGet,
+				Scheme: "https",
+				Host:   "www.example.com",
+				Path:   "/api/v1/path",
+				Query: url.Values{
+					"foo": []string{"bar"},
+				},
+			},
+			want: &Record{
+				Version: RecordVersion,
+				Method:  http.MethodGet,
+				URL:     "https://www.example.com/api/v1/path",
+				Query: url.Values{
+					"foo": []string{"bar"},
+				},
+			},
+		},
+		{
+			name: "success post with encoded body",
+			fields: fields{
+				Method: http.MethodPost,
+				Scheme: "https",
+				Host:   "www.example.com",
+				Path:   "/api/v1/path",
+				Header: http.Header{
+					"Content-Type": []string{"application/json"},
+				},
+				RequestBody: &mockBody{Name: "foo", Age: 1},
+			},
+			want: &Record{
+				Version: RecordVersion,
+				Method:  http.MethodPost,
+				URL:     "https://www.example.com/api/v1/path",
+				Header: http.Header{
+					"Content-Type": []string{"application/json"},
+				},
+				Body:      []byte(`{"name":"foo","age":1}`),
+				MediaType: "application/json",
+			},
+		},
+		{
+			name: "success redacted",
+			fields: fields{
+				Auth:   &APIKey{In: APIKeyInQuery, Name: "key", Value: "secret"},
+				Method: http.MethodGet,
+				Scheme: "https",
+				Host:   "www.example.com",
+				Path:   "/api/v1/path",
+				Query: url.Values{
+					"foo": []string{"bar"},
+					"key": []string{"secret"},
+				},
+				Header: http.Header{
+					"Authorization": []string{"Bearer secret"},
+					"Cookie":        []string{"session=secret"},
+					"X-Trace":       []string{"abc"},
+				},
+			},
+			want: &Record{
+				Version: RecordVersion,
+				Method:  http.MethodGet,
+				URL:     "https://www.example.com/api/v1/path",
+				Query: url.Values{
+					"foo": []string{"bar"},
+					"key": []string{Redacted},
+				},
+				Header: http.Header{
+					"Authorization": []string{Redacted},
+					"Cookie":        []string{Redacted},
+					"X-Trace":       []string{"abc"},
+				},
+			},
+		},
+		{
+			name: "success include secrets",
+			fields: fields{
+				Method: http.MethodGet,
+				Scheme: "https",
+				Host:   "www.example.com",
+				Path:   "/api/v1/path",
+				Header: http.Header{
+					"Authorization": []string{"Bearer secret"},
+				},
+			},
+			options: &ExportOptions{IncludeSecrets: true},
+			want: &Record{
+				Version: RecordVersion,
+				Method:  http.MethodGet,
+				URL:     "https://www.example.com/api/v1/path",
+				Header: http.Header{
+					"Authorization": []string{"Bearer secret"},
+				},
+			},
+		},
+		{
+			name: "error unknown encoding",
+			fields: fields{
+				Method:      http.MethodPost,
+				Scheme:      "https",
+				Host:        "www.example.com",
+				Path:        "/api/v1/path",
+				RequestBody: &mockBody{Name: "foo", Age: 1},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := &Request{
+				Auth:        tt.fields.Auth,
+				Method:      tt.fields.Method,
+				Scheme:      tt.fields.Scheme,
+				Host:        tt.fields.Host,
+				Path:        tt.fields.Path,
+				Query:       tt.fields.Query,
+				Header:      tt.fields.Header,
+				RequestBody: tt.fields.RequestBody,
+			}
+			got, err := o.Export(tt.options)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Request.Export() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Request.Export() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestImport(t *testing.T) {
+	type args struct {
+		r *Record
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    *Request
+		wantErr bool
+	}{
+		{
+			name: "success",
+			args: args{
+				r: &Record{
+					Version: RecordVersion,
+					Method:  http.MethodPost,
+					URL:     "https://www.example.com/api/v1/path",
+					Query: url.Values{
+						"foo": []string{"bar"},
+					},
+					Body:      []byte(`{"name":"foo","age":1}`),
+					MediaType: "application/json",
+				},
+			},
+			want: &Request{
+				Method: http.MethodPost,
+				Scheme: "https",
+				Host:   "www.example.com",
+				Path:   "/api/v1/path",
+				Query: url.Values{
+					"foo": []string{"bar"},
+				},
+				Header: http.Header{
+					"Content-Type": []string{"application/json"},
+				},
+				RequestBody: []byte(`{"name":"foo","age":1}`),
+			},
+		},
+		{
+			name: "error unsupported version",
+			args: args{
+				r: &Record{
+					Version: RecordVersion + 1,
+					Method:  http.MethodGet,
+					URL:     "https://www.example.com/api/v1/path",
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "error invalid URL",
+			args: args{
+				r: &Record{
+					Version: RecordVersion,
+					Method:  http.MethodGet,
+					URL:     "https://www.example.com/%zz",
+				},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Import(tt.args.r)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Import() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Import() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecordReader_ReadRequest(t *testing.T) {
+	var received [][]byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		received = append(received, append([]byte(r.Method+" "+r.URL.RequestURI()+" "), body...))
+	}))
+	defer server.Close()
+
+	var b bytes.Buffer
+	w := NewRecordWriter(&b)
+	requests := []*Request{
+		NewRequest().WithMethod(http.MethodGet).AddQuery("foo", "bar"),
+		NewRequest().WithMethod(http.MethodPost).AddHeader("Content-Type", "application/json").WithRequestBody(&mockBody{Name: "foo", Age: 1}),
+	}
+	for _, req := range requests {
+		if _, err := req.FromURLString(server.URL + "/api/v1/path"); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.WriteRequest(req, nil); err != nil {
+			t.Fatalf("RecordWriter.WriteRequest() error = %v", err)
+		}
+	}
+
+	r := NewRecordReader(&b)
+	for {
+		req, err := r.ReadRequest()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("RecordReader.ReadRequest() error = %v", err)
+		}
+		if _, err := req.Do(); err != nil {
+			t.Fatalf("Request.Do() error = %v", err)
+		}
+	}
+
+	want := [][]byte{
+		[]byte(`GET /api/v1/path?foo=bar `),
+		[]byte(`POST /api/v1/path {"name":"foo","age":1}`),
+	}
+	if !reflect.DeepEqual(received, want) {
+		t.Errorf("RecordReader.ReadRequest() replayed %q, want %q", received, want)
+	}
+}
@@ -0,0 +1,118 @@
+package http
+
+import (
+	"context"
+	"net/http"
+)
+
+// Future is the pending result of a HTTP request made by Request.Go.
+type Future struct {
+	done   chan struct{}
+	cancel context.CancelFunc
+	resp   *http.Response
+	err    error
+}
+
+// Go makes the HTTP request in a goroutine with the context, returning a
+// Future for its result.
+//
+// The request must not be changed until the Future is done.
+func (o *Request) Go(ctx context.Context, options ...*DoOptions) *Future {
+	ctx, cancel := context.WithCancel(ctx)
+	f := &Future{
+		done:   make(chan struct{}),
+		cancel: cancel,
+	}
+
+	go func() {
+		defer close(f.done)
+
+		f.resp, f.err = o.DoContext(ctx, options...)
+		if f.resp != nil {
+			f.resp.Body = &cancelBody{ReadCloser: f.resp.Body, cancel: cancel}
+		} else {
+			cancel()
+		}
+	}()
+
+	return f
+}
+
+// Wait waits for the HTTP request and returns its result.
+//
+// The context of the request is cancelled when the response body is closed.
+func (o *Future) Wait() (*http.Response, error) {
+	<-o.done
+	return o.resp, o.err
+}
+
+// Done returns a channel that is closed when the HTTP request is done.
+func (o *Future) Done() <-chan struct{} {
+	return o.done
+}
+
+// Cancel cancels the context of the HTTP request.
+func (o *Future) Cancel() {
+	o.cancel()
+}
+
+// WaitAll waits for all of the futures. If one fails, those still pending are
+// cancelled and its error is returned.
+func WaitAll(futures ...*Future) error {
+	done := waitEach(futures)
+
+	var err error
+	for range futures {
+		i := <-done
+		if _, ferr := futures[i].Wait(); ferr != nil && err == nil {
+			err = ferr
+			for _, f := range futures {
+				select {
+				case <-f.Done():
+				default:
+					f.Cancel()
+				}
+			}
+		}
+	}
+
+	return err
+}
+
+// WaitAny waits for the first of the futures to be done and returns its index
+// and result. The others are cancelled and their response bodies closed.
+func WaitAny(futures ...*Future) (int, *http.Response, error) {
+	if len(futures) == 0 {
+		return -1, nil, nil
+	}
+
+	done := waitEach(futures)
+	first := <-done
+	for i, f := range futures {
+		if i == first {
+			continue
+		}
+		f.Cancel()
+		go func(f *Future) {
+			if resp, _ := f.Wait(); resp != nil {
+				resp.Body.Close()
+			}
+		}(f)
+	}
+
+	resp, err := futures[first].Wait()
+	return first, resp, err
+}
+
+// waitEach returns a channel that receives the index of each future when it
+// is done.
+func waitEach(futures []*Future) <-chan int {
+	done := make(chan int, len(futures))
+	for i, f := range futures {
+		go func(i int, f *Future) {
+			<-f.Done()
+			done <- i
+		}(i, f)
+	}
+	return done
+}
@@ -0,0 +1,130 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newFutureServer() (*httptest.Server, chan struct{}, chan struct{}) {
+	started := make(chan struct{}, 10)
+	cancelled := make(chan struct{}, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/slow":
+			started <- struct{}{}
+			select {
+			case <-time.After(5 * time.Second):
+			case <-r.Context().Done():
+				cancelled <- struct{}{}
+			}
+		case "/fail":
+			time.Sleep(10 * time.Millisecond)
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.Write([]byte("ok"))
+		}
+	}))
+	return server, started, cancelled
+}
+
+func TestRequest_Go(t *testing.T) {
+	server, started, cancelled := newFutureServer()
+	defer server.Close()
+
+	base, err := NewRequest().WithMethod(http.MethodGet).FromURLString(server.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f := base.Clone().Go(context.Background())
+	<-f.Done()
+	resp, err := f.Wait()
+	if err != nil {
+		t.Fatalf("Future.Wait() error = %v", err)
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil || string(body) != "ok" {
+		t.Errorf("Future.Wait() body = %q, %v, want %q", body, err, "ok")
+	}
+
+	f = base.Clone().WithPath("/slow").Go(context.Background())
+	<-started
+	f.Cancel()
+	if _, err := f.Wait(); !errors.Is(err, context.Canceled) {
+		t.Errorf("Future.Wait() error = %v, want %v", err, context.Canceled)
+	}
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Errorf("Future.Cancel() did not abort the request")
+	}
+}
+
+func TestWaitAll(t *testing.T) {
+	server, started, cancelled := newFutureServer()
+	defer server.Close()
+
+	base, err := NewRequest().WithMethod(http.MethodGet).FromURLString(server.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ok := base.Clone().Go(context.Background())
+	if err := WaitAll(ok, base.Clone().Go(context.Background())); err != nil {
+		t.Errorf("WaitAll() error = %v", err)
+	}
+
+	slow := base.Clone().WithPath("/slow").Go(context.Background())
+	<-started
+	err = WaitAll(slow, base.Clone().WithPath("/fail").Go(context.Background()))
+	var statusCodeErr *StatusCodeError
+	if !errors.As(err, &statusCodeErr) {
+		t.Errorf("WaitAll() error = %v, want %T", err, statusCodeErr)
+	}
+	if _, err := slow.Wait(); !errors.Is(err, context.Canceled) {
+		t.Errorf("Future.Wait() error = %v, want %v", err, context.Canceled)
+	}
+	<-cancelled
+
+	// Responses of completed futures remain readable.
+	resp, _ := ok.Wait()
+	if body, err := ioutil.ReadAll(resp.Body); err != nil || string(body) != "ok" {
+		t.Errorf("Future.Wait() body = %q, %v, want %q", body, err, "ok")
+	}
+	resp.Body.Close()
+}
+
+func TestWaitAny(t *testing.T) {
+	server, started, cancelled := newFutureServer()
+	defer server.Close()
+
+	base, err := NewRequest().WithMethod(http.MethodGet).FromURLString(server.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	slow := base.Clone().WithPath("/slow").Go(context.Background())
+	<-started
+	i, resp, err := WaitAny(slow, base.Clone().Go(context.Background()))
+	if err != nil {
+		t.Fatalf("WaitAny() error = %v", err)
+	}
+	resp.Body.Close()
+	if i != 1 {
+		t.Errorf("WaitAny() = %v, want 1", i)
+	}
+	if _, err := slow.Wait(); !errors.Is(err, context.Canceled) {
+		t.Errorf("Future.Wait() error = %v, want %v", err, context.Canceled)
+	}
+	<-cancelled
+
+	if i, _, _ := WaitAny(); i != -1 {
+		t.Errorf("WaitAny() = %v, want -1", i)
+	}
+}
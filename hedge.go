@@ -0,0 +1,155 @@
+package http
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+)
+
+// HedgePolicy sends additional copies of a request if earlier copies have not
+// succeeded within a delay, using whichever succeeds first and cancelling the
+// others.
+//
+// Only idempotent methods are hedged. Each copy sends the full request body.
+type HedgePolicy struct {
+	// Delay is the time to wait before sending each additional copy, such as
+	// the 95th percentile latency of the service.
+	Delay time.Duration
+
+	// MaxHedges is the number of copies sent in addition to the first.
+	MaxHedges int
+
+	// StatusCodes are the status codes that count as success, all below 500
+	// if empty. A copy that fails sends the next immediately if no others are
+	// in flight.
+	StatusCodes []int
+}
+
+func (o *HedgePolicy) success(resp *http.Response) bool {
+	if len(o.StatusCodes) == 0 {
+		return resp.StatusCode < 500
+	}
+	return containsStatusCode(o.StatusCodes, resp.StatusCode)
+}
+
+type hedgeResult struct {
+	i    int
+	resp *http.Response
+	err  error
+}
+
+// sendHedged sends the request according to the policy, returning the first
+// successful response or the last failed one.
+func (o *Request) sendHedged(ctx context.Context, policy *HedgePolicy, ref string, body []byte) (*http.Response, error) {
+	if policy == nil || policy.MaxHedges < 1 || !idempotent(o.Method) {
+		return o.send(ctx, ref, body)
+	}
+
+	results := make(chan hedgeResult, policy.MaxHedges+1)
+	var cancels []context.CancelFunc
+	start := func() {
+		attemptCtx, cancel := context.WithCancel(ctx)
+		i := len(cancels)
+		cancels = append(cancels, cancel)
+		go func() {
+			resp, err := o.send(attemptCtx, ref, body)
+			results <- hedgeResult{i: i, resp: resp, err: err}
+		}()
+	}
+
+	timer := time.NewTimer(policy.Delay)
+	defer timer.Stop()
+
+	start()
+	pending := 1
+	var last *hedgeResult
+	for pending > 0 {
+		select {
+		case <-timer.C:
+			if len(cancels) <= policy.MaxHedges {
+				start()
+				pending++
+				timer.Reset(policy.Delay)
+			}
+		case r := <-results:
+			pending--
+			if last != nil {
+				discardHedge(last, cancels)
+			}
+			last = &r
+
+			if r.err == nil && policy.success(r.resp) {
+				for i, cancel := range cancels {
+					if i != r.i {
+						cancel()
+					}
+				}
+				go func(pending int) {
+					for ; pending > 0; pending-- {
+						r := <-results
+						discardHedge(&r, cancels)
+					}
+				}(pending)
+				return hedgeResponse(&r, cancels), nil
+			}
+
+			if pending == 0 && len(cancels) <= policy.MaxHedges {
+				start()
+				pending++
+				resetTimer(timer, policy.Delay)
+			}
+		}
+	}
+
+	return hedgeResponse(last, cancels), last.err
+}
+
+// hedgeResponse returns the response of the result, cancelling its context
+// once the body is closed.
+func hedgeResponse(r *hedgeResult, cancels []context.CancelFunc) *http.Response {
+	if r.resp == nil {
+		cancels[r.i]()
+		return nil
+	}
+	r.resp.Body = &cancelBody{ReadCloser: r.resp.Body, cancel: cancels[r.i]}
+	return r.resp
+}
+
+func discardHedge(r *hedgeResult, cancels []context.CancelFunc) {
+	if r.resp != nil {
+		r.resp.Body.Close()
+	}
+	cancels[r.i]()
+}
+
+func resetTimer(timer *time.Timer, d time.Duration) {
+	if !timer.Stop() {
+		select {
+		case <-timer.C:
+		default:
+		}
+	}
+	timer.Reset(d)
+}
+
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (o *cancelBody) Close() error {
+	err := o.ReadCloser.Close()
+	o.cancel()
+	return err
+}
+
+// idempotent reports whether the method is idempotent per RFC 9110.
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,160 @@
+package http
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRequest_Do_hedging(t *testing.T) {
+	type attempt struct {
+		delay      time.Duration
+		statusCode int
+	}
+	tests := []struct {
+		name      string
+		method    string
+		policy    *HedgePolicy
+		attempts  []attempt
+		wantCalls int
+		want      int
+		wantErr   bool
+	}{
+		{
+			name:   "success hedge wins",
+			method: http.MethodPut,
+			policy: &HedgePolicy{Delay: 10 * time.Millisecond, MaxHedges: 1},
+			attempts: []attempt{
+				{delay: time.Second, statusCode: http.StatusOK},
+				{statusCode: http.StatusCreated},
+			},
+			wantCalls: 2,
+			want:      http.StatusCreated,
+		},
+		{
+			name:   "success first wins",
+			method: http.MethodGet,
+			policy: &HedgePolicy{Delay: time.Second, MaxHedges: 2},
+			attempts: []attempt{
+				{statusCode: http.StatusOK},
+			},
+			wantCalls: 1,
+			want:      http.StatusOK,
+		},
+		{
+			name:   "success failure sends next",
+			method: http.MethodGet,
+			policy: &HedgePolicy{Delay: time.Second, MaxHedges: 1},
+			attempts: []attempt{
+				{statusCode: http.StatusServiceUnavailable},
+				{statusCode: http.StatusOK},
+			},
+			wantCalls: 2,
+			want:      http.StatusOK,
+		},
+		{
+			name:   "success status codes",
+			method: http.MethodGet,
+			policy: &HedgePolicy{Delay: time.Second, MaxHedges: 1, StatusCodes: []int{http.StatusOK}},
+			attempts: []attempt{
+				{statusCode: http.StatusNotFound},
+				{statusCode: http.StatusOK},
+			},
+			wantCalls: 2,
+			want:      http.StatusOK,
+		},
+		{
+			name:   "success non-idempotent not hedged",
+			method: http.MethodPost,
+			policy: &HedgePolicy{Delay: 10 * time.Millisecond, MaxHedges: 1},
+			attempts: []attempt{
+				{delay: 50 * time.Millisecond, statusCode: http.StatusOK},
+			},
+			wantCalls: 1,
+			want:      http.StatusOK,
+		},
+		{
+			name:   "error all failed",
+			method: http.MethodGet,
+			policy: &HedgePolicy{Delay: time.Second, MaxHedges: 1},
+			attempts: []attempt{
+				{statusCode: http.StatusServiceUnavailable},
+				{statusCode: http.StatusBadGateway},
+			},
+			wantCalls: 2,
+			want:      http.StatusBadGateway,
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int32
+			var mu sync.Mutex
+			var bodies []string
+			cancelled := make(chan struct{}, len(tt.attempts))
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				attempt := tt.attempts[atomic.AddInt32(&calls, 1)-1]
+				body, _ := ioutil.ReadAll(r.Body)
+				mu.Lock()
+				bodies = append(bodies, string(body))
+				mu.Unlock()
+
+				select {
+				case <-time.After(attempt.delay):
+				case <-r.Context().Done():
+					cancelled <- struct{}{}
+					return
+				}
+				w.WriteHeader(attempt.statusCode)
+			}))
+			defer server.Close()
+
+			o, err := NewRequest().WithMethod(tt.method).WithRequestBody([]byte("body")).FromURLString(server.URL + "/")
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := o.Do(&DoOptions{WithHedging: tt.policy})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Request.Do() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			got.Body.Close()
+			if got.StatusCode != tt.want {
+				t.Errorf("Request.Do() status = %v, want %v", got.StatusCode, tt.want)
+			}
+			if tt.attempts[0].delay == time.Second {
+				select {
+				case <-cancelled:
+				case <-time.After(500 * time.Millisecond):
+					t.Errorf("Request.Do() did not cancel the losing request")
+				}
+			}
+			if got := int(atomic.LoadInt32(&calls)); got != tt.wantCalls {
+				t.Errorf("server called %d times, want %d", got, tt.wantCalls)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			for _, body := range bodies {
+				if body != "body" {
+					t.Errorf("server got body %q, want %q", body, "body")
+				}
+			}
+		})
+	}
+}
+
+func TestRequest_Do_hedgingError(t *testing.T) {
+	o, err := NewRequest().WithMethod(http.MethodGet).FromURLString("http://127.0.0.1:1/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = o.Do(&DoOptions{WithHedging: &HedgePolicy{Delay: time.Second, MaxHedges: 2}})
+	var statusCodeErr *StatusCodeError
+	if err == nil || errors.As(err, &statusCodeErr) {
+		t.Errorf("Request.Do() error = %v, want transport error", err)
+	}
+}
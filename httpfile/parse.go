@@ -0,0 +1,236 @@
+// Package httpfile parses and runs .http request files, as used by the VS Code
+// REST Client and JetBrains HTTP Client.
+//
+// A file is a sequence of requests separated by lines starting with "###".
+// Each request has an optional "# @name" comment, a request line of a method
+// and URL, headers, a blank line, and a body. The body may be read from a file
+// with "< path", or with "<@ path" to substitute variables in it. File
+// variables are declared with "@name = value".
+//
+// Variables are referenced with "{{name}}" and resolve to file variables, then
+// environment variables, then the values of earlier responses:
+//
+//	{{login.response.status}}
+//	{{login.response.headers.Location}}
+//	{{login.response.body.token}}
+//	{{login.response.body.$.items[0].id}}
+//
+// The system variables {{$timestamp}} and {{$uuid}} are also available.
+package httpfile
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// File is a parsed .http file.
+type File struct {
+	// Dir is the directory that body files are relative to.
+	Dir string
+
+	Variables map[string]string
+	Requests  []*Request
+}
+
+// Request is a single request of a File, before variables are substituted.
+type Request struct {
+	Name   string
+	Title  string
+	Line   int
+	Method string
+	URL    string
+	Header []Header
+	Body   string
+
+	// BodyFile is the path of a file containing the body, whose variables are
+	// substituted if BodyFileVariables is set.
+	BodyFile          string
+	BodyFileVariables bool
+}
+
+// Header is a single header of a Request.
+type Header struct {
+	Name  string
+	Value string
+}
+
+func (o *Request) label() string {
+	if o.Name != "" {
+		return o.Name
+	}
+	return o.Title
+}
+
+// ParseFile parses the .http file at path.
+func ParseFile(path string) (*File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	file, err := Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing %s: %w", path, err)
+	}
+	file.Dir = filepath.Dir(path)
+
+	return file, nil
+}
+
+// Parse parses a .http file.
+func Parse(r io.Reader) (*File, error) {
+	file := &File{
+		Variables: map[string]string{},
+	}
+
+	var block []string
+	blockStart := 1
+	title := ""
+
+	flush := func() error {
+		req, err := parseBlock(file, block, blockStart, title)
+		if err != nil {
+			return err
+		}
+		if req != nil {
+			file.Requests = append(file.Requests, req)
+		}
+		return nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if strings.HasPrefix(text, "###") {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			block = nil
+			blockStart = line + 1
+			title = strings.TrimSpace(strings.TrimPrefix(text, "###"))
+			continue
+		}
+		block = append(block, text)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+
+	return file, nil
+}
+
+func parseBlock(file *File, lines []string, start int, title string) (*Request, error) {
+	req := &Request{
+		Title: title,
+	}
+
+	i := 0
+	for ; i < len(lines); i++ {
+		text := strings.TrimSpace(lines[i])
+
+		if comment, ok := trimComment(text); ok {
+			if strings.HasPrefix(comment, "@name") {
+				req.Name = strings.TrimSpace(strings.TrimPrefix(comment, "@name"))
+			}
+			continue
+		}
+		if text == "" {
+			continue
+		}
+		if strings.HasPrefix(text, "@") {
+			parts := strings.SplitN(text[1:], "=", 2)
+			if len(parts) != 2 {
+				return nil, fmt.Errorf("line %d: invalid variable %q", start+i, text)
+			}
+			file.Variables[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+			continue
+		}
+
+		break
+	}
+	if i == len(lines) {
+		return nil, nil
+	}
+
+	req.Line = start + i
+	fields := strings.Fields(lines[i])
+	switch {
+	case len(fields) >= 2 && isMethod(fields[0]):
+		req.Method = fields[0]
+		req.URL = fields[1]
+	case len(fields) >= 1:
+		req.Method = "GET"
+		req.URL = fields[0]
+	}
+	i++
+
+	for ; i < len(lines); i++ {
+		text := strings.TrimSpace(lines[i])
+		if !strings.HasPrefix(text, "?") && !strings.HasPrefix(text, "&") {
+			break
+		}
+		req.URL += text
+	}
+
+	for ; i < len(lines); i++ {
+		text := strings.TrimSpace(lines[i])
+		if text == "" {
+			i++
+			break
+		}
+		if _, ok := trimComment(text); ok {
+			continue
+		}
+
+		parts := strings.SplitN(text, ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("line %d: invalid header %q", start+i, text)
+		}
+		req.Header = append(req.Header, Header{
+			Name:  strings.TrimSpace(parts[0]),
+			Value: strings.TrimSpace(parts[1]),
+		})
+	}
+
+	body := strings.TrimSpace(strings.Join(lines[i:], "\n"))
+	switch {
+	case strings.HasPrefix(body, "<@"):
+		req.BodyFile = strings.TrimSpace(strings.TrimPrefix(body, "<@"))
+		req.BodyFileVariables = true
+	case strings.HasPrefix(body, "< "):
+		req.BodyFile = strings.TrimSpace(strings.TrimPrefix(body, "<"))
+	default:
+		req.Body = body
+	}
+
+	return req, nil
+}
+
+func trimComment(text string) (string, bool) {
+	switch {
+	case strings.HasPrefix(text, "#"):
+		return strings.TrimSpace(strings.TrimPrefix(text, "#")), true
+	case strings.HasPrefix(text, "//"):
+		return strings.TrimSpace(strings.TrimPrefix(text, "//")), true
+	default:
+		return "", false
+	}
+}
+
+func isMethod(s string) bool {
+	for _, r := range s {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return s != ""
+}
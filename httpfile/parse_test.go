@@ -0,0 +1,118 @@
+package httpfile
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	type args struct {
+		s string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    *File
+		wantErr bool
+	}{
+		{
+			name: "success single request",
+			args: args{
+				s: "GET https://example.com/pets\nAccept: application/json\n",
+			},
+			want: &File{
+				Variables: map[string]string{},
+				Requests: []*Request{
+					{
+						Line:   1,
+						Method: "GET",
+						URL:    "https://example.com/pets",
+						Header: []Header{{Name: "Accept", Value: "application/json"}},
+					},
+				},
+			},
+		},
+		{
+			name: "success variables, names, and bodies",
+			args: args{
+				s: strings.Join([]string{
+					"@host = https://example.com",
+					"",
+					"### Log in",
+					"# @name login",
+					"POST {{host}}/login",
+					"Content-Type: application/json",
+					"",
+					"{\"user\": \"bob\"}",
+					"",
+					"###",
+					"// a comment",
+					"{{host}}/pets",
+					"    ?limit=10",
+					"    &offset=20",
+					"",
+					"### Upload",
+					"PUT {{host}}/upload",
+					"",
+					"<@ ./body.json",
+				}, "\n"),
+			},
+			want: &File{
+				Variables: map[string]string{
+					"host": "https://example.com",
+				},
+				Requests: []*Request{
+					{
+						Name:   "login",
+						Title:  "Log in",
+						Line:   5,
+						Method: "POST",
+						URL:    "{{host}}/login",
+						Header: []Header{{Name: "Content-Type", Value: "application/json"}},
+						Body:   "{\"user\": \"bob\"}",
+					},
+					{
+						Line:   12,
+						Method: "GET",
+						URL:    "{{host}}/pets?limit=10&offset=20",
+					},
+					{
+						Title:             "Upload",
+						Line:              17,
+						Method:            "PUT",
+						URL:               "{{host}}/upload",
+						BodyFile:          "./body.json",
+						BodyFileVariables: true,
+					},
+				},
+			},
+		},
+		{
+			name: "error invalid header",
+			args: args{
+				s: "GET https://example.com\nnot a header\n",
+			},
+			wantErr: true,
+		},
+		{
+			name: "error invalid variable",
+			args: args{
+				s: "@host\nGET https://example.com\n",
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(strings.NewReader(tt.args.s))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
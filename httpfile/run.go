@@ -0,0 +1,352 @@
+package httpfile
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	httpclient "github.com/kevinsnydercodes/go-http-client"
+)
+
+const maxVariableDepth = 10
+
+var variableRegexp = regexp.MustCompile(`\{\{\s*([^{}]+?)\s*\}\}`)
+
+// LoadEnvironment loads the named environment from a JSON environment file of
+// the form {"$shared": {...}, "dev": {...}}, with the named environment taking
+// precedence over "$shared".
+func LoadEnvironment(path, name string) (map[string]string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var environments map[string]map[string]interface{}
+	if err := json.Unmarshal(data, &environments); err != nil {
+		return nil, fmt.Errorf("error decoding environment file: %w", err)
+	}
+
+	named, ok := environments[name]
+	if !ok && name != "" {
+		return nil, fmt.Errorf("environment %q not found", name)
+	}
+
+	env := map[string]string{}
+	for _, values := range []map[string]interface{}{environments["$shared"], named} {
+		for key, value := range values {
+			env[key] = formatValue(value)
+		}
+	}
+
+	return env, nil
+}
+
+// Runner runs the requests of a File in order.
+type Runner struct {
+	Client      *http.Client
+	Environment map[string]string
+}
+
+// Result is the outcome of running a single Request.
+type Result struct {
+	Request    *Request
+	Method     string
+	URL        string
+	StatusCode int
+	Duration   time.Duration
+	Err        error
+}
+
+// Passed returns whether the request was made and received a 2xx status code.
+func (o *Result) Passed() bool {
+	return o.Err == nil
+}
+
+type response struct {
+	statusCode int
+	header     http.Header
+	body       []byte
+}
+
+type run struct {
+	runner    *Runner
+	file      *File
+	responses map[string]*response
+}
+
+// Run runs the requests of the File in order, continuing after failures, and
+// returns their results.
+func (o *Runner) Run(ctx context.Context, file *File) []*Result {
+	r := &run{
+		runner:    o,
+		file:      file,
+		responses: map[string]*response{},
+	}
+
+	var results []*Result
+	for _, req := range file.Requests {
+		if ctx.Err() != nil {
+			results = append(results, &Result{Request: req, Method: req.Method, URL: req.URL, Err: ctx.Err()})
+			continue
+		}
+		results = append(results, r.do(ctx, req))
+	}
+
+	return results
+}
+
+func (r *run) do(ctx context.Context, req *Request) *Result {
+	result := &Result{
+		Request: req,
+		Method:  req.Method,
+		URL:     req.URL,
+	}
+
+	o, err := r.build(req)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	if u, err := o.URL(); err == nil {
+		result.URL = u.String()
+	}
+
+	var body []byte
+	o.WithResponseBody(&body)
+
+	start := time.Now()
+	resp, err := o.DoContext(ctx)
+	result.Duration = time.Since(start)
+	result.Err = err
+
+	if resp != nil {
+		resp.Body.Close()
+		result.StatusCode = resp.StatusCode
+		if req.Name != "" {
+			r.responses[req.Name] = &response{
+				statusCode: resp.StatusCode,
+				header:     resp.Header,
+				body:       body,
+			}
+		}
+	}
+
+	return result
+}
+
+func (r *run) build(req *Request) (*httpclient.Request, error) {
+	ref, err := r.substitute(req.URL, 0)
+	if err != nil {
+		return nil, fmt.Errorf("error resolving URL: %w", err)
+	}
+
+	o, err := httpclient.NewRequest().WithClient(r.runner.Client).WithMethod(req.Method).FromURLString(ref)
+	if err != nil {
+		return nil, err
+	}
+	if o.Path == "" {
+		o.WithPath("/")
+	}
+	o.WithDefaultHeader()
+
+	for _, h := range req.Header {
+		value, err := r.substitute(h.Value, 0)
+		if err != nil {
+			return nil, fmt.Errorf("error resolving header %q: %w", h.Name, err)
+		}
+		o.AddHeader(h.Name, value)
+	}
+
+	body := req.Body
+	if req.BodyFile != "" {
+		path := req.BodyFile
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(r.file.Dir, path)
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("error reading body file: %w", err)
+		}
+		if !req.BodyFileVariables {
+			return o.WithRequestBody(data), nil
+		}
+		body = string(data)
+	}
+	if body != "" {
+		body, err = r.substitute(body, 0)
+		if err != nil {
+			return nil, fmt.Errorf("error resolving body: %w", err)
+		}
+		o.WithRequestBody([]byte(body))
+	}
+
+	return o, nil
+}
+
+func (r *run) substitute(s string, depth int) (string, error) {
+	if depth > maxVariableDepth {
+		return "", fmt.Errorf("variables nested too deeply")
+	}
+
+	var err error
+	result := variableRegexp.ReplaceAllStringFunc(s, func(match string) string {
+		if err != nil {
+			return match
+		}
+		var value string
+		value, err = r.resolve(variableRegexp.FindStringSubmatch(match)[1], depth)
+		return value
+	})
+
+	return result, err
+}
+
+func (r *run) resolve(name string, depth int) (string, error) {
+	switch name {
+	case "$timestamp":
+		return strconv.FormatInt(time.Now().Unix(), 10), nil
+	case "$uuid":
+		return newUUID()
+	}
+
+	if value, ok := r.file.Variables[name]; ok {
+		return r.substitute(value, depth+1)
+	}
+	if value, ok := r.runner.Environment[name]; ok {
+		return value, nil
+	}
+
+	parts := strings.SplitN(name, ".", 4)
+	if len(parts) >= 3 && parts[1] == "response" {
+		resp, ok := r.responses[parts[0]]
+		if !ok {
+			return "", fmt.Errorf("no response for request %q", parts[0])
+		}
+		path := ""
+		if len(parts) == 4 {
+			path = parts[3]
+		}
+		return resp.lookup(parts[2], path)
+	}
+
+	return "", fmt.Errorf("undefined variable %q", name)
+}
+
+func (o *response) lookup(part, path string) (string, error) {
+	switch part {
+	case "status":
+		return strconv.Itoa(o.statusCode), nil
+	case "headers":
+		values, ok := o.header[http.CanonicalHeaderKey(path)]
+		if !ok {
+			return "", fmt.Errorf("response header %q not found", path)
+		}
+		return strings.Join(values, ", "), nil
+	case "body":
+		path = strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+		if path == "" || path == "*" {
+			return string(o.body), nil
+		}
+
+		var v interface{}
+		if err := json.Unmarshal(o.body, &v); err != nil {
+			return "", fmt.Errorf("error decoding response body: %w", err)
+		}
+		for _, key := range splitPath(path) {
+			switch node := v.(type) {
+			case map[string]interface{}:
+				var ok bool
+				if v, ok = node[key]; !ok {
+					return "", fmt.Errorf("response body has no %q", path)
+				}
+			case []interface{}:
+				i, err := strconv.Atoi(key)
+				if err != nil || i < 0 || i >= len(node) {
+					return "", fmt.Errorf("response body has no %q", path)
+				}
+				v = node[i]
+			default:
+				return "", fmt.Errorf("response body has no %q", path)
+			}
+		}
+		return formatValue(v), nil
+	default:
+		return "", fmt.Errorf("unknown response part %q", part)
+	}
+}
+
+// splitPath splits a path such as "items[0].id" into its keys.
+func splitPath(path string) []string {
+	path = strings.Replace(path, "[", ".", -1)
+	path = strings.Replace(path, "]", "", -1)
+
+	var keys []string
+	for _, key := range strings.Split(path, ".") {
+		if key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func formatValue(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+func newUUID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
+
+// WriteSummary writes a line for each result followed by the pass and fail
+// counts and total duration.
+func WriteSummary(w io.Writer, results []*Result) {
+	passed := 0
+	var total time.Duration
+
+	for _, result := range results {
+		status := "FAIL"
+		if result.Passed() {
+			status = "PASS"
+			passed++
+		}
+		total += result.Duration
+
+		fmt.Fprintf(w, "%s ", status)
+		if label := result.Request.label(); label != "" {
+			fmt.Fprintf(w, "%s: ", label)
+		}
+		fmt.Fprintf(w, "%s %s", result.Method, result.URL)
+		if result.StatusCode != 0 {
+			fmt.Fprintf(w, " %d", result.StatusCode)
+		}
+		fmt.Fprintf(w, " (%s)", result.Duration.Round(time.Millisecond))
+		if result.Err != nil {
+			fmt.Fprintf(w, ": %v", result.Err)
+		}
+		fmt.Fprintln(w)
+	}
+
+	fmt.Fprintf(w, "%d passed, %d failed (%s)\n", passed, len(results)-passed, total.Round(time.Millisecond))
+}
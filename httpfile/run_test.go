@@ -0,0 +1,224 @@
+package httpfile
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadEnvironment(t *testing.T) {
+	dir, err := ioutil.TempDir("", "httpfile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "http-client.env.json")
+	data := `{"$shared": {"host": "https://example.com", "limit": 10}, "dev": {"host": "http://localhost:3000"}}`
+	if err := ioutil.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	type args struct {
+		name string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name: "success shared",
+			args: args{
+				name: "",
+			},
+			want: map[string]string{
+				"host":  "https://example.com",
+				"limit": "10",
+			},
+		},
+		{
+			name: "success named",
+			args: args{
+				name: "dev",
+			},
+			want: map[string]string{
+				"host":  "http://localhost:3000",
+				"limit": "10",
+			},
+		},
+		{
+			name: "error not found",
+			args: args{
+				name: "prod",
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := LoadEnvironment(path, tt.args.name)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("LoadEnvironment() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LoadEnvironment() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunner_Run(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login":
+			body, _ := ioutil.ReadAll(r.Body)
+			if string(body) != `{"user": "bob"}` {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.Header().Set("Location", "/users/1")
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"token": "abc",
+				"items": []interface{}{map[string]interface{}{"id": 7}},
+			})
+		case "/users/1":
+			if r.Header.Get("Authorization") != "Bearer abc" || r.URL.Query().Get("item") != "7" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			fmt.Fprint(w, `{}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	type fields struct {
+		Environment map[string]string
+	}
+	type args struct {
+		s string
+	}
+	tests := []struct {
+		name       string
+		fields     fields
+		args       args
+		wantPassed []bool
+		wantStatus []int
+	}{
+		{
+			name: "success chained responses",
+			fields: fields{
+				Environment: map[string]string{
+					"host": server.URL,
+				},
+			},
+			args: args{
+				s: strings.Join([]string{
+					"@user = bob",
+					"# @name login",
+					"POST {{host}}/login",
+					"",
+					`{"user": "{{user}}"}`,
+					"###",
+					"GET {{host}}{{login.response.headers.Location}}?item={{login.response.body.$.items[0].id}}",
+					"Authorization: Bearer {{login.response.body.token}}",
+				}, "\n"),
+			},
+			wantPassed: []bool{true, true},
+			wantStatus: []int{200, 200},
+		},
+		{
+			name: "error continues after failures",
+			fields: fields{
+				Environment: map[string]string{
+					"host": server.URL,
+				},
+			},
+			args: args{
+				s: strings.Join([]string{
+					"GET {{host}}/missing",
+					"###",
+					"GET {{undefined}}/login",
+					"###",
+					"GET {{host}}/users/1?item={{login.response.body.token}}",
+					"###",
+					"POST {{host}}/login",
+					"",
+					`{"user": "bob"}`,
+				}, "\n"),
+			},
+			wantPassed: []bool{false, false, false, true},
+			wantStatus: []int{404, 0, 0, 200},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := Parse(strings.NewReader(tt.args.s))
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+
+			o := &Runner{
+				Environment: tt.fields.Environment,
+			}
+			results := o.Run(context.Background(), file)
+
+			var gotPassed []bool
+			var gotStatus []int
+			for _, result := range results {
+				gotPassed = append(gotPassed, result.Passed())
+				gotStatus = append(gotStatus, result.StatusCode)
+			}
+			if !reflect.DeepEqual(gotPassed, tt.wantPassed) {
+				t.Errorf("Runner.Run() passed = %v, want %v", gotPassed, tt.wantPassed)
+			}
+			if !reflect.DeepEqual(gotStatus, tt.wantStatus) {
+				t.Errorf("Runner.Run() status = %v, want %v", gotStatus, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestWriteSummary(t *testing.T) {
+	results := []*Result{
+		{
+			Request:    &Request{Name: "login"},
+			Method:     "POST",
+			URL:        "https://example.com/login",
+			StatusCode: 200,
+		},
+		{
+			Request:    &Request{},
+			Method:     "GET",
+			URL:        "https://example.com/pets",
+			StatusCode: 404,
+			Err:        fmt.Errorf("bad status"),
+		},
+	}
+
+	var b bytes.Buffer
+	WriteSummary(&b, results)
+
+	want := strings.Join([]string{
+		"PASS login: POST https://example.com/login 200 (0s)",
+		"FAIL GET https://example.com/pets 404 (0s): bad status",
+		"1 passed, 1 failed (0s)",
+		"",
+	}, "\n")
+	if got := b.String(); got != want {
+		t.Errorf("WriteSummary() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,609 @@
+package http
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SignatureAlgorithm is an HTTP message signature algorithm of RFC 9421.
+type SignatureAlgorithm string
+
+const (
+	SignatureHMACSHA256      SignatureAlgorithm = "hmac-sha256"
+	SignatureEd25519         SignatureAlgorithm = "ed25519"
+	SignatureECDSAP256SHA256 SignatureAlgorithm = "ecdsa-p256-sha256"
+	SignatureRSAPSSSHA512    SignatureAlgorithm = "rsa-pss-sha512"
+)
+
+// DefaultSignatureLabel is the label of signatures if none is given.
+const DefaultSignatureLabel = "sig1"
+
+// MessageSigner authenticates with HTTP message signatures, as defined by RFC
+// 9421, setting the "Signature-Input" and "Signature" headers.
+//
+// Components are derived components such as "@method", "@target-uri",
+// "@authority", "@path", and "@query", or lowercase header names. If
+// ContentDigest is set, a "Content-Digest" header is computed over the body
+// before signing, so the encoded request body of Do is covered when
+// "content-digest" is a component.
+type MessageSigner struct {
+	Label      string
+	KeyID      string
+	Algorithm  SignatureAlgorithm
+	Components []string
+
+	// Key is a []byte for HMAC-SHA256, an ed25519.PrivateKey, an
+	// *ecdsa.PrivateKey, or an *rsa.PrivateKey.
+	Key interface{}
+
+	ContentDigest bool
+
+	// Expiry, if set, adds an expiry to signatures.
+	Expiry time.Duration
+
+	// Now returns the signing time, time.Now if nil.
+	Now func() time.Time
+}
+
+// Authenticate signs the request.
+func (o *MessageSigner) Authenticate(req *http.Request) error {
+	if o.ContentDigest {
+		body, err := readBody(req)
+		if err != nil {
+			return fmt.Errorf("error reading body: %w", err)
+		}
+		req.Header.Set("Content-Digest", ContentDigest(body))
+	}
+
+	now := time.Now()
+	if o.Now != nil {
+		now = o.Now()
+	}
+
+	params := o.params(now)
+	base, err := signatureBase(requestComponents(req), o.Components, params)
+	if err != nil {
+		return err
+	}
+
+	signature, err := o.sign([]byte(base))
+	if err != nil {
+		return fmt.Errorf("error signing: %w", err)
+	}
+
+	label := o.Label
+	if label == "" {
+		label = DefaultSignatureLabel
+	}
+	req.Header.Set("Signature-Input", label+"="+params)
+	req.Header.Set("Signature", label+"=:"+base64.StdEncoding.EncodeToString(signature)+":")
+	return nil
+}
+
+func (o *MessageSigner) params(now time.Time) string {
+	var b strings.Builder
+
+	b.WriteString("(")
+	for i, component := range o.Components {
+		if i > 0 {
+			b.WriteString(" ")
+		}
+		b.WriteString(strconv.Quote(component))
+	}
+	b.WriteString(")")
+
+	fmt.Fprintf(&b, ";created=%d", now.Unix())
+	if o.Expiry > 0 {
+		fmt.Fprintf(&b, ";expires=%d", now.Add(o.Expiry).Unix())
+	}
+	if o.KeyID != "" {
+		fmt.Fprintf(&b, ";keyid=%s", strconv.Quote(o.KeyID))
+	}
+
+	return b.String()
+}
+
+func (o *MessageSigner) sign(base []byte) ([]byte, error) {
+	switch o.Algorithm {
+	case SignatureHMACSHA256:
+		key, ok := o.Key.([]byte)
+		if !ok {
+			return nil, fmt.Errorf("key must be []byte for %s", o.Algorithm)
+		}
+		h := hmac.New(sha256.New, key)
+		h.Write(base)
+		return h.Sum(nil), nil
+	case SignatureEd25519:
+		key, ok := o.Key.(ed25519.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("key must be ed25519.PrivateKey for %s", o.Algorithm)
+		}
+		return ed25519.Sign(key, base), nil
+	case SignatureECDSAP256SHA256:
+		key, ok := o.Key.(*ecdsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("key must be *ecdsa.PrivateKey for %s", o.Algorithm)
+		}
+		return signECDSAP256(key, base)
+	case SignatureRSAPSSSHA512:
+		key, ok := o.Key.(*rsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("key must be *rsa.PrivateKey for %s", o.Algorithm)
+		}
+		sum := sha512.Sum512(base)
+		return rsa.SignPSS(rand.Reader, key, crypto.SHA512, sum[:], &rsa.PSSOptions{SaltLength: 64})
+	default:
+		return nil, fmt.Errorf("unsupported signature algorithm %q", o.Algorithm)
+	}
+}
+
+// MessageVerifier verifies HTTP message signatures, as defined by RFC 9421.
+//
+// Verifying a signature that covers "content-digest" does not verify the body;
+// use VerifyContentDigest with the body for that.
+type MessageVerifier struct {
+	// Label selects the signature to verify, the first signature if empty.
+	Label     string
+	KeyID     string
+	Algorithm SignatureAlgorithm
+
+	// Key is a []byte for HMAC-SHA256, an ed25519.PublicKey, an
+	// *ecdsa.PublicKey, or an *rsa.PublicKey.
+	Key interface{}
+
+	// Components are the components that the signature must cover.
+	Components []string
+
+	// MaxAge, if set, rejects signatures created longer ago.
+	MaxAge time.Duration
+
+	// Now returns the verification time, time.Now if nil.
+	Now func() time.Time
+}
+
+// VerifyRequest verifies the signature of a request.
+func (o *MessageVerifier) VerifyRequest(req *http.Request) error {
+	return o.verify(req.Header, requestComponents(req))
+}
+
+// VerifyResponse verifies the signature of a response.
+func (o *MessageVerifier) VerifyResponse(resp *http.Response) error {
+	return o.verify(resp.Header, responseComponents(resp))
+}
+
+func (o *MessageVerifier) verify(header http.Header, components componentFunc) error {
+	inputs, err := parseDictionary(strings.Join(header[http.CanonicalHeaderKey("Signature-Input")], ", "))
+	if err != nil {
+		return fmt.Errorf("error parsing Signature-Input header: %w", err)
+	}
+	signatures, err := parseDictionary(strings.Join(header[http.CanonicalHeaderKey("Signature")], ", "))
+	if err != nil {
+		return fmt.Errorf("error parsing Signature header: %w", err)
+	}
+
+	var input *dictionaryMember
+	for i := range inputs {
+		if o.Label == "" || inputs[i].key == o.Label {
+			input = &inputs[i]
+			break
+		}
+	}
+	if input == nil {
+		return fmt.Errorf("missing signature %q", o.Label)
+	}
+
+	var signature []byte
+	for _, member := range signatures {
+		if member.key == input.key {
+			if signature, err = parseByteSequence(member.value); err != nil {
+				return fmt.Errorf("error parsing signature: %w", err)
+			}
+		}
+	}
+	if signature == nil {
+		return fmt.Errorf("missing signature %q", input.key)
+	}
+
+	covered, params, err := parseSignatureParams(input.value)
+	if err != nil {
+		return fmt.Errorf("error parsing signature parameters: %w", err)
+	}
+	for _, required := range o.Components {
+		if !containsString(covered, required) {
+			return fmt.Errorf("signature does not cover %q", required)
+		}
+	}
+	if err := o.checkParams(params); err != nil {
+		return err
+	}
+
+	base, err := signatureBase(components, covered, input.value)
+	if err != nil {
+		return err
+	}
+	if err := o.check([]byte(base), signature); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (o *MessageVerifier) checkParams(params map[string]string) error {
+	now := time.Now()
+	if o.Now != nil {
+		now = o.Now()
+	}
+
+	if o.KeyID != "" {
+		if keyID, _ := strconv.Unquote(params["keyid"]); keyID != o.KeyID {
+			return fmt.Errorf("signature key ID does not match")
+		}
+	}
+	if alg, ok := params["alg"]; ok {
+		if alg, _ = strconv.Unquote(alg); alg != string(o.Algorithm) {
+			return fmt.Errorf("signature algorithm %q does not match", alg)
+		}
+	}
+	if expires, ok := params["expires"]; ok {
+		t, err := strconv.ParseInt(expires, 10, 64)
+		if err != nil || now.Unix() > t {
+			return fmt.Errorf("signature expired")
+		}
+	}
+	if o.MaxAge > 0 {
+		t, err := strconv.ParseInt(params["created"], 10, 64)
+		if err != nil {
+			return fmt.Errorf("signature has no creation time")
+		}
+		if now.Sub(time.Unix(t, 0)) > o.MaxAge {
+			return fmt.Errorf("signature too old")
+		}
+	}
+
+	return nil
+}
+
+func (o *MessageVerifier) check(base, signature []byte) error {
+	valid := false
+
+	switch o.Algorithm {
+	case SignatureHMACSHA256:
+		key, ok := o.Key.([]byte)
+		if !ok {
+			return fmt.Errorf("key must be []byte for %s", o.Algorithm)
+		}
+		h := hmac.New(sha256.New, key)
+		h.Write(base)
+		valid = hmac.Equal(h.Sum(nil), signature)
+	case SignatureEd25519:
+		key, ok := o.Key.(ed25519.PublicKey)
+		if !ok {
+			return fmt.Errorf("key must be ed25519.PublicKey for %s", o.Algorithm)
+		}
+		valid = ed25519.Verify(key, base, signature)
+	case SignatureECDSAP256SHA256:
+		key, ok := o.Key.(*ecdsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("key must be *ecdsa.PublicKey for %s", o.Algorithm)
+		}
+		var err error
+		if valid, err = verifyECDSAP256(key, base, signature); err != nil {
+			return err
+		}
+	case SignatureRSAPSSSHA512:
+		key, ok := o.Key.(*rsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("key must be *rsa.PublicKey for %s", o.Algorithm)
+		}
+		sum := sha512.Sum512(base)
+		valid = rsa.VerifyPSS(key, crypto.SHA512, sum[:], signature, &rsa.PSSOptions{SaltLength: 64}) == nil
+	default:
+		return fmt.Errorf("unsupported signature algorithm %q", o.Algorithm)
+	}
+
+	if !valid {
+		return fmt.Errorf("invalid signature")
+	}
+	return nil
+}
+
+// componentFunc returns the value of a component of a message.
+type componentFunc func(name string) (string, bool)
+
+func requestComponents(req *http.Request) componentFunc {
+	return func(name string) (string, bool) {
+		scheme := req.URL.Scheme
+		if scheme == "" {
+			scheme = "http"
+			if req.TLS != nil {
+				scheme = "https"
+			}
+		}
+
+		switch name {
+		case "@method":
+			return strings.ToUpper(req.Method), true
+		case "@scheme":
+			return scheme, true
+		case "@authority":
+			return authority(scheme, requestHost(req)), true
+		case "@target-uri":
+			return scheme + "://" + authority(scheme, requestHost(req)) + req.URL.RequestURI(), true
+		case "@request-target":
+			return req.URL.RequestURI(), true
+		case "@path":
+			if p := req.URL.EscapedPath(); p != "" {
+				return p, true
+			}
+			return "/", true
+		case "@query":
+			return "?" + req.URL.RawQuery, true
+		case "content-length":
+			if values := req.Header[http.CanonicalHeaderKey(name)]; len(values) > 0 || req.ContentLength < 0 {
+				return headerComponent(req.Header, name)
+			}
+			return strconv.FormatInt(req.ContentLength, 10), true
+		default:
+			return headerComponent(req.Header, name)
+		}
+	}
+}
+
+func responseComponents(resp *http.Response) componentFunc {
+	return func(name string) (string, bool) {
+		if name == "@status" {
+			return strconv.Itoa(resp.StatusCode), true
+		}
+		return headerComponent(resp.Header, name)
+	}
+}
+
+func headerComponent(header http.Header, name string) (string, bool) {
+	if strings.HasPrefix(name, "@") {
+		return "", false
+	}
+
+	values := header[http.CanonicalHeaderKey(name)]
+	if len(values) == 0 {
+		return "", false
+	}
+	trimmed := make([]string, len(values))
+	for i, value := range values {
+		trimmed[i] = strings.TrimSpace(value)
+	}
+	values = trimmed
+	return strings.Join(values, ", "), true
+}
+
+// authority returns the lowercase host, without the default port of the
+// scheme.
+func authority(scheme, host string) string {
+	host = strings.ToLower(host)
+	switch {
+	case scheme == "http" && strings.HasSuffix(host, ":80"):
+		return strings.TrimSuffix(host, ":80")
+	case scheme == "https" && strings.HasSuffix(host, ":443"):
+		return strings.TrimSuffix(host, ":443")
+	default:
+		return host
+	}
+}
+
+func signatureBase(components componentFunc, names []string, params string) (string, error) {
+	var b strings.Builder
+	for _, name := range names {
+		value, ok := components(name)
+		if !ok {
+			return "", fmt.Errorf("missing component %q", name)
+		}
+		fmt.Fprintf(&b, "%q: %s\n", name, value)
+	}
+	fmt.Fprintf(&b, "%q: %s", "@signature-params", params)
+
+	return b.String(), nil
+}
+
+// parseSignatureParams parses an inner list of component names followed by
+// parameters, such as `("@method" "date");created=1;keyid="a"`, keeping the
+// parameter values as they are serialized.
+func parseSignatureParams(s string) ([]string, map[string]string, error) {
+	if !strings.HasPrefix(s, "(") {
+		return nil, nil, fmt.Errorf("expected inner list")
+	}
+	s = s[1:]
+
+	var components []string
+	for {
+		s = strings.TrimLeft(s, " ")
+		if s == "" {
+			return nil, nil, fmt.Errorf("unterminated inner list")
+		}
+		if s[0] == ')' {
+			s = s[1:]
+			break
+		}
+
+		item, rest, err := cutString(s)
+		if err != nil || (rest != "" && rest[0] != ' ' && rest[0] != ')') {
+			return nil, nil, fmt.Errorf("unsupported component at %s", s)
+		}
+		name, err := strconv.Unquote(item)
+		if err != nil {
+			return nil, nil, fmt.Errorf("unsupported component %s", item)
+		}
+		components = append(components, name)
+		s = rest
+	}
+
+	params := map[string]string{}
+	for s = strings.TrimSpace(s); s != ""; s = strings.TrimLeft(s, " ") {
+		if s[0] != ';' {
+			return nil, nil, fmt.Errorf("expected parameter at %s", s)
+		}
+		s = strings.TrimLeft(s[1:], " ")
+
+		end := strings.IndexAny(s, "=;")
+		if end < 0 {
+			end = len(s)
+		}
+		key := strings.TrimSpace(s[:end])
+		if key == "" {
+			return nil, nil, fmt.Errorf("missing parameter name")
+		}
+		s = s[end:]
+
+		value := "?1"
+		if strings.HasPrefix(s, "=") {
+			s = s[1:]
+			if strings.HasPrefix(s, `"`) {
+				var err error
+				if value, s, err = cutString(s); err != nil {
+					return nil, nil, fmt.Errorf("error parsing parameter %q: %w", key, err)
+				}
+			} else {
+				end := strings.Index(s, ";")
+				if end < 0 {
+					end = len(s)
+				}
+				value, s = strings.TrimSpace(s[:end]), s[end:]
+			}
+		}
+		params[key] = value
+	}
+
+	return components, params, nil
+}
+
+// cutString cuts the structured field string at the start of s, returning it
+// as it is serialized and the rest of s.
+func cutString(s string) (string, string, error) {
+	if !strings.HasPrefix(s, `"`) {
+		return "", "", fmt.Errorf("expected string")
+	}
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return s[:i+1], s[i+1:], nil
+		}
+	}
+	return "", "", fmt.Errorf("unterminated string")
+}
+
+type dictionaryMember struct {
+	key   string
+	value string
+}
+
+// parseDictionary splits a structured field dictionary into its members,
+// keeping their values as they are serialized.
+func parseDictionary(s string) ([]dictionaryMember, error) {
+	var members []dictionaryMember
+
+	start, depth, quoted := 0, 0, false
+	for i := 0; i <= len(s); i++ {
+		if i < len(s) {
+			switch c := s[i]; {
+			case quoted && c == '\\':
+				i++
+				continue
+			case c == '"':
+				quoted = !quoted
+				continue
+			case quoted:
+				continue
+			case c == '(':
+				depth++
+				continue
+			case c == ')':
+				depth--
+				continue
+			case c != ',' || depth > 0:
+				continue
+			}
+		}
+
+		member := strings.TrimSpace(s[start:i])
+		start = i + 1
+		if member == "" {
+			continue
+		}
+		kv := strings.SplitN(member, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid member %q", member)
+		}
+		members = append(members, dictionaryMember{key: strings.TrimSpace(kv[0]), value: strings.TrimSpace(kv[1])})
+	}
+	if quoted || depth != 0 {
+		return nil, fmt.Errorf("unterminated value")
+	}
+
+	return members, nil
+}
+
+// parseByteSequence parses a structured field byte sequence such as ":aGk=:".
+func parseByteSequence(s string) ([]byte, error) {
+	if len(s) < 2 || s[0] != ':' || s[len(s)-1] != ':' {
+		return nil, fmt.Errorf("expected byte sequence")
+	}
+	return base64.StdEncoding.DecodeString(s[1 : len(s)-1])
+}
+
+// signECDSAP256 signs the SHA-256 of data, returning the signature as the
+// fixed-size concatenation of r and s.
+func signECDSAP256(key *ecdsa.PrivateKey, data []byte) ([]byte, error) {
+	if key.Curve != elliptic.P256() {
+		return nil, fmt.Errorf("key must use curve P-256")
+	}
+
+	sum := sha256.Sum256(data)
+	r, s, err := ecdsa.Sign(rand.Reader, key, sum[:])
+	if err != nil {
+		return nil, err
+	}
+
+	signature := make([]byte, 64)
+	rBytes, sBytes := r.Bytes(), s.Bytes()
+	copy(signature[32-len(rBytes):32], rBytes)
+	copy(signature[64-len(sBytes):], sBytes)
+	return signature, nil
+}
+
+// verifyECDSAP256 reports whether signature, the concatenation of r and s, is
+// a valid signature of the SHA-256 of data.
+func verifyECDSAP256(key *ecdsa.PublicKey, data, signature []byte) (bool, error) {
+	if key.Curve != elliptic.P256() {
+		return false, fmt.Errorf("key must use curve P-256")
+	}
+	if len(signature) != 64 {
+		return false, nil
+	}
+	sum := sha256.Sum256(data)
+	r := new(big.Int).SetBytes(signature[:32])
+	s := new(big.Int).SetBytes(signature[32:])
+	return ecdsa.Verify(key, sum[:], r, s), nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,430 @@
+package http
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// The test vectors are from RFC 9421, appendix B.
+
+const mockSignatureBody = `{"hello": "world"}`
+
+func mockSignatureRequest(t *testing.T) *http.Request {
+	req, err := http.NewRequest(http.MethodPost, "https://example.com/foo?param=Value&Pet=dog", strings.NewReader(mockSignatureBody))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Date", "Tue, 20 Apr 2021 02:07:55 GMT")
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Digest", "sha-512=:WZDPaVn/7XgHaAy8pmojAkGWoRx2UFChF41A2svX+TaPm+AbwAgBWnrIiYllu7BNNyealdVLvRwEmTHWXvJwew==:")
+	return req
+}
+
+func mockSignatureTime() time.Time {
+	return time.Unix(1618884473, 0)
+}
+
+func mockEd25519Key(t *testing.T) ed25519.PrivateKey {
+	der, err := base64.StdEncoding.DecodeString("MC4CAQAwBQYDK2VwBCIEIJ+DYvh6SEqVTm50DFtMDoQikTmiCqirVv9mWG9qfSnF")
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key.(ed25519.PrivateKey)
+}
+
+func mockHMACKey(t *testing.T) []byte {
+	key, err := base64.StdEncoding.DecodeString("uzvJfB4u3N0Jy4T7NZ75MDVcr8zSTInedJtkgcu46YW4XByzNJjxBdtjUkdJPBtbmHhIDi6pcl8jsasjlTMtDQ==")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestMessageSigner_Authenticate(t *testing.T) {
+	tests := []struct {
+		name          string
+		signer        *MessageSigner
+		wantInput     string
+		wantSignature string
+	}{
+		{
+			name: "success hmac-sha256",
+			signer: &MessageSigner{
+				Label:      "sig-b25",
+				KeyID:      "test-shared-secret",
+				Algorithm:  SignatureHMACSHA256,
+				Components: []string{"date", "@authority", "content-type"},
+				Key:        mockHMACKey(t),
+				Now:        mockSignatureTime,
+			},
+			wantInput:     `sig-b25=("date" "@authority" "content-type");created=1618884473;keyid="test-shared-secret"`,
+			wantSignature: `sig-b25=:pxcQw6G3AjtMBQjwo8XzkZf/bws5LelbaMk5rGIGtE8=:`,
+		},
+		{
+			name: "success ed25519",
+			signer: &MessageSigner{
+				Label:      "sig-b26",
+				KeyID:      "test-key-ed25519",
+				Algorithm:  SignatureEd25519,
+				Components: []string{"date", "@method", "@path", "@authority", "content-type", "content-length"},
+				Key:        mockEd25519Key(t),
+				Now:        mockSignatureTime,
+			},
+			wantInput:     `sig-b26=("date" "@method" "@path" "@authority" "content-type" "content-length");created=1618884473;keyid="test-key-ed25519"`,
+			wantSignature: `sig-b26=:wqcAqbmYJ2ji2glfAMaRy4gruYYnx2nEFN2HN6jrnDnQCK1u02Gb04v9EDgwUPiu4A0w6vuQv5lIp5WPpBKRCw==:`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := mockSignatureRequest(t)
+			if err := tt.signer.Authenticate(req); err != nil {
+				t.Fatalf("MessageSigner.Authenticate() error = %v", err)
+			}
+			if got := req.Header.Get("Signature-Input"); got != tt.wantInput {
+				t.Errorf("MessageSigner.Authenticate() Signature-Input = %v, want %v", got, tt.wantInput)
+			}
+			if got := req.Header.Get("Signature"); got != tt.wantSignature {
+				t.Errorf("MessageSigner.Authenticate() Signature = %v, want %v", got, tt.wantSignature)
+			}
+		})
+	}
+}
+
+func TestMessageSigner_Authenticate_ecdsaCurve(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o := &MessageSigner{
+		Algorithm:  SignatureECDSAP256SHA256,
+		Components: []string{"@method"},
+		Key:        key,
+	}
+
+	if err := o.Authenticate(mockSignatureRequest(t)); err == nil {
+		t.Errorf("MessageSigner.Authenticate() error = nil, want error for P-384 key")
+	}
+}
+
+func TestMessageVerifier_VerifyRequest(t *testing.T) {
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p384Key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ed25519Key := mockEd25519Key(t)
+
+	components := []string{"@method", "@target-uri", "@authority", "@path", "@query", "content-type", "content-digest"}
+
+	tests := []struct {
+		name     string
+		signer   *MessageSigner
+		verifier *MessageVerifier
+		modify   func(req *http.Request)
+		wantErr  bool
+	}{
+		{
+			name: "success hmac-sha256",
+			signer: &MessageSigner{
+				Algorithm:     SignatureHMACSHA256,
+				Components:    components,
+				Key:           mockHMACKey(t),
+				ContentDigest: true,
+			},
+			verifier: &MessageVerifier{
+				Algorithm:  SignatureHMACSHA256,
+				Key:        mockHMACKey(t),
+				Components: []string{"content-digest"},
+			},
+		},
+		{
+			name: "success ed25519",
+			signer: &MessageSigner{
+				KeyID:      "test-key-ed25519",
+				Algorithm:  SignatureEd25519,
+				Components: components,
+				Key:        ed25519Key,
+			},
+			verifier: &MessageVerifier{
+				KeyID:     "test-key-ed25519",
+				Algorithm: SignatureEd25519,
+				Key:       ed25519Key.Public(),
+				MaxAge:    time.Minute,
+			},
+		},
+		{
+			name: "success ecdsa-p256-sha256",
+			signer: &MessageSigner{
+				Algorithm:  SignatureECDSAP256SHA256,
+				Components: components,
+				Key:        ecdsaKey,
+			},
+			verifier: &MessageVerifier{
+				Algorithm: SignatureECDSAP256SHA256,
+				Key:       &ecdsaKey.PublicKey,
+			},
+		},
+		{
+			name: "success key id with delimiters",
+			signer: &MessageSigner{
+				KeyID:      `tenant;a) "b"`,
+				Algorithm:  SignatureHMACSHA256,
+				Components: components,
+				Key:        mockHMACKey(t),
+			},
+			verifier: &MessageVerifier{
+				KeyID:     `tenant;a) "b"`,
+				Algorithm: SignatureHMACSHA256,
+				Key:       mockHMACKey(t),
+			},
+		},
+		{
+			name: "success rsa-pss-sha512",
+			signer: &MessageSigner{
+				Algorithm:  SignatureRSAPSSSHA512,
+				Components: components,
+				Key:        rsaKey,
+			},
+			verifier: &MessageVerifier{
+				Algorithm: SignatureRSAPSSSHA512,
+				Key:       &rsaKey.PublicKey,
+			},
+		},
+		{
+			name: "error ecdsa curve not P-256",
+			signer: &MessageSigner{
+				Algorithm:  SignatureECDSAP256SHA256,
+				Components: components,
+				Key:        ecdsaKey,
+			},
+			verifier: &MessageVerifier{
+				Algorithm: SignatureECDSAP256SHA256,
+				Key:       &p384Key.PublicKey,
+			},
+			wantErr: true,
+		},
+		{
+			name: "error modified query",
+			signer: &MessageSigner{
+				Algorithm:  SignatureEd25519,
+				Components: components,
+				Key:        ed25519Key,
+			},
+			verifier: &MessageVerifier{
+				Algorithm: SignatureEd25519,
+				Key:       ed25519Key.Public(),
+			},
+			modify: func(req *http.Request) {
+				req.URL.RawQuery = "param=Other"
+			},
+			wantErr: true,
+		},
+		{
+			name: "error component not covered",
+			signer: &MessageSigner{
+				Algorithm:  SignatureEd25519,
+				Components: []string{"@method"},
+				Key:        ed25519Key,
+			},
+			verifier: &MessageVerifier{
+				Algorithm:  SignatureEd25519,
+				Key:        ed25519Key.Public(),
+				Components: []string{"content-digest"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "error expired",
+			signer: &MessageSigner{
+				Algorithm:  SignatureEd25519,
+				Components: []string{"@method"},
+				Key:        ed25519Key,
+				Expiry:     time.Second,
+				Now:        mockSignatureTime,
+			},
+			verifier: &MessageVerifier{
+				Algorithm: SignatureEd25519,
+				Key:       ed25519Key.Public(),
+			},
+			wantErr: true,
+		},
+		{
+			name: "error key ID",
+			signer: &MessageSigner{
+				KeyID:      "a",
+				Algorithm:  SignatureEd25519,
+				Components: []string{"@method"},
+				Key:        ed25519Key,
+			},
+			verifier: &MessageVerifier{
+				KeyID:     "b",
+				Algorithm: SignatureEd25519,
+				Key:       ed25519Key.Public(),
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := mockSignatureRequest(t)
+			if err := tt.signer.Authenticate(req); err != nil {
+				t.Fatalf("MessageSigner.Authenticate() error = %v", err)
+			}
+			if tt.modify != nil {
+				tt.modify(req)
+			}
+
+			err := tt.verifier.VerifyRequest(req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("MessageVerifier.VerifyRequest() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseSignatureParams(t *testing.T) {
+	tests := []struct {
+		name           string
+		s              string
+		wantComponents []string
+		wantParams     map[string]string
+		wantErr        bool
+	}{
+		{
+			name:           "success",
+			s:              `("@method" "content-type");created=1618884473;keyid="test-key"`,
+			wantComponents: []string{"@method", "content-type"},
+			wantParams:     map[string]string{"created": "1618884473", "keyid": `"test-key"`},
+		},
+		{
+			name:           "success quoted delimiters",
+			s:              `("@method");nonce="a;b=c";tag="x) \"y\"";flag`,
+			wantComponents: []string{"@method"},
+			wantParams:     map[string]string{"nonce": `"a;b=c"`, "tag": `"x) \"y\""`, "flag": "?1"},
+		},
+		{
+			name:       "success empty list",
+			s:          `();created=1`,
+			wantParams: map[string]string{"created": "1"},
+		},
+		{
+			name:    "error unterminated string",
+			s:       `("@method");nonce="a;b`,
+			wantErr: true,
+		},
+		{
+			name:    "error unterminated inner list",
+			s:       `("@method"`,
+			wantErr: true,
+		},
+		{
+			name:    "error not inner list",
+			s:       `"@method";created=1`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			components, params, err := parseSignatureParams(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseSignatureParams() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(components, tt.wantComponents) {
+				t.Errorf("parseSignatureParams() components = %q, want %q", components, tt.wantComponents)
+			}
+			if !reflect.DeepEqual(params, tt.wantParams) {
+				t.Errorf("parseSignatureParams() params = %q, want %q", params, tt.wantParams)
+			}
+		})
+	}
+}
+
+func TestMessageVerifier_VerifyResponse(t *testing.T) {
+	key := mockEd25519Key(t)
+	verifier := &MessageVerifier{
+		Algorithm:  SignatureEd25519,
+		Key:        key.Public(),
+		Components: []string{"@status", "content-digest"},
+	}
+
+	// The server checks the signature and Content-Digest of the request, and
+	// signs its response with the same key.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := make([]byte, r.ContentLength)
+		r.Body.Read(body)
+		requestVerifier := &MessageVerifier{
+			Algorithm:  SignatureEd25519,
+			Key:        key.Public(),
+			Components: []string{"@method", "@target-uri", "content-digest"},
+		}
+		if err := requestVerifier.VerifyRequest(r); err != nil {
+			t.Errorf("MessageVerifier.VerifyRequest() error = %v", err)
+		}
+		if err := VerifyContentDigest(r.Header, body); err != nil {
+			t.Errorf("VerifyContentDigest() error = %v", err)
+		}
+
+		respBody := []byte(`{"ok":true}`)
+		w.Header().Set("Content-Digest", ContentDigest(respBody))
+		sig := &MessageSigner{Algorithm: SignatureEd25519, Key: key, Components: []string{"@status", "content-digest"}}
+		base, err := signatureBase(responseComponents(&http.Response{StatusCode: http.StatusOK, Header: w.Header()}), sig.Components, sig.params(time.Now()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Header().Set("Signature-Input", "sig1="+sig.params(time.Now()))
+		w.Header().Set("Signature", "sig1=:"+base64.StdEncoding.EncodeToString(ed25519.Sign(key, []byte(base)))+":")
+		w.Write(respBody)
+	}))
+	defer server.Close()
+
+	var body []byte
+	o, err := NewRequest().
+		WithMethod(http.MethodPost).
+		AddHeader("Content-Type", "application/json").
+		WithRequestBody(map[string]string{"hello": "world"}).
+		WithResponseBody(&body).
+		WithAuth(ChainAuth(
+			&TokenAuth{Source: StaticTokenSource("abc")},
+			&MessageSigner{Algorithm: SignatureEd25519, Key: key, Components: []string{"@method", "@target-uri", "authorization", "content-digest"}, ContentDigest: true},
+		)).
+		FromURLString(server.URL + "/foo?a=b")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := o.Do()
+	if err != nil {
+		t.Fatalf("Request.Do() error = %v", err)
+	}
+	if err := verifier.VerifyResponse(resp); err != nil {
+		t.Errorf("MessageVerifier.VerifyResponse() error = %v", err)
+	}
+	if err := VerifyContentDigest(resp.Header, body); err != nil {
+		t.Errorf("VerifyContentDigest() error = %v", err)
+	}
+}
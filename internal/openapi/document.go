@@ -0,0 +1,231 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Document is an OpenAPI 3.0 or 3.1 document.
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       Info                 `json:"info"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components Components           `json:"components"`
+}
+
+// Info is the metadata of a Document.
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Version     string `json:"version"`
+}
+
+// PathItem describes the operations available on a single path.
+type PathItem struct {
+	Parameters []*Parameter `json:"parameters"`
+	Get        *Operation   `json:"get"`
+	Put        *Operation   `json:"put"`
+	Post       *Operation   `json:"post"`
+	Delete     *Operation   `json:"delete"`
+	Options    *Operation   `json:"options"`
+	Head       *Operation   `json:"head"`
+	Patch      *Operation   `json:"patch"`
+	Trace      *Operation   `json:"trace"`
+}
+
+// Operation describes a single API operation on a path.
+type Operation struct {
+	OperationID string               `json:"operationId"`
+	Summary     string               `json:"summary"`
+	Description string               `json:"description"`
+	Tags        []string             `json:"tags"`
+	Deprecated  bool                 `json:"deprecated"`
+	Parameters  []*Parameter         `json:"parameters"`
+	RequestBody *RequestBody         `json:"requestBody"`
+	Responses   map[string]*Response `json:"responses"`
+}
+
+// Parameter describes a single operation parameter.
+type Parameter struct {
+	Ref         string  `json:"$ref"`
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description"`
+	Required    bool    `json:"required"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody describes a single request body.
+type RequestBody struct {
+	Ref         string                `json:"$ref"`
+	Description string                `json:"description"`
+	Required    bool                  `json:"required"`
+	Content     map[string]*MediaType `json:"content"`
+}
+
+// Response describes a single response from an operation.
+type Response struct {
+	Ref         string                `json:"$ref"`
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content"`
+}
+
+// MediaType describes the schema of a request or response body.
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Components holds the reusable objects of a Document.
+type Components struct {
+	Schemas       map[string]*Schema      `json:"schemas"`
+	Parameters    map[string]*Parameter   `json:"parameters"`
+	RequestBodies map[string]*RequestBody `json:"requestBodies"`
+	Responses     map[string]*Response    `json:"responses"`
+}
+
+// Schema describes a data type.
+type Schema struct {
+	Ref                  string                `json:"$ref"`
+	Type                 SchemaType            `json:"type"`
+	Format               string                `json:"format"`
+	Description          string                `json:"description"`
+	Nullable             bool                  `json:"nullable"`
+	Enum                 []interface{}         `json:"enum"`
+	Properties           map[string]*Schema    `json:"properties"`
+	Required             []string              `json:"required"`
+	Items                *Schema               `json:"items"`
+	AdditionalProperties *AdditionalProperties `json:"additionalProperties"`
+	AllOf                []*Schema             `json:"allOf"`
+	OneOf                []*Schema             `json:"oneOf"`
+	AnyOf                []*Schema             `json:"anyOf"`
+}
+
+// SchemaType is the type of a Schema, which is a single string in OpenAPI 3.0
+// and may also be an array of strings in OpenAPI 3.1.
+type SchemaType []string
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (o *SchemaType) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*o = SchemaType{s}
+		return nil
+	}
+
+	var types []string
+	if err := json.Unmarshal(data, &types); err != nil {
+		return fmt.Errorf("type must be string or array of strings: %w", err)
+	}
+	*o = types
+	return nil
+}
+
+// Primary returns the first type other than "null".
+func (o SchemaType) Primary() string {
+	for _, t := range o {
+		if t != "null" {
+			return t
+		}
+	}
+	return ""
+}
+
+// Nullable returns whether the type includes "null".
+func (o SchemaType) Nullable() bool {
+	for _, t := range o {
+		if t == "null" {
+			return true
+		}
+	}
+	return false
+}
+
+// AdditionalProperties is either a boolean or a Schema.
+type AdditionalProperties struct {
+	Allowed bool
+	Schema  *Schema
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (o *AdditionalProperties) UnmarshalJSON(data []byte) error {
+	var allowed bool
+	if err := json.Unmarshal(data, &allowed); err == nil {
+		*o = AdditionalProperties{Allowed: allowed}
+		return nil
+	}
+
+	schema := &Schema{}
+	if err := json.Unmarshal(data, schema); err != nil {
+		return fmt.Errorf("additionalProperties must be boolean or schema: %w", err)
+	}
+	*o = AdditionalProperties{Allowed: true, Schema: schema}
+	return nil
+}
+
+// Parse parses an OpenAPI 3.0 or 3.1 JSON document.
+func Parse(data []byte) (*Document, error) {
+	doc := &Document{}
+	if err := json.Unmarshal(data, doc); err != nil {
+		return nil, fmt.Errorf("error decoding document: %w", err)
+	}
+
+	if !strings.HasPrefix(doc.OpenAPI, "3.0.") && !strings.HasPrefix(doc.OpenAPI, "3.1.") {
+		return nil, fmt.Errorf("unsupported OpenAPI version %q", doc.OpenAPI)
+	}
+
+	return doc, nil
+}
+
+func (o *Document) resolveParameter(p *Parameter) (*Parameter, error) {
+	if p.Ref == "" {
+		return p, nil
+	}
+	name, err := refName(p.Ref, "parameters")
+	if err != nil {
+		return nil, err
+	}
+	resolved, ok := o.Components.Parameters[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown parameter %q", p.Ref)
+	}
+	return o.resolveParameter(resolved)
+}
+
+func (o *Document) resolveRequestBody(b *RequestBody) (*RequestBody, error) {
+	if b.Ref == "" {
+		return b, nil
+	}
+	name, err := refName(b.Ref, "requestBodies")
+	if err != nil {
+		return nil, err
+	}
+	resolved, ok := o.Components.RequestBodies[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown request body %q", b.Ref)
+	}
+	return o.resolveRequestBody(resolved)
+}
+
+func (o *Document) resolveResponse(r *Response) (*Response, error) {
+	if r.Ref == "" {
+		return r, nil
+	}
+	name, err := refName(r.Ref, "responses")
+	if err != nil {
+		return nil, err
+	}
+	resolved, ok := o.Components.Responses[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown response %q", r.Ref)
+	}
+	return o.resolveResponse(resolved)
+}
+
+func refName(ref, kind string) (string, error) {
+	prefix := "#/components/" + kind + "/"
+	if !strings.HasPrefix(ref, prefix) {
+		return "", fmt.Errorf("unsupported reference %q", ref)
+	}
+	return strings.TrimPrefix(ref, prefix), nil
+}
@@ -0,0 +1,105 @@
+package openapi
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    *Document
+		wantErr bool
+	}{
+		{
+			name: "success 3.0",
+			data: `{"openapi":"3.0.3","info":{"title":"Foo"}}`,
+			want: &Document{
+				OpenAPI: "3.0.3",
+				Info: Info{
+					Title: "Foo",
+				},
+			},
+		},
+		{
+			name: "success 3.1 type array and additional properties",
+			data: `{"openapi":"3.1.0","components":{"schemas":{"Foo":{"type":["string","null"]},"Bar":{"type":"object","additionalProperties":false}}}}`,
+			want: &Document{
+				OpenAPI: "3.1.0",
+				Components: Components{
+					Schemas: map[string]*Schema{
+						"Foo": {
+							Type: SchemaType{"string", "null"},
+						},
+						"Bar": {
+							Type:                 SchemaType{"object"},
+							AdditionalProperties: &AdditionalProperties{},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:    "error version 2",
+			data:    `{"swagger":"2.0"}`,
+			wantErr: true,
+		},
+		{
+			name:    "error invalid json",
+			data:    `{`,
+			wantErr: true,
+		},
+		{
+			name:    "error invalid type",
+			data:    `{"openapi":"3.1.0","components":{"schemas":{"Foo":{"type":1}}}}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSchemaType(t *testing.T) {
+	tests := []struct {
+		name         string
+		o            SchemaType
+		wantPrimary  string
+		wantNullable bool
+	}{
+		{
+			name:        "success single",
+			o:           SchemaType{"string"},
+			wantPrimary: "string",
+		},
+		{
+			name:         "success nullable",
+			o:            SchemaType{"null", "integer"},
+			wantPrimary:  "integer",
+			wantNullable: true,
+		},
+		{
+			name: "success empty",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.o.Primary(); got != tt.wantPrimary {
+				t.Errorf("SchemaType.Primary() = %v, want %v", got, tt.wantPrimary)
+			}
+			if got := tt.o.Nullable(); got != tt.wantNullable {
+				t.Errorf("SchemaType.Nullable() = %v, want %v", got, tt.wantNullable)
+			}
+		})
+	}
+}
@@ -0,0 +1,862 @@
+package openapi
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"mime"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Options configure code generation.
+type Options struct {
+	// PackageName is the name of the generated package.
+	PackageName string
+
+	// SplitByTags places the operations of each tag in their own file, named
+	// after the first tag of each operation.
+	SplitByTags bool
+}
+
+const clientImportPath = "github.com/kevinsnydercodes/go-http-client"
+
+var methods = []string{"GET", "PUT", "POST", "DELETE", "OPTIONS", "HEAD", "PATCH", "TRACE"}
+
+func (o *PathItem) operation(method string) *Operation {
+	switch method {
+	case "GET":
+		return o.Get
+	case "PUT":
+		return o.Put
+	case "POST":
+		return o.Post
+	case "DELETE":
+		return o.Delete
+	case "OPTIONS":
+		return o.Options
+	case "HEAD":
+		return o.Head
+	case "PATCH":
+		return o.Patch
+	case "TRACE":
+		return o.Trace
+	default:
+		return nil
+	}
+}
+
+type file struct {
+	imports map[string]bool
+	decls   []string
+}
+
+// reserve adds an empty declaration to be set once it is built, so that
+// declarations of nested types created while building it follow it.
+func (o *file) reserve() int {
+	o.decls = append(o.decls, "")
+	return len(o.decls) - 1
+}
+
+type generator struct {
+	doc         *Document
+	opts        Options
+	names       names
+	schemaNames map[string]string
+	files       map[string]*file
+}
+
+type operation struct {
+	name     string
+	method   string
+	path     string
+	op       *Operation
+	params   []*Parameter
+	fileName string
+}
+
+// Generate generates the Go source files of a client for the Document, keyed
+// by file name.
+//
+// The output is deterministic: the same Document and Options always produce
+// the same files.
+func Generate(doc *Document, opts Options) (map[string][]byte, error) {
+	if opts.PackageName == "" {
+		opts.PackageName = "client"
+	}
+
+	g := &generator{
+		doc:         doc,
+		opts:        opts,
+		names:       names{"Client": true, "NewClient": true, "APIError": true},
+		schemaNames: map[string]string{},
+		files:       map[string]*file{},
+	}
+
+	schemaKeys := sortedKeys(doc.Components.Schemas)
+	for _, key := range schemaKeys {
+		g.schemaNames[key] = g.names.unique(goName(key))
+	}
+
+	g.clientDecl(g.file("client.go"))
+
+	for _, key := range schemaKeys {
+		if err := g.schemaDecl(g.file("types.go"), g.schemaNames[key], key, doc.Components.Schemas[key]); err != nil {
+			return nil, fmt.Errorf("error generating schema %q: %w", key, err)
+		}
+	}
+
+	operations, err := g.operations()
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range operations {
+		if err := g.operationDecl(g.file(o.fileName), o); err != nil {
+			return nil, fmt.Errorf("error generating operation %s %s: %w", o.method, o.path, err)
+		}
+	}
+
+	out := map[string][]byte{}
+	for name, f := range g.files {
+		src := g.render(f)
+		formatted, err := format.Source(src)
+		if err != nil {
+			return nil, fmt.Errorf("error formatting %s: %w", name, err)
+		}
+		out[name] = formatted
+	}
+
+	return out, nil
+}
+
+func (g *generator) file(name string) *file {
+	f, ok := g.files[name]
+	if !ok {
+		f = &file{imports: map[string]bool{}}
+		g.files[name] = f
+	}
+	return f
+}
+
+func (g *generator) render(f *file) []byte {
+	var b bytes.Buffer
+
+	b.WriteString("// Code generated by openapi-gen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&b, "package %s\n\n", g.opts.PackageName)
+
+	var std, other []string
+	for path := range f.imports {
+		if strings.Contains(path, ".") {
+			other = append(other, path)
+		} else {
+			std = append(std, path)
+		}
+	}
+	sort.Strings(std)
+	sort.Strings(other)
+	if len(std)+len(other) > 0 {
+		b.WriteString("import (\n")
+		for _, path := range std {
+			fmt.Fprintf(&b, "%q\n", path)
+		}
+		if len(std) > 0 && len(other) > 0 {
+			b.WriteString("\n")
+		}
+		for _, path := range other {
+			if path == clientImportPath {
+				fmt.Fprintf(&b, "httpclient %q\n", path)
+			} else {
+				fmt.Fprintf(&b, "%q\n", path)
+			}
+		}
+		b.WriteString(")\n\n")
+	}
+
+	b.WriteString(strings.Join(f.decls, "\n"))
+
+	return b.Bytes()
+}
+
+func (g *generator) clientDecl(f *file) {
+	for _, path := range []string{"context", "encoding/json", "errors", "fmt", "net/http", "strings", clientImportPath} {
+		f.imports[path] = true
+	}
+
+	title := "the API"
+	if g.doc.Info.Title != "" {
+		title = "the " + g.doc.Info.Title + " API"
+	}
+
+	f.decls = append(f.decls, fmt.Sprintf(`// Client is a client for %s.
+type Client struct {
+	base *httpclient.Request
+}
+
+// NewClient creates a new Client whose requests are cloned from base, which
+// supplies the client, scheme, host, header, and a path prefix.
+func NewClient(base *httpclient.Request) *Client {
+	if base == nil {
+		base = httpclient.NewRequest()
+	}
+	return &Client{base: base}
+}
+
+// APIError represents a response with an error status code.
+type APIError struct {
+	StatusCode int
+	Body       []byte
+
+	// Value is the decoded body of a documented error response.
+	Value interface{}
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("received status code %%d", e.StatusCode)
+}
+
+// Unwrap returns the StatusCodeError of the response.
+func (e *APIError) Unwrap() error {
+	return &httpclient.StatusCodeError{StatusCode: e.StatusCode}
+}
+
+func newAPIError(resp *http.Response, data []byte, value interface{}) error {
+	err := &APIError{StatusCode: resp.StatusCode, Body: data}
+	if value != nil && len(data) > 0 && json.Unmarshal(data, value) == nil {
+		err.Value = value
+	}
+	return err
+}
+
+func decodeBody(data []byte, v interface{}) error {
+	if len(data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("error decoding response body: %%w", err)
+	}
+	return nil
+}
+
+func (c *Client) do(ctx context.Context, method, path string, params interface{}) (*http.Response, []byte, error) {
+	o, err := c.base.Clone().WithMethod(method).WithPath(strings.TrimSuffix(c.base.Path, "/") + path).Bind(params)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error binding parameters: %%w", err)
+	}
+
+	var data []byte
+	resp, err := o.WithResponseBody(&data).DoContext(ctx)
+	if resp != nil {
+		resp.Body.Close()
+	}
+	var statusCodeErr *httpclient.StatusCodeError
+	if err != nil && !errors.As(err, &statusCodeErr) {
+		return nil, nil, err
+	}
+
+	return resp, data, nil
+}
+`, title))
+}
+
+func (g *generator) schemaDecl(f *file, name, key string, s *Schema) error {
+	doc := append([]string{fmt.Sprintf("%s defines the %s schema.", name, key)}, paragraphs(s.Description)...)
+
+	if isStruct(s) {
+		return g.structDecl(f, name, s, doc)
+	}
+
+	idx := f.reserve()
+	var b strings.Builder
+	writeComment(&b, "", doc)
+
+	if s.Ref == "" && s.Type.Primary() == "string" && s.Format == "" && len(s.Enum) > 0 {
+		fmt.Fprintf(&b, "type %s string\n\n", name)
+		fmt.Fprintf(&b, "// Values of %s.\nconst (\n", name)
+		for _, value := range s.Enum {
+			v, ok := value.(string)
+			if !ok {
+				continue
+			}
+			fmt.Fprintf(&b, "%s %s = %q\n", g.names.unique(name+goName(v)), name, v)
+		}
+		b.WriteString(")\n")
+		f.decls[idx] = b.String()
+		return nil
+	}
+
+	t, err := g.goType(f, s, name+"Value")
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(&b, "type %s %s\n", name, t)
+	f.decls[idx] = b.String()
+	return nil
+}
+
+func isStruct(s *Schema) bool {
+	if s.Ref != "" {
+		return false
+	}
+	if t := s.Type.Primary(); t != "" && t != "object" {
+		return false
+	}
+	return len(s.Properties) > 0 || len(s.AllOf) > 1 || (len(s.AllOf) == 1 && len(s.Properties) > 0)
+}
+
+// isStructSchema returns whether the schema, following references, is
+// generated as a struct.
+func (g *generator) isStructSchema(s *Schema) bool {
+	for s != nil && s.Ref != "" {
+		key, err := refName(s.Ref, "schemas")
+		if err != nil {
+			return false
+		}
+		s = g.doc.Components.Schemas[key]
+	}
+	return s != nil && isStruct(s)
+}
+
+func (g *generator) structDecl(f *file, name string, s *Schema, doc []string) error {
+	idx := f.reserve()
+
+	var b strings.Builder
+	writeComment(&b, "", doc)
+	fmt.Fprintf(&b, "type %s struct {\n", name)
+
+	for _, sub := range s.AllOf {
+		if sub.Ref != "" {
+			t, err := g.goType(f, sub, name)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(&b, "%s\n", t)
+			continue
+		}
+		if err := g.fieldDecls(&b, f, name, sub.Properties, sub.Required); err != nil {
+			return err
+		}
+	}
+	if err := g.fieldDecls(&b, f, name, s.Properties, s.Required); err != nil {
+		return err
+	}
+
+	b.WriteString("}\n")
+	f.decls[idx] = b.String()
+	return nil
+}
+
+func (g *generator) fieldDecls(b *strings.Builder, f *file, structName string, properties map[string]*Schema, required []string) error {
+	isRequired := map[string]bool{}
+	for _, key := range required {
+		isRequired[key] = true
+	}
+
+	for _, key := range sortedKeys(properties) {
+		prop := properties[key]
+		fieldName := goName(key)
+
+		t, err := g.goType(f, prop, structName+fieldName)
+		if err != nil {
+			return fmt.Errorf("error generating property %q: %w", key, err)
+		}
+
+		tag := key
+		if !isRequired[key] {
+			tag += ",omitempty"
+		}
+		if (!isRequired[key] || isNullable(prop)) && isPointable(t) {
+			t = "*" + t
+		}
+
+		writeComment(b, "", paragraphs(prop.Description))
+		fmt.Fprintf(b, "%s %s `json:%q`\n", fieldName, t, tag)
+	}
+
+	return nil
+}
+
+// goType returns the Go type of the schema, declaring any types needed for it
+// in the file. The name is used for inline object schemas.
+func (g *generator) goType(f *file, s *Schema, name string) (string, error) {
+	if s == nil {
+		return "interface{}", nil
+	}
+
+	if s.Ref != "" {
+		key, err := refName(s.Ref, "schemas")
+		if err != nil {
+			return "", err
+		}
+		t, ok := g.schemaNames[key]
+		if !ok {
+			return "", fmt.Errorf("unknown schema %q", s.Ref)
+		}
+		return t, nil
+	}
+
+	if len(s.AllOf) == 1 && len(s.Properties) == 0 {
+		return g.goType(f, s.AllOf[0], name)
+	}
+
+	if variants := append(append([]*Schema(nil), s.OneOf...), s.AnyOf...); len(variants) > 0 {
+		var nonNull []*Schema
+		for _, v := range variants {
+			if v.Ref != "" || v.Type.Primary() != "" || len(v.Properties) > 0 {
+				nonNull = append(nonNull, v)
+			}
+		}
+		if len(nonNull) == 1 {
+			return g.goType(f, nonNull[0], name)
+		}
+		return "interface{}", nil
+	}
+
+	switch s.Type.Primary() {
+	case "string":
+		switch s.Format {
+		case "date-time":
+			f.imports["time"] = true
+			return "time.Time", nil
+		case "byte", "binary":
+			return "[]byte", nil
+		default:
+			return "string", nil
+		}
+	case "integer":
+		switch s.Format {
+		case "int32", "int64":
+			return s.Format, nil
+		default:
+			return "int", nil
+		}
+	case "number":
+		if s.Format == "float" {
+			return "float32", nil
+		}
+		return "float64", nil
+	case "boolean":
+		return "bool", nil
+	case "array":
+		t, err := g.goType(f, s.Items, name+"Item")
+		if err != nil {
+			return "", err
+		}
+		return "[]" + t, nil
+	case "object", "":
+		if isStruct(s) {
+			typeName := g.names.unique(name)
+			doc := append([]string{typeName + " defines an inline schema."}, paragraphs(s.Description)...)
+			if err := g.structDecl(f, typeName, s, doc); err != nil {
+				return "", err
+			}
+			return typeName, nil
+		}
+		if ap := s.AdditionalProperties; ap != nil && ap.Schema != nil {
+			t, err := g.goType(f, ap.Schema, name+"Value")
+			if err != nil {
+				return "", err
+			}
+			return "map[string]" + t, nil
+		}
+		if s.Type.Primary() == "object" {
+			return "map[string]interface{}", nil
+		}
+		return "interface{}", nil
+	default:
+		return "", fmt.Errorf("unsupported type %q", s.Type.Primary())
+	}
+}
+
+func isNullable(s *Schema) bool {
+	if s.Nullable || s.Type.Nullable() {
+		return true
+	}
+	for _, v := range append(append([]*Schema(nil), s.OneOf...), s.AnyOf...) {
+		if v.Ref == "" && v.Type.Nullable() && v.Type.Primary() == "" {
+			return true
+		}
+	}
+	return false
+}
+
+func isPointable(t string) bool {
+	return !strings.HasPrefix(t, "[]") && !strings.HasPrefix(t, "map[") && t != "interface{}"
+}
+
+func (g *generator) operations() ([]*operation, error) {
+	var operations []*operation
+	seen := map[string]bool{}
+
+	for _, path := range sortedKeys(g.doc.Paths) {
+		item := g.doc.Paths[path]
+
+		for _, method := range methods {
+			op := item.operation(method)
+			if op == nil {
+				continue
+			}
+
+			params, err := g.mergeParameters(item.Parameters, op.Parameters)
+			if err != nil {
+				return nil, fmt.Errorf("error resolving parameters of %s %s: %w", method, path, err)
+			}
+
+			name := goName(op.OperationID)
+			if op.OperationID == "" {
+				name = goName(strings.ToLower(method) + " " + path)
+			}
+			if seen[name] {
+				return nil, fmt.Errorf("duplicate operation name %q", name)
+			}
+			seen[name] = true
+
+			target := "api.go"
+			if g.opts.SplitByTags {
+				tag := "default"
+				if len(op.Tags) > 0 {
+					tag = op.Tags[0]
+				}
+				target = fileName(tag) + "_api.go"
+			}
+
+			operations = append(operations, &operation{
+				name:     name,
+				method:   method,
+				path:     path,
+				op:       op,
+				params:   params,
+				fileName: target,
+			})
+		}
+	}
+
+	return operations, nil
+}
+
+func (g *generator) mergeParameters(pathParams, opParams []*Parameter) ([]*Parameter, error) {
+	var params []*Parameter
+	index := map[string]int{}
+
+	for _, p := range append(append([]*Parameter(nil), pathParams...), opParams...) {
+		resolved, err := g.doc.resolveParameter(p)
+		if err != nil {
+			return nil, err
+		}
+
+		key := resolved.In + " " + resolved.Name
+		if i, ok := index[key]; ok {
+			params[i] = resolved
+			continue
+		}
+		index[key] = len(params)
+		params = append(params, resolved)
+	}
+
+	return params, nil
+}
+
+func (g *generator) operationDecl(f *file, o *operation) error {
+	f.imports["context"] = true
+
+	paramsType, err := g.paramsDecl(f, o)
+	if err != nil {
+		return err
+	}
+
+	idx := f.reserve()
+
+	statuses := sortedStatuses(o.op.Responses)
+
+	var resultType, resultStatus string
+	for _, status := range statuses {
+		resp, err := g.doc.resolveResponse(o.op.Responses[status])
+		if err != nil {
+			return err
+		}
+		if !isSuccess(status) || len(resp.Content) == 0 {
+			continue
+		}
+		mediaType, content := pickMediaType(resp.Content)
+		if isJSON(mediaType) {
+			resultType, err = g.goType(f, content.Schema, o.name+"Response")
+			if err != nil {
+				return err
+			}
+			if g.isStructSchema(content.Schema) {
+				resultType = "*" + resultType
+			}
+		} else {
+			resultType = "[]byte"
+		}
+		resultStatus = status
+		break
+	}
+
+	var b strings.Builder
+
+	doc := []string{fmt.Sprintf("%s makes a %s request to %s.", o.name, o.method, o.path)}
+	doc = append(doc, paragraphs(o.op.Summary)...)
+	doc = append(doc, paragraphs(o.op.Description)...)
+	if o.op.Deprecated {
+		doc = append(doc, "Deprecated: this operation is deprecated.")
+	}
+	writeComment(&b, "", doc)
+
+	args := "ctx context.Context"
+	paramsArg := "nil"
+	if paramsType != "" {
+		args += ", params *" + paramsType
+		paramsArg = "params"
+	}
+	results := "error"
+	ret := "err"
+	if resultType != "" {
+		results = "(" + resultType + ", error)"
+		ret = "out, err"
+	}
+
+	fmt.Fprintf(&b, "func (c *Client) %s(%s) %s {\n", o.name, args, results)
+	if resultType != "" {
+		fmt.Fprintf(&b, "var out %s\n", resultType)
+	}
+	fmt.Fprintf(&b, "resp, data, err := c.do(ctx, %q, %q, %s)\n", o.method, o.path, paramsArg)
+	fmt.Fprintf(&b, "if err != nil {\nreturn %s\n}\n\n", ret)
+	b.WriteString("switch {\n")
+
+	hasDefault := false
+	for _, status := range statuses {
+		resp, err := g.doc.resolveResponse(o.op.Responses[status])
+		if err != nil {
+			return err
+		}
+		if status == "default" {
+			hasDefault = true
+		}
+
+		fmt.Fprintf(&b, "case %s:\n", statusCondition(status))
+
+		if isSuccess(status) {
+			if status == resultStatus {
+				if resultType == "[]byte" {
+					b.WriteString("out = data\n")
+				} else {
+					b.WriteString("err = decodeBody(data, &out)\n")
+				}
+			}
+			continue
+		}
+
+		errorValue := "nil"
+		if mediaType, content := pickMediaType(resp.Content); content != nil && isJSON(mediaType) {
+			t, err := g.goType(f, content.Schema, o.name+statusName(status)+"Response")
+			if err != nil {
+				return err
+			}
+			errorValue = "new(" + t + ")"
+		}
+		fmt.Fprintf(&b, "err = newAPIError(resp, data, %s)\n", errorValue)
+	}
+	if !hasDefault {
+		b.WriteString("case resp.StatusCode/100 != 2:\nerr = newAPIError(resp, data, nil)\n")
+	}
+
+	fmt.Fprintf(&b, "}\n\nreturn %s\n}\n", ret)
+
+	f.decls[idx] = b.String()
+	return nil
+}
+
+func (g *generator) paramsDecl(f *file, o *operation) (string, error) {
+	var body *RequestBody
+	if o.op.RequestBody != nil {
+		var err error
+		body, err = g.doc.resolveRequestBody(o.op.RequestBody)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if len(o.params) == 0 && body == nil {
+		return "", nil
+	}
+
+	name := g.names.unique(o.name + "Params")
+	idx := f.reserve()
+
+	var b strings.Builder
+	writeComment(&b, "", []string{fmt.Sprintf("%s are the parameters of %s.", name, o.name)})
+	fmt.Fprintf(&b, "type %s struct {\n", name)
+
+	for _, p := range o.params {
+		switch p.In {
+		case "path", "query", "header":
+		case "cookie":
+			continue
+		default:
+			return "", fmt.Errorf("unsupported location %q of parameter %q", p.In, p.Name)
+		}
+
+		fieldName := goName(p.Name)
+		t, err := g.goType(f, p.Schema, name+fieldName)
+		if err != nil {
+			return "", fmt.Errorf("error generating parameter %q: %w", p.Name, err)
+		}
+
+		tag := p.Name
+		if p.In != "path" && !p.Required {
+			tag += ",omitempty"
+			if isPointable(t) {
+				t = "*" + t
+			}
+		}
+
+		writeComment(&b, "", paragraphs(p.Description))
+		fmt.Fprintf(&b, "%s %s `%s:%q`\n", fieldName, t, p.In, tag)
+	}
+
+	if body != nil && len(body.Content) > 0 {
+		mediaType, content := pickMediaType(body.Content)
+
+		t := "[]byte"
+		if isJSON(mediaType) {
+			var err error
+			t, err = g.goType(f, content.Schema, o.name+"Request")
+			if err != nil {
+				return "", fmt.Errorf("error generating request body: %w", err)
+			}
+			if isPointable(t) {
+				t = "*" + t
+			}
+		}
+
+		writeComment(&b, "", paragraphs(body.Description))
+		fmt.Fprintf(&b, "Body %s `body:%q`\n", t, mediaType)
+	}
+
+	b.WriteString("}\n")
+	f.decls[idx] = b.String()
+	return name, nil
+}
+
+// pickMediaType returns the first JSON media type, or the first media type in
+// sorted order if there is none.
+func pickMediaType(content map[string]*MediaType) (string, *MediaType) {
+	keys := sortedKeys(content)
+	for _, key := range keys {
+		if isJSON(key) {
+			return key, content[key]
+		}
+	}
+	if len(keys) == 0 {
+		return "", nil
+	}
+	return keys[0], content[keys[0]]
+}
+
+func isJSON(mediaType string) bool {
+	t, _, err := mime.ParseMediaType(mediaType)
+	if err != nil {
+		return false
+	}
+	return t == "application/json" || strings.HasSuffix(t, "+json")
+}
+
+func isSuccess(status string) bool {
+	return strings.HasPrefix(status, "2")
+}
+
+func statusCondition(status string) string {
+	switch {
+	case status == "default":
+		return "resp.StatusCode/100 != 2"
+	case strings.HasSuffix(strings.ToUpper(status), "XX"):
+		return fmt.Sprintf("resp.StatusCode/100 == %s", status[:1])
+	default:
+		return fmt.Sprintf("resp.StatusCode == %s", status)
+	}
+}
+
+func statusName(status string) string {
+	if status == "default" {
+		return "Default"
+	}
+	return strings.ToUpper(status)
+}
+
+// sortedStatuses sorts exact status codes first, then ranges such as "4XX",
+// then "default".
+func sortedStatuses(responses map[string]*Response) []string {
+	rank := func(status string) int {
+		switch {
+		case status == "default":
+			return 2
+		case strings.HasSuffix(strings.ToUpper(status), "XX"):
+			return 1
+		default:
+			return 0
+		}
+	}
+
+	var statuses []string
+	for status := range responses {
+		if rank(status) == 0 {
+			if _, err := strconv.Atoi(status); err != nil {
+				continue
+			}
+		}
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		if ri, rj := rank(statuses[i]), rank(statuses[j]); ri != rj {
+			return ri < rj
+		}
+		return statuses[i] < statuses[j]
+	})
+
+	return statuses
+}
+
+func paragraphs(s string) []string {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil
+	}
+	return []string{s}
+}
+
+func writeComment(b *strings.Builder, indent string, paragraphs []string) {
+	for i, p := range paragraphs {
+		if i > 0 {
+			fmt.Fprintf(b, "%s//\n", indent)
+		}
+		for _, line := range strings.Split(p, "\n") {
+			line = strings.TrimRight(line, " \t")
+			if line == "" {
+				fmt.Fprintf(b, "%s//\n", indent)
+				continue
+			}
+			fmt.Fprintf(b, "%s// %s\n", indent, line)
+		}
+	}
+}
+
+func sortedKeys(m interface{}) []string {
+	var keys []string
+	switch m := m.(type) {
+	case map[string]*Schema:
+		for key := range m {
+			keys = append(keys, key)
+		}
+	case map[string]*PathItem:
+		for key := range m {
+			keys = append(keys, key)
+		}
+	case map[string]*MediaType:
+		for key := range m {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,138 @@
+package openapi
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+func TestGenerate(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+	}{
+		{
+			name: "petstore",
+			opts: Options{
+				PackageName: "petstore",
+				SplitByTags: true,
+			},
+		},
+		{
+			name: "widgets",
+			opts: Options{
+				PackageName: "widgets",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := ioutil.ReadFile(filepath.Join("testdata", tt.name+".json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			doc, err := Parse(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := Generate(doc, tt.opts)
+			if err != nil {
+				t.Fatalf("Generate() error = %v", err)
+			}
+
+			// Ensure that the output is deterministic
+			again, err := Generate(doc, tt.opts)
+			if err != nil {
+				t.Fatalf("Generate() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, again) {
+				t.Errorf("Generate() is not deterministic")
+			}
+
+			dir := filepath.Join("testdata", tt.name)
+			if *update {
+				if err := os.RemoveAll(dir); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.MkdirAll(dir, 0755); err != nil {
+					t.Fatal(err)
+				}
+				for name, src := range got {
+					if err := ioutil.WriteFile(filepath.Join(dir, name+".golden"), src, 0644); err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
+
+			golden, err := filepath.Glob(filepath.Join(dir, "*.golden"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var wantNames, gotNames []string
+			for _, path := range golden {
+				wantNames = append(wantNames, strings.TrimSuffix(filepath.Base(path), ".golden"))
+			}
+			for name := range got {
+				gotNames = append(gotNames, name)
+			}
+			sort.Strings(wantNames)
+			sort.Strings(gotNames)
+			if !reflect.DeepEqual(gotNames, wantNames) {
+				t.Fatalf("Generate() files = %v, want %v", gotNames, wantNames)
+			}
+
+			for _, name := range wantNames {
+				want, err := ioutil.ReadFile(filepath.Join(dir, name+".golden"))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(got[name]) != string(want) {
+					t.Errorf("Generate() %s = \n%s\nwant\n%s", name, got[name], want)
+				}
+			}
+		})
+	}
+}
+
+func TestGenerate_Error(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+	}{
+		{
+			name: "unknown schema reference",
+			doc:  `{"openapi":"3.0.0","components":{"schemas":{"Foo":{"$ref":"#/components/schemas/Bar"}}}}`,
+		},
+		{
+			name: "external reference",
+			doc:  `{"openapi":"3.0.0","components":{"schemas":{"Foo":{"$ref":"other.json#/Foo"}}}}`,
+		},
+		{
+			name: "duplicate operation name",
+			doc:  `{"openapi":"3.0.0","paths":{"/a":{"get":{"operationId":"foo"}},"/b":{"get":{"operationId":"foo"}}}}`,
+		},
+		{
+			name: "unknown parameter reference",
+			doc:  `{"openapi":"3.0.0","paths":{"/a":{"get":{"parameters":[{"$ref":"#/components/parameters/Foo"}]}}}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Parse([]byte(tt.doc))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := Generate(doc, Options{}); err == nil {
+				t.Errorf("Generate() error = %v, wantErr %v", err, true)
+			}
+		})
+	}
+}
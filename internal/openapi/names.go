@@ -0,0 +1,114 @@
+package openapi
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var initialisms = map[string]bool{
+	"API":   true,
+	"CPU":   true,
+	"DNS":   true,
+	"HTML":  true,
+	"HTTP":  true,
+	"HTTPS": true,
+	"ID":    true,
+	"IP":    true,
+	"JSON":  true,
+	"SQL":   true,
+	"TLS":   true,
+	"TTL":   true,
+	"UI":    true,
+	"UID":   true,
+	"URI":   true,
+	"URL":   true,
+	"UUID":  true,
+	"XML":   true,
+}
+
+// splitWords splits s into words on non-alphanumeric characters and on changes
+// of case, keeping runs of upper case letters such as acronyms together.
+func splitWords(s string) []string {
+	var words []string
+	var word []rune
+
+	runes := []rune(s)
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			if len(word) > 0 {
+				words = append(words, string(word))
+				word = nil
+			}
+			continue
+		}
+
+		if len(word) > 0 && unicode.IsUpper(r) {
+			prev := word[len(word)-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if !unicode.IsUpper(prev) || nextLower {
+				words = append(words, string(word))
+				word = nil
+			}
+		}
+
+		word = append(word, r)
+	}
+	if len(word) > 0 {
+		words = append(words, string(word))
+	}
+
+	return words
+}
+
+// goName converts s into an exported Go identifier.
+func goName(s string) string {
+	var b strings.Builder
+
+	for _, word := range splitWords(s) {
+		upper := strings.ToUpper(word)
+		if initialisms[upper] {
+			b.WriteString(upper)
+			continue
+		}
+
+		runes := []rune(strings.ToLower(word))
+		runes[0] = unicode.ToUpper(runes[0])
+		b.WriteString(string(runes))
+	}
+
+	name := b.String()
+	if name == "" {
+		return "Empty"
+	}
+	if unicode.IsDigit([]rune(name)[0]) {
+		return "N" + name
+	}
+	return name
+}
+
+// fileName converts s into a lower case file name stem.
+func fileName(s string) string {
+	words := splitWords(s)
+	for i, word := range words {
+		words[i] = strings.ToLower(word)
+	}
+	if len(words) == 0 {
+		return "default"
+	}
+	return strings.Join(words, "_")
+}
+
+// names allocates unique Go identifiers.
+type names map[string]bool
+
+// unique returns name, or name followed by the smallest number that makes it
+// unique, and reserves the result.
+func (o names) unique(name string) string {
+	result := name
+	for i := 2; o[result]; i++ {
+		result = name + strconv.Itoa(i)
+	}
+	o[result] = true
+	return result
+}
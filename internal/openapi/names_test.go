@@ -0,0 +1,59 @@
+package openapi
+
+import "testing"
+
+func TestGoName(t *testing.T) {
+	tests := []struct {
+		s    string
+		want string
+	}{
+		{s: "listPets", want: "ListPets"},
+		{s: "showPetById", want: "ShowPetByID"},
+		{s: "get-widget", want: "GetWidget"},
+		{s: "get /users/{userId}/api_keys", want: "GetUsersUserIDAPIKeys"},
+		{s: "X-Request-ID", want: "XRequestID"},
+		{s: "HTTPServer", want: "HTTPServer"},
+		{s: "snake_case_name", want: "SnakeCaseName"},
+		{s: "404", want: "N404"},
+		{s: "", want: "Empty"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			if got := goName(tt.s); got != tt.want {
+				t.Errorf("goName() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFileName(t *testing.T) {
+	tests := []struct {
+		s    string
+		want string
+	}{
+		{s: "pets", want: "pets"},
+		{s: "Pet Store", want: "pet_store"},
+		{s: "userAccounts", want: "user_accounts"},
+		{s: "", want: "default"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			if got := fileName(tt.s); got != tt.want {
+				t.Errorf("fileName() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNames_Unique(t *testing.T) {
+	o := names{"Client": true}
+
+	for _, want := range []string{"Pet", "Pet2", "Pet3"} {
+		if got := o.unique("Pet"); got != want {
+			t.Errorf("names.unique() = %v, want %v", got, want)
+		}
+	}
+	if got := o.unique("Client"); got != "Client2" {
+		t.Errorf("names.unique() = %v, want %v", got, "Client2")
+	}
+}
@@ -0,0 +1,367 @@
+package http
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// JWTAlgorithm is a JSON Web Signature algorithm.
+type JWTAlgorithm string
+
+const (
+	JWTHS256 JWTAlgorithm = "HS256"
+	JWTRS256 JWTAlgorithm = "RS256"
+	JWTES256 JWTAlgorithm = "ES256"
+	JWTEdDSA JWTAlgorithm = "EdDSA"
+)
+
+// DefaultJWTLifetime is the lifetime of JWTs if JWTSource.Lifetime is zero.
+const DefaultJWTLifetime = 5 * time.Minute
+
+// JWTSource is a TokenSource of self-signed JWTs, which it caches until they
+// are about to expire.
+//
+// As a TokenSource, its tokens have the configured audience. As an
+// Authenticator, the audience of each request defaults to its scheme and
+// host, such as "https://api.example.com".
+type JWTSource struct {
+	Algorithm JWTAlgorithm
+
+	// Key is a []byte for HS256, an *rsa.PrivateKey for RS256, an
+	// *ecdsa.PrivateKey for ES256, or an ed25519.PrivateKey for EdDSA.
+	Key   interface{}
+	KeyID string
+
+	Issuer   string
+	Subject  string
+	Audience string
+
+	// Claims are added to the registered claims of each token.
+	Claims map[string]interface{}
+
+	// Lifetime is how long tokens are valid.
+	Lifetime time.Duration
+
+	// ExpirySkew is how long before their expiry tokens are replaced.
+	ExpirySkew time.Duration
+
+	// Now returns the current time, time.Now if nil.
+	Now func() time.Time
+
+	mu     sync.Mutex
+	tokens map[string]*Token
+}
+
+// Token returns a token for the configured audience.
+func (o *JWTSource) Token(ctx context.Context) (*Token, error) {
+	return o.token(o.Audience)
+}
+
+// Authenticate sets the "Authorization" header of the request to a token for
+// the configured audience, or the scheme and host of the request.
+func (o *JWTSource) Authenticate(req *http.Request) error {
+	audience := o.Audience
+	if audience == "" {
+		audience = req.URL.Scheme + "://" + requestHost(req)
+	}
+
+	auth := &TokenAuth{
+		Source: TokenSourceFunc(func(context.Context) (*Token, error) {
+			return o.token(audience)
+		}),
+	}
+	return auth.Authenticate(req)
+}
+
+func (o *JWTSource) token(audience string) (*Token, error) {
+	now := o.now()
+	skew := o.ExpirySkew
+	if skew == 0 {
+		skew = DefaultExpirySkew
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if token, ok := o.tokens[audience]; ok && now.Add(skew).Before(token.Expiry) {
+		return token, nil
+	}
+
+	lifetime := o.Lifetime
+	if lifetime == 0 {
+		lifetime = DefaultJWTLifetime
+	}
+
+	jti := make([]byte, 16)
+	if _, err := rand.Read(jti); err != nil {
+		return nil, err
+	}
+
+	claims := map[string]interface{}{}
+	for key, value := range o.Claims {
+		claims[key] = value
+	}
+	claims["iat"] = now.Unix()
+	claims["exp"] = now.Add(lifetime).Unix()
+	claims["jti"] = base64.RawURLEncoding.EncodeToString(jti)
+	if o.Issuer != "" {
+		claims["iss"] = o.Issuer
+	}
+	if o.Subject != "" {
+		claims["sub"] = o.Subject
+	}
+	if audience != "" {
+		claims["aud"] = audience
+	}
+
+	value, err := SignJWT(o.Algorithm, o.Key, o.KeyID, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	token := &Token{
+		Value:  Secret(value),
+		Expiry: now.Add(lifetime),
+	}
+	if o.tokens == nil {
+		o.tokens = map[string]*Token{}
+	}
+	o.tokens[audience] = token
+
+	return token, nil
+}
+
+func (o *JWTSource) now() time.Time {
+	if o.Now != nil {
+		return o.Now()
+	}
+	return time.Now()
+}
+
+// SignJWT returns a compact JWT of the claims signed with the key.
+func SignJWT(alg JWTAlgorithm, key interface{}, keyID string, claims map[string]interface{}) (string, error) {
+	header := map[string]string{
+		"alg": string(alg),
+		"typ": "JWT",
+	}
+	if keyID != "" {
+		header["kid"] = keyID
+	}
+
+	headerJSON, err := json.Marshal(header)
+	if err != nil {
+		return "", fmt.Errorf("error encoding header: %w", err)
+	}
+	claimsJSON, err := json.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("error encoding claims: %w", err)
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(headerJSON) + "." + base64.RawURLEncoding.EncodeToString(claimsJSON)
+
+	var signature []byte
+	switch alg {
+	case JWTHS256:
+		k, ok := key.([]byte)
+		if !ok {
+			return "", fmt.Errorf("key must be []byte for %s", alg)
+		}
+		h := hmac.New(sha256.New, k)
+		h.Write([]byte(signingInput))
+		signature = h.Sum(nil)
+	case JWTRS256:
+		k, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return "", fmt.Errorf("key must be *rsa.PrivateKey for %s", alg)
+		}
+		sum := sha256.Sum256([]byte(signingInput))
+		if signature, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, sum[:]); err != nil {
+			return "", fmt.Errorf("error signing: %w", err)
+		}
+	case JWTES256:
+		k, ok := key.(*ecdsa.PrivateKey)
+		if !ok {
+			return "", fmt.Errorf("key must be *ecdsa.PrivateKey for %s", alg)
+		}
+		if signature, err = signECDSAP256(k, []byte(signingInput)); err != nil {
+			return "", fmt.Errorf("error signing: %w", err)
+		}
+	case JWTEdDSA:
+		k, ok := key.(ed25519.PrivateKey)
+		if !ok {
+			return "", fmt.Errorf("key must be ed25519.PrivateKey for %s", alg)
+		}
+		signature = ed25519.Sign(k, []byte(signingInput))
+	default:
+		return "", fmt.Errorf("unsupported JWT algorithm %q", alg)
+	}
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// JWTVerifier verifies JWTs, such as those of a JWTSource in tests.
+type JWTVerifier struct {
+	Algorithm JWTAlgorithm
+
+	// Key is a []byte for HS256, an *rsa.PublicKey for RS256, an
+	// *ecdsa.PublicKey for ES256, or an ed25519.PublicKey for EdDSA.
+	Key interface{}
+
+	// Issuer and Audience, if set, must match the claims.
+	Issuer   string
+	Audience string
+
+	// Leeway is the clock skew allowed when checking times.
+	Leeway time.Duration
+
+	// Now returns the current time, time.Now if nil.
+	Now func() time.Time
+}
+
+// Verify checks the signature and claims of the token, and returns its claims.
+func (o *JWTVerifier) Verify(token string) (map[string]interface{}, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("malformed JWT")
+	}
+
+	headerJSON, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("error decoding header: %w", err)
+	}
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := json.Unmarshal(headerJSON, &header); err != nil {
+		return nil, fmt.Errorf("error decoding header: %w", err)
+	}
+	if header.Alg != string(o.Algorithm) {
+		return nil, fmt.Errorf("JWT algorithm %q does not match", header.Alg)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("error decoding signature: %w", err)
+	}
+	if err := o.check([]byte(parts[0]+"."+parts[1]), signature); err != nil {
+		return nil, err
+	}
+
+	claimsJSON, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("error decoding claims: %w", err)
+	}
+	var claims map[string]interface{}
+	if err := json.Unmarshal(claimsJSON, &claims); err != nil {
+		return nil, fmt.Errorf("error decoding claims: %w", err)
+	}
+
+	if err := o.checkClaims(claims); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+// VerifyRequest verifies the bearer JWT of the request's "Authorization"
+// header, and returns its claims.
+func (o *JWTVerifier) VerifyRequest(req *http.Request) (map[string]interface{}, error) {
+	parts := strings.SplitN(req.Header.Get("Authorization"), " ", 2)
+	if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") {
+		return nil, fmt.Errorf("missing bearer token")
+	}
+	return o.Verify(parts[1])
+}
+
+func (o *JWTVerifier) check(signingInput, signature []byte) error {
+	valid := false
+
+	switch o.Algorithm {
+	case JWTHS256:
+		key, ok := o.Key.([]byte)
+		if !ok {
+			return fmt.Errorf("key must be []byte for %s", o.Algorithm)
+		}
+		h := hmac.New(sha256.New, key)
+		h.Write(signingInput)
+		valid = hmac.Equal(h.Sum(nil), signature)
+	case JWTRS256:
+		key, ok := o.Key.(*rsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("key must be *rsa.PublicKey for %s", o.Algorithm)
+		}
+		sum := sha256.Sum256(signingInput)
+		valid = rsa.VerifyPKCS1v15(key, crypto.SHA256, sum[:], signature) == nil
+	case JWTES256:
+		key, ok := o.Key.(*ecdsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("key must be *ecdsa.PublicKey for %s", o.Algorithm)
+		}
+		var err error
+		if valid, err = verifyECDSAP256(key, signingInput, signature); err != nil {
+			return err
+		}
+	case JWTEdDSA:
+		key, ok := o.Key.(ed25519.PublicKey)
+		if !ok {
+			return fmt.Errorf("key must be ed25519.PublicKey for %s", o.Algorithm)
+		}
+		valid = ed25519.Verify(key, signingInput, signature)
+	default:
+		return fmt.Errorf("unsupported JWT algorithm %q", o.Algorithm)
+	}
+
+	if !valid {
+		return fmt.Errorf("invalid JWT signature")
+	}
+	return nil
+}
+
+func (o *JWTVerifier) checkClaims(claims map[string]interface{}) error {
+	now := time.Now()
+	if o.Now != nil {
+		now = o.Now()
+	}
+
+	if exp, ok := claims["exp"].(float64); ok && now.Add(-o.Leeway).Unix() >= int64(exp) {
+		return fmt.Errorf("JWT expired")
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Add(o.Leeway).Unix() < int64(nbf) {
+		return fmt.Errorf("JWT not yet valid")
+	}
+	if o.Issuer != "" && claims["iss"] != o.Issuer {
+		return fmt.Errorf("JWT issuer does not match")
+	}
+	if o.Audience != "" && !jwtHasAudience(claims["aud"], o.Audience) {
+		return fmt.Errorf("JWT audience does not match")
+	}
+
+	return nil
+}
+
+func jwtHasAudience(aud interface{}, audience string) bool {
+	switch aud := aud.(type) {
+	case string:
+		return aud == audience
+	case []interface{}:
+		for _, a := range aud {
+			if a == audience {
+				return true
+			}
+		}
+	}
+	return false
+}
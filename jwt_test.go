@@ -0,0 +1,201 @@
+package http
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestJWTSource_Token(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p384Key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ed25519Public, ed25519Key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		algorithm JWTAlgorithm
+		key       interface{}
+		verifyKey interface{}
+		wantErr   bool
+	}{
+		{
+			name:      "success HS256",
+			algorithm: JWTHS256,
+			key:       []byte("secret"),
+			verifyKey: []byte("secret"),
+		},
+		{
+			name:      "success RS256",
+			algorithm: JWTRS256,
+			key:       rsaKey,
+			verifyKey: &rsaKey.PublicKey,
+		},
+		{
+			name:      "success ES256",
+			algorithm: JWTES256,
+			key:       ecdsaKey,
+			verifyKey: &ecdsaKey.PublicKey,
+		},
+		{
+			name:      "success EdDSA",
+			algorithm: JWTEdDSA,
+			key:       ed25519Key,
+			verifyKey: ed25519Public,
+		},
+		{
+			name:      "error wrong key",
+			algorithm: JWTHS256,
+			key:       []byte("secret"),
+			verifyKey: []byte("other"),
+			wantErr:   true,
+		},
+		{
+			name:      "error ES256 key curve",
+			algorithm: JWTES256,
+			key:       p384Key,
+			wantErr:   true,
+		},
+		{
+			name:      "error ES256 verify key curve",
+			algorithm: JWTES256,
+			key:       ecdsaKey,
+			verifyKey: &p384Key.PublicKey,
+			wantErr:   true,
+		},
+		{
+			name:      "error key type",
+			algorithm: JWTRS256,
+			key:       []byte("secret"),
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := &JWTSource{
+				Algorithm: tt.algorithm,
+				Key:       tt.key,
+				Issuer:    "svc-a",
+				Subject:   "svc-a",
+				Audience:  "https://svc-b.example.com",
+				Claims: map[string]interface{}{
+					"scope": "read",
+				},
+			}
+			token, err := o.Token(context.Background())
+			if err == nil {
+				verifier := &JWTVerifier{
+					Algorithm: tt.algorithm,
+					Key:       tt.verifyKey,
+					Issuer:    "svc-a",
+					Audience:  "https://svc-b.example.com",
+				}
+				var claims map[string]interface{}
+				claims, err = verifier.Verify(string(token.Value))
+				if err == nil && (claims["scope"] != "read" || claims["sub"] != "svc-a" || claims["jti"] == "") {
+					t.Errorf("JWTVerifier.Verify() claims = %v", claims)
+				}
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("JWTSource.Token() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSignJWT_ecdsaCurve(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P521(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := SignJWT(JWTES256, key, "", map[string]interface{}{"sub": "a"}); err == nil {
+		t.Errorf("SignJWT() error = nil, want error for P-521 key")
+	}
+}
+
+func TestJWTSource_Token_cache(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	o := &JWTSource{
+		Algorithm: JWTHS256,
+		Key:       []byte("secret"),
+		Lifetime:  time.Minute,
+		Now:       func() time.Time { return now },
+	}
+
+	first, err := o.Token(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now = now.Add(45 * time.Second)
+	second, err := o.Token(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second != first {
+		t.Errorf("JWTSource.Token() = new token, want cached token")
+	}
+
+	now = now.Add(10 * time.Second)
+	third, err := o.Token(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if third == first {
+		t.Errorf("JWTSource.Token() = cached token, want new token near expiry")
+	}
+
+	now = now.Add(10 * time.Second)
+	verifier := &JWTVerifier{Algorithm: JWTHS256, Key: []byte("secret"), Now: func() time.Time { return now }}
+	if _, err := verifier.Verify(string(first.Value)); err == nil {
+		t.Errorf("JWTVerifier.Verify() error = nil, want expired")
+	}
+}
+
+func TestRequest_Do_jwt(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		verifier := &JWTVerifier{
+			Algorithm: JWTHS256,
+			Key:       []byte("secret"),
+			Issuer:    "svc-a",
+			Audience:  server.URL,
+		}
+		if _, err := verifier.VerifyRequest(r); err != nil {
+			t.Errorf("JWTVerifier.VerifyRequest() error = %v", err)
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+
+	o, err := NewRequest().
+		WithMethod(http.MethodGet).
+		WithAuth(&JWTSource{Algorithm: JWTHS256, Key: []byte("secret"), Issuer: "svc-a"}).
+		FromURLString(server.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := o.Do(); err != nil {
+		t.Errorf("Request.Do() error = %v", err)
+	}
+}
@@ -0,0 +1,84 @@
+package http
+
+import (
+	"io"
+	"net/http"
+	"sync"
+)
+
+// Middleware wraps the transport of a client, such as to limit, observe, or
+// change its requests.
+type Middleware func(next http.RoundTripper) http.RoundTripper
+
+// RoundTripperFunc is a function that implements http.RoundTripper.
+type RoundTripperFunc func(req *http.Request) (*http.Response, error)
+
+// RoundTrip calls f(req).
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// WithMiddleware wraps the transport of the Request's client with the
+// middleware, the first being the outermost.
+//
+// The client is copied, so that a shared client such as http.DefaultClient is
+// not changed, and clones of the Request share the middleware.
+func (o *Request) WithMiddleware(middleware ...Middleware) *Request {
+	o.ensureClient()
+
+	transport := o.Client.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	for i := len(middleware) - 1; i >= 0; i-- {
+		transport = middleware[i](transport)
+	}
+
+	client := *o.Client
+	client.Transport = transport
+	o.Client = &client
+	return o
+}
+
+// HostKey returns the host of the request, the default key of limiters and
+// circuit breakers.
+func HostKey(req *http.Request) string {
+	return requestHost(req)
+}
+
+// RouteKey returns the method, host, and path of the request, to key limiters
+// and circuit breakers by endpoint.
+func RouteKey(req *http.Request) string {
+	return req.Method + " " + requestHost(req) + req.URL.EscapedPath()
+}
+
+// doneBody is a response body that calls done once, with the error if any,
+// when it is read to the end or closed.
+type doneBody struct {
+	io.ReadCloser
+	once sync.Once
+	done func(err error)
+}
+
+func (o *doneBody) Read(p []byte) (int, error) {
+	n, err := o.ReadCloser.Read(p)
+	if err != nil {
+		o.finish(err)
+	}
+	return n, err
+}
+
+func (o *doneBody) Close() error {
+	err := o.ReadCloser.Close()
+	o.finish(nil)
+	return err
+}
+
+func (o *doneBody) finish(err error) {
+	if err == io.EOF {
+		err = nil
+	}
+	o.once.Do(func() {
+		o.done(err)
+	})
+}
@@ -0,0 +1,45 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestRequest_WithMiddleware(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get("X-Order")))
+	}))
+	defer server.Close()
+
+	var calls []string
+	middleware := func(name string) Middleware {
+		return func(next http.RoundTripper) http.RoundTripper {
+			return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				calls = append(calls, name)
+				req.Header.Add("X-Order", name)
+				return next.RoundTrip(req)
+			})
+		}
+	}
+
+	base, err := NewRequest().WithMethod(http.MethodGet).WithMiddleware(middleware("a"), middleware("b")).FromURLString(server.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if http.DefaultClient.Transport != nil {
+		t.Errorf("Request.WithMiddleware() changed http.DefaultClient")
+	}
+
+	var body []byte
+	if _, err := base.Clone().WithResponseBody(&body).Do(); err != nil {
+		t.Fatalf("Request.Do() error = %v", err)
+	}
+	if want := []string{"a", "b"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("Request.WithMiddleware() calls = %v, want %v", calls, want)
+	}
+	if string(body) != "a" {
+		t.Errorf("Request.Do() body = %q, want %q", body, "a")
+	}
+}
@@ -0,0 +1,261 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultExpirySkew is how long before their expiry OAuth2 tokens are
+// refreshed, if OAuth2Config.ExpirySkew is zero.
+const DefaultExpirySkew = 10 * time.Second
+
+// OAuth2Config is the configuration of an OAuth2 client.
+type OAuth2Config struct {
+	Client *http.Client
+
+	TokenURL     string
+	ClientID     string
+	ClientSecret Secret
+	Scopes       []string
+
+	// ClientAuthInBody sends the client credentials in the request body
+	// rather than with basic auth.
+	ClientAuthInBody bool
+
+	// ExpirySkew is how long before their expiry tokens are refreshed.
+	ExpirySkew time.Duration
+}
+
+// ClientCredentials returns a TokenSource that supplies tokens of the
+// client_credentials grant.
+func (c *OAuth2Config) ClientCredentials() *OAuth2TokenSource {
+	return &OAuth2TokenSource{
+		config:    c,
+		grantType: "client_credentials",
+	}
+}
+
+// RefreshToken returns a TokenSource that supplies tokens of the refresh_token
+// grant, starting from refreshToken. Refresh tokens returned by the token
+// endpoint replace it.
+func (c *OAuth2Config) RefreshToken(refreshToken string) *OAuth2TokenSource {
+	return &OAuth2TokenSource{
+		config:       c,
+		grantType:    "refresh_token",
+		refreshToken: refreshToken,
+	}
+}
+
+// OAuth2Error is an error response of an OAuth2 token endpoint.
+type OAuth2Error struct {
+	StatusCode  int
+	Code        string `json:"error"`
+	Description string `json:"error_description"`
+}
+
+func (o *OAuth2Error) Error() string {
+	if o.Description != "" {
+		return fmt.Sprintf("oauth2: %s: %s", o.Code, o.Description)
+	}
+	return fmt.Sprintf("oauth2: %s", o.Code)
+}
+
+// OAuth2TokenSource is a TokenSource that fetches tokens from an OAuth2 token
+// endpoint. It caches the token until it is about to expire, and is safe for
+// concurrent use, with concurrent callers sharing a single fetch. Each caller
+// gives up waiting when its own context is done, without cancelling the fetch
+// for the others, so the Client should have a Timeout.
+type OAuth2TokenSource struct {
+	config    *OAuth2Config
+	grantType string
+
+	mu           sync.Mutex
+	token        *Token
+	refreshToken string
+	fetch        *tokenFetch
+}
+
+type tokenFetch struct {
+	done  chan struct{}
+	token *Token
+	err   error
+}
+
+type oauth2TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// Token returns the cached token, or fetches a new token if it is missing or
+// about to expire.
+func (o *OAuth2TokenSource) Token(ctx context.Context) (*Token, error) {
+	o.mu.Lock()
+	if o.valid(o.token) {
+		token := o.token
+		o.mu.Unlock()
+		return token, nil
+	}
+
+	f := o.fetch
+	if f == nil {
+		f = &tokenFetch{done: make(chan struct{})}
+		o.fetch = f
+		go o.runFetch(detachedContext{ctx}, f, o.refreshToken)
+	}
+	o.mu.Unlock()
+
+	select {
+	case <-f.done:
+		return f.token, f.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// runFetch fetches a token for the callers waiting on f. It runs on a context
+// detached from the caller that started it, which may give up first.
+func (o *OAuth2TokenSource) runFetch(ctx context.Context, f *tokenFetch, refreshToken string) {
+	f.token, refreshToken, f.err = o.fetchToken(ctx, refreshToken)
+
+	o.mu.Lock()
+	if f.err == nil {
+		o.token = f.token
+		o.refreshToken = refreshToken
+	}
+	o.fetch = nil
+	o.mu.Unlock()
+	close(f.done)
+}
+
+// Invalidate drops the cached token if it is the given token, so that the
+// next call to Token fetches a new token.
+func (o *OAuth2TokenSource) Invalidate(token *Token) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.token != nil && token != nil && o.token.Value == token.Value {
+		o.token = nil
+	}
+}
+
+func (o *OAuth2TokenSource) valid(token *Token) bool {
+	if !token.Valid() {
+		return false
+	}
+
+	skew := o.config.ExpirySkew
+	if skew == 0 {
+		skew = DefaultExpirySkew
+	}
+	return token.Expiry.IsZero() || time.Now().Add(skew).Before(token.Expiry)
+}
+
+func (o *OAuth2TokenSource) fetchToken(ctx context.Context, refreshToken string) (*Token, string, error) {
+	c := o.config
+
+	form := url.Values{}
+	form.Set("grant_type", o.grantType)
+	if o.grantType == "refresh_token" {
+		if refreshToken == "" {
+			return nil, "", fmt.Errorf("must provide refresh token")
+		}
+		form.Set("refresh_token", refreshToken)
+	}
+	if len(c.Scopes) > 0 {
+		form.Set("scope", strings.Join(c.Scopes, " "))
+	}
+
+	req, err := NewRequest().
+		WithClient(c.Client).
+		WithMethod(http.MethodPost).
+		AddHeader("Content-Type", "application/x-www-form-urlencoded").
+		AddHeader("Accept", "application/json").
+		FromURLString(c.TokenURL)
+	if err != nil {
+		return nil, "", fmt.Errorf("error parsing token URL: %w", err)
+	}
+	if c.ClientAuthInBody {
+		form.Set("client_id", c.ClientID)
+		if c.ClientSecret != "" {
+			form.Set("client_secret", string(c.ClientSecret))
+		}
+	} else {
+		req.WithAuth(&BasicAuth{
+			Username: url.QueryEscape(c.ClientID),
+			Password: Secret(url.QueryEscape(string(c.ClientSecret))),
+		})
+	}
+
+	var body []byte
+	resp, err := req.WithRequestBody(form).WithResponseBody(&body).DoContext(ctx)
+	if resp != nil {
+		resp.Body.Close()
+	}
+
+	var statusCodeErr *StatusCodeError
+	if errors.As(err, &statusCodeErr) {
+		oauthErr := &OAuth2Error{StatusCode: statusCodeErr.StatusCode}
+		if decodeErr := decode(EncodingJSON, bytes.NewReader(body), oauthErr); decodeErr != nil || oauthErr.Code == "" {
+			return nil, "", fmt.Errorf("error fetching token: %w", err)
+		}
+		return nil, "", oauthErr
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("error fetching token: %w", err)
+	}
+
+	tokenResp := &oauth2TokenResponse{}
+	if err := decode(EncodingJSON, bytes.NewReader(body), tokenResp); err != nil {
+		return nil, "", fmt.Errorf("error decoding token response: %w", err)
+	}
+	if tokenResp.AccessToken == "" {
+		return nil, "", fmt.Errorf("token response has no access token")
+	}
+
+	token := &Token{
+		Type:  tokenResp.TokenType,
+		Value: Secret(tokenResp.AccessToken),
+	}
+	if strings.EqualFold(token.Type, "bearer") {
+		token.Type = "Bearer"
+	}
+	if tokenResp.ExpiresIn > 0 {
+		token.Expiry = time.Now().Add(time.Duration(tokenResp.ExpiresIn) * time.Second)
+	}
+	if tokenResp.RefreshToken != "" {
+		refreshToken = tokenResp.RefreshToken
+	}
+
+	return token, refreshToken, nil
+}
+
+// detachedContext is a context with the values of its parent but never done,
+// for work shared by callers that may each give up.
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (detachedContext) Err() error {
+	return nil
+}
+
+func (o detachedContext) Value(key interface{}) interface{} {
+	return o.parent.Value(key)
+}
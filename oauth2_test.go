@@ -0,0 +1,260 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newMockTokenServer(t *testing.T, expiresIn int) (*httptest.Server, *int32) {
+	var fetches int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Fatal(err)
+		}
+
+		clientID, clientSecret, ok := r.BasicAuth()
+		if !ok {
+			clientID, clientSecret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if clientID != "foo" || clientSecret != "bar" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"error":"invalid_client","error_description":"bad client"}`)
+			return
+		}
+
+		n := atomic.AddInt32(&fetches, 1)
+		switch r.PostForm.Get("grant_type") {
+		case "client_credentials":
+			time.Sleep(10 * time.Millisecond)
+			fmt.Fprintf(w, `{"access_token":"token-%d","token_type":"bearer","expires_in":%d}`, n, expiresIn)
+		case "refresh_token":
+			if r.PostForm.Get("refresh_token") != fmt.Sprintf("refresh-%d", n-1) {
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprint(w, `{"error":"invalid_grant"}`)
+				return
+			}
+			fmt.Fprintf(w, `{"access_token":"token-%d","token_type":"bearer","expires_in":%d,"refresh_token":"refresh-%d"}`, n, expiresIn, n)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error":"unsupported_grant_type"}`)
+		}
+	}))
+	return server, &fetches
+}
+
+func TestOAuth2TokenSource_Token(t *testing.T) {
+	server, fetches := newMockTokenServer(t, 3600)
+	defer server.Close()
+
+	tests := []struct {
+		name    string
+		config  *OAuth2Config
+		want    Secret
+		wantErr error
+	}{
+		{
+			name: "success basic auth",
+			config: &OAuth2Config{
+				TokenURL:     server.URL + "/token",
+				ClientID:     "foo",
+				ClientSecret: "bar",
+			},
+			want: "token-1",
+		},
+		{
+			name: "success auth in body",
+			config: &OAuth2Config{
+				TokenURL:         server.URL + "/token",
+				ClientID:         "foo",
+				ClientSecret:     "bar",
+				ClientAuthInBody: true,
+			},
+			want: "token-2",
+		},
+		{
+			name: "error invalid client",
+			config: &OAuth2Config{
+				TokenURL:     server.URL + "/token",
+				ClientID:     "foo",
+				ClientSecret: "baz",
+			},
+			wantErr: &OAuth2Error{StatusCode: http.StatusUnauthorized, Code: "invalid_client", Description: "bad client"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.config.ClientCredentials().Token(context.Background())
+			if tt.wantErr != nil {
+				var oauthErr *OAuth2Error
+				if !errors.As(err, &oauthErr) || *oauthErr != *tt.wantErr.(*OAuth2Error) {
+					t.Errorf("OAuth2TokenSource.Token() error = %v, wantErr %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("OAuth2TokenSource.Token() error = %v", err)
+			}
+			if got.Value != tt.want || got.Type != "Bearer" {
+				t.Errorf("OAuth2TokenSource.Token() = %q %q, want Bearer %q", got.Type, string(got.Value), string(tt.want))
+			}
+		})
+	}
+
+	if n := atomic.LoadInt32(fetches); n != 2 {
+		t.Errorf("token endpoint fetched %d times, want 2", n)
+	}
+}
+
+func TestOAuth2TokenSource_Token_cache(t *testing.T) {
+	server, fetches := newMockTokenServer(t, 3600)
+	defer server.Close()
+
+	source := (&OAuth2Config{
+		TokenURL:     server.URL + "/token",
+		ClientID:     "foo",
+		ClientSecret: "bar",
+	}).ClientCredentials()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			token, err := source.Token(context.Background())
+			if err != nil || token.Value != "token-1" {
+				t.Errorf("OAuth2TokenSource.Token() = %v, %v", token, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := atomic.LoadInt32(fetches); n != 1 {
+		t.Errorf("token endpoint fetched %d times, want 1", n)
+	}
+}
+
+func TestOAuth2TokenSource_Token_cancel(t *testing.T) {
+	received := make(chan struct{})
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(received)
+		<-release
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"access_token":"token-1","token_type":"bearer","expires_in":3600}`)
+	}))
+	defer server.Close()
+
+	source := (&OAuth2Config{
+		TokenURL:     server.URL + "/token",
+		ClientID:     "foo",
+		ClientSecret: "bar",
+	}).ClientCredentials()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	leader := make(chan error, 1)
+	go func() {
+		_, err := source.Token(ctx)
+		leader <- err
+	}()
+	<-received
+
+	follower := make(chan *Token, 1)
+	go func() {
+		token, err := source.Token(context.Background())
+		if err != nil {
+			t.Errorf("OAuth2TokenSource.Token() error = %v", err)
+		}
+		follower <- token
+	}()
+
+	cancel()
+	if err := <-leader; !errors.Is(err, context.Canceled) {
+		t.Errorf("OAuth2TokenSource.Token() error = %v, want %v", err, context.Canceled)
+	}
+
+	close(release)
+	if token := <-follower; token == nil || token.Value != "token-1" {
+		t.Errorf("OAuth2TokenSource.Token() = %v, want token-1", token)
+	}
+}
+
+func TestOAuth2TokenSource_Token_expiry(t *testing.T) {
+	server, fetches := newMockTokenServer(t, 5)
+	defer server.Close()
+
+	source := (&OAuth2Config{
+		TokenURL:     server.URL + "/token",
+		ClientID:     "foo",
+		ClientSecret: "bar",
+	}).RefreshToken("refresh-0")
+
+	for i, want := range []Secret{"token-1", "token-2"} {
+		token, err := source.Token(context.Background())
+		if err != nil {
+			t.Fatalf("OAuth2TokenSource.Token() error = %v", err)
+		}
+		if token.Value != want {
+			t.Errorf("OAuth2TokenSource.Token() %d = %q, want %q", i, string(token.Value), string(want))
+		}
+	}
+
+	if n := atomic.LoadInt32(fetches); n != 2 {
+		t.Errorf("token endpoint fetched %d times, want 2", n)
+	}
+}
+
+func TestRequest_Do_oauth2Retry(t *testing.T) {
+	tokenServer, fetches := newMockTokenServer(t, 3600)
+	defer tokenServer.Close()
+
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if r.Header.Get("Authorization") != "Bearer token-2" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, "ok")
+	}))
+	defer server.Close()
+
+	source := (&OAuth2Config{
+		TokenURL:     tokenServer.URL + "/token",
+		ClientID:     "foo",
+		ClientSecret: "bar",
+	}).ClientCredentials()
+
+	var body []byte
+	o, err := NewRequest().WithMethod(http.MethodPost).WithTokenSource(source).WithRequestBody([]byte("hello")).WithResponseBody(&body).FromURLString(server.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := o.Do(); err != nil {
+		t.Fatalf("Request.Do() error = %v", err)
+	}
+	if string(body) != "ok" {
+		t.Errorf("Request.Do() body = %q, want %q", body, "ok")
+	}
+	if n := atomic.LoadInt32(&calls); n != 2 {
+		t.Errorf("server called %d times, want 2", n)
+	}
+
+	// A second rejection is returned rather than retried again.
+	source.Invalidate(&Token{Value: "token-2"})
+	_, err = o.Do()
+	var statusCodeErr *StatusCodeError
+	if !errors.As(err, &statusCodeErr) || statusCodeErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Request.Do() error = %v, want 401", err)
+	}
+	if n := atomic.LoadInt32(fetches); n != 4 {
+		t.Errorf("token endpoint fetched %d times, want 4", n)
+	}
+}
@@ -0,0 +1,191 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const (
+	defaultPollInterval    = time.Second
+	defaultPollMaxInterval = 30 * time.Second
+)
+
+// PollOptions are options to use when polling a long-running operation.
+type PollOptions struct {
+	// Done reports whether a response of the status URL is in a terminal
+	// state, returning an error if the operation failed. It sees every
+	// response, including those with a status code above 2xx, which fail with
+	// a *StatusCodeError once Done reports them as terminal. If nil, the
+	// operation is done once the status URL responds with another status code
+	// than 202.
+	Done func(resp *http.Response, body []byte) (bool, error)
+
+	// Interval is the delay before the first poll, doubling up to MaxInterval
+	// for each subsequent poll unless the response has a "Retry-After", which
+	// is also capped at MaxInterval. They default to 1 and 30 seconds.
+	Interval    time.Duration
+	MaxInterval time.Duration
+
+	// Progress, if set, is called after each poll with its number and
+	// response, whatever its status code.
+	Progress func(poll int, resp *http.Response, body []byte)
+
+	// FetchResult fetches the result of the operation into the response body
+	// of the Request once it is done, from the "Location" of the last response
+	// of the status URL, the "Location" of the 202 response if it was polled
+	// with its "Operation-Location", or else the URL of the Request. If not
+	// set, the last response of the status URL is decoded into it instead.
+	FetchResult bool
+
+	// Options are the options of each request.
+	Options []*DoOptions
+}
+
+// DoAndPoll makes the HTTP request, and if it is accepted with a 202 response,
+// polls the status URL of its "Operation-Location" or "Location" until the
+// operation is done. It returns the last response, with its body read.
+//
+// The status URL is resolved relative to the URL of the Request, and is
+// requested with the client, authenticator, and header of the Request. The
+// "Location" of a response of the status URL is resolved relative to the
+// status URL.
+func (o *Request) DoAndPoll(ctx context.Context, options *PollOptions) (*http.Response, error) {
+	if options == nil {
+		options = &PollOptions{}
+	}
+	opts := joinOptions(options.Options...)
+
+	var body []byte
+	resp, err := o.Clone().WithResponseBody(&body).DoContext(ctx, options.Options...)
+	if resp != nil {
+		resp.Body.Close()
+	}
+	if err != nil {
+		return resp, err
+	}
+	if resp.StatusCode != http.StatusAccepted {
+		return resp, o.decodeResponseBody(opts, resp, bytes.NewReader(body))
+	}
+
+	base, err := o.URL()
+	if err != nil {
+		return resp, fmt.Errorf("error building URL: %w", err)
+	}
+	accepted := resp
+	statusURL, err := operationURL(base, resp, "Operation-Location", "Location")
+	if err != nil {
+		return resp, err
+	}
+	if statusURL == nil {
+		return resp, fmt.Errorf("accepted response has no Operation-Location or Location")
+	}
+
+	interval := options.Interval
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+	maxInterval := options.MaxInterval
+	if maxInterval <= 0 {
+		maxInterval = defaultPollMaxInterval
+	}
+
+	delay := interval
+	for poll := 1; ; poll++ {
+		if until, ok := retryAfter(resp.Header, time.Now()); ok {
+			if delay = time.Until(until); delay > maxInterval {
+				delay = maxInterval
+			}
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return resp, ctx.Err()
+		}
+		if interval *= 2; interval > maxInterval {
+			interval = maxInterval
+		}
+		delay = interval
+
+		resp, err = o.pollRequest(statusURL).WithResponseBody(&body).DoContext(ctx, options.Options...)
+		if resp != nil {
+			resp.Body.Close()
+		}
+		var statusCodeErr *StatusCodeError
+		if err != nil && !errors.As(err, &statusCodeErr) {
+			return resp, fmt.Errorf("error polling operation: %w", err)
+		}
+		if options.Progress != nil {
+			options.Progress(poll, resp, body)
+		}
+
+		done := resp.StatusCode != http.StatusAccepted
+		if options.Done != nil {
+			var doneErr error
+			if done, doneErr = options.Done(resp, body); doneErr != nil {
+				return resp, doneErr
+			}
+		}
+		if done && err != nil {
+			return resp, fmt.Errorf("error polling operation: %w", err)
+		}
+		if done {
+			break
+		}
+	}
+
+	if !options.FetchResult {
+		return resp, o.decodeResponseBody(opts, resp, bytes.NewReader(body))
+	}
+
+	resultURL, err := operationURL(statusURL, resp, "Location")
+	if err != nil {
+		return resp, err
+	}
+	if resultURL == nil && accepted.Header.Get("Operation-Location") != "" {
+		if resultURL, err = operationURL(base, accepted, "Location"); err != nil {
+			return resp, err
+		}
+	}
+	result := o.Clone().WithMethod(http.MethodGet).WithRequestBody(nil)
+	if resultURL != nil {
+		result = o.pollRequest(resultURL).WithResponseBody(o.ResponseBody)
+	}
+	resp, err = result.DoContext(ctx, options.Options...)
+	if resp != nil {
+		resp.Body.Close()
+	}
+	if err != nil {
+		return resp, fmt.Errorf("error fetching operation result: %w", err)
+	}
+	return resp, nil
+}
+
+// pollRequest returns a GET request of the Request for the URL.
+func (o *Request) pollRequest(u *url.URL) *Request {
+	return o.Clone().WithMethod(http.MethodGet).WithRequestBody(nil).WithPath("/").WithQuery(url.Values{}).FromURL(u)
+}
+
+// operationURL returns the first of the headers of the response as a URL
+// resolved relative to base.
+func operationURL(base *url.URL, resp *http.Response, names ...string) (*url.URL, error) {
+	for _, name := range names {
+		value := resp.Header.Get(name)
+		if value == "" {
+			continue
+		}
+
+		u, err := url.Parse(value)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing %s: %w", name, err)
+		}
+		return base.ResolveReference(u), nil
+	}
+	return nil, nil
+}
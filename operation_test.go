@@ -0,0 +1,212 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRequest_DoAndPoll(t *testing.T) {
+	type status struct {
+		Status string `json:"status"`
+	}
+	type result struct {
+		ID     string `json:"id"`
+		Status string `json:"status"`
+	}
+	jsonDone := func(resp *http.Response, body []byte) (bool, error) {
+		var s status
+		if err := json.Unmarshal(body, &s); err != nil {
+			return false, err
+		}
+		if s.Status == "failed" {
+			return false, errors.New("operation failed")
+		}
+		return s.Status == "succeeded", nil
+	}
+
+	tests := []struct {
+		name         string
+		path         string
+		options      *PollOptions
+		want         result
+		wantProgress []int
+		wantErr      bool
+	}{
+		{
+			name:         "success fetch result",
+			path:         "/jobs",
+			options:      &PollOptions{Interval: time.Millisecond, FetchResult: true},
+			want:         result{ID: "1"},
+			wantProgress: []int{1, 2, 3},
+		},
+		{
+			name:         "success status body",
+			path:         "/jobs",
+			options:      &PollOptions{Interval: time.Millisecond},
+			want:         result{Status: "succeeded"},
+			wantProgress: []int{1, 2, 3},
+		},
+		{
+			name:         "success custom done",
+			path:         "/operations",
+			options:      &PollOptions{Interval: time.Millisecond, Done: jsonDone, FetchResult: true},
+			want:         result{ID: "1"},
+			wantProgress: []int{1, 2},
+		},
+		{
+			name:         "success redirected with long retry after",
+			path:         "/jobs-v1",
+			options:      &PollOptions{Interval: time.Millisecond, MaxInterval: time.Millisecond, FetchResult: true},
+			want:         result{ID: "1"},
+			wantProgress: []int{1, 2, 3},
+		},
+		{
+			name:         "success done after error status",
+			path:         "/operations/flaky",
+			options:      &PollOptions{Interval: time.Millisecond, Done: jsonDone},
+			want:         result{Status: "succeeded"},
+			wantProgress: []int{1, 2},
+		},
+		{
+			name:    "success not accepted",
+			path:    "/results/1",
+			options: &PollOptions{Interval: time.Millisecond},
+			want:    result{ID: "1"},
+		},
+		{
+			name:         "error operation failed status",
+			path:         "/operations/gone",
+			options:      &PollOptions{Interval: time.Millisecond, Done: jsonDone},
+			wantProgress: []int{1},
+			wantErr:      true,
+		},
+		{
+			name:         "error operation failed",
+			path:         "/operations/failing",
+			options:      &PollOptions{Interval: time.Millisecond, Done: jsonDone},
+			wantProgress: []int{1},
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			polls := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				defer mu.Unlock()
+
+				switch r.URL.Path {
+				case "/jobs":
+					w.Header().Set("Location", "jobs/1/status")
+					w.Header().Set("Retry-After", "0")
+					w.WriteHeader(http.StatusAccepted)
+				case "/jobs/1/status":
+					polls++
+					if polls < 3 {
+						w.WriteHeader(http.StatusAccepted)
+						fmt.Fprint(w, `{"status":"running"}`)
+						return
+					}
+					w.Header().Set("Location", "/results/1")
+					fmt.Fprint(w, `{"status":"succeeded"}`)
+				case "/operations":
+					w.Header().Set("Operation-Location", "/operations/1")
+					w.Header().Set("Location", "/results/1")
+					w.WriteHeader(http.StatusAccepted)
+				case "/operations/1":
+					polls++
+					if polls < 2 {
+						fmt.Fprint(w, `{"status":"running"}`)
+						return
+					}
+					fmt.Fprint(w, `{"status":"succeeded"}`)
+				case "/jobs-v1":
+					http.Redirect(w, r, "/v2/jobs", http.StatusTemporaryRedirect)
+				case "/v2/jobs":
+					// The status URL is relative to the Request, not the
+					// redirect.
+					w.Header().Set("Location", "jobs/1/status")
+					w.Header().Set("Retry-After", "3600")
+					w.WriteHeader(http.StatusAccepted)
+				case "/operations/flaky":
+					w.Header().Set("Operation-Location", "/operations/3")
+					w.WriteHeader(http.StatusAccepted)
+				case "/operations/3":
+					polls++
+					if polls < 2 {
+						w.WriteHeader(http.StatusServiceUnavailable)
+						fmt.Fprint(w, `{"status":"running"}`)
+						return
+					}
+					fmt.Fprint(w, `{"status":"succeeded"}`)
+				case "/operations/gone":
+					w.Header().Set("Operation-Location", "/operations/4")
+					w.WriteHeader(http.StatusAccepted)
+				case "/operations/4":
+					w.WriteHeader(http.StatusGone)
+					fmt.Fprint(w, `{"status":"failed"}`)
+				case "/operations/failing":
+					w.Header().Set("Operation-Location", "/operations/2")
+					w.WriteHeader(http.StatusAccepted)
+				case "/operations/2":
+					fmt.Fprint(w, `{"status":"failed"}`)
+				case "/results/1":
+					fmt.Fprint(w, `{"id":"1"}`)
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			defer server.Close()
+
+			var got result
+			o, err := NewRequest().WithMethod(http.MethodPost).AddHeader("Accept", "application/json").WithResponseBody(&got).FromURLString(server.URL + tt.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var progress []int
+			tt.options.Progress = func(poll int, resp *http.Response, body []byte) {
+				progress = append(progress, poll)
+			}
+			_, err = o.DoAndPoll(context.Background(), tt.options)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Request.DoAndPoll() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Request.DoAndPoll() response body = %+v, want %+v", got, tt.want)
+			}
+			if !reflect.DeepEqual(progress, tt.wantProgress) {
+				t.Errorf("PollOptions.Progress() = %v, want %v", progress, tt.wantProgress)
+			}
+		})
+	}
+}
+
+func TestRequest_DoAndPoll_cancel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Location", "/status")
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	o, err := NewRequest().WithMethod(http.MethodPost).FromURLString(server.URL + "/jobs")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = o.DoAndPoll(ctx, &PollOptions{Interval: time.Millisecond, MaxInterval: 5 * time.Millisecond})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Request.DoAndPoll() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
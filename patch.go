@@ -0,0 +1,492 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Content types of patch documents.
+const (
+	ContentTypeJSONPatch  = "application/json-patch+json"
+	ContentTypeMergePatch = "application/merge-patch+json"
+)
+
+// JSON Patch operations.
+const (
+	JSONPatchAdd     = "add"
+	JSONPatchRemove  = "remove"
+	JSONPatchReplace = "replace"
+	JSONPatchMove    = "move"
+	JSONPatchCopy    = "copy"
+	JSONPatchTest    = "test"
+)
+
+// JSONPatchOperation is an operation of a JSON Patch.
+type JSONPatchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	From  string      `json:"from,omitempty"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// MarshalJSON encodes the operation, with its value if the operation has one
+// even if it is nil.
+func (o JSONPatchOperation) MarshalJSON() ([]byte, error) {
+	type operation JSONPatchOperation
+	switch o.Op {
+	case JSONPatchAdd, JSONPatchReplace, JSONPatchTest:
+		return json.Marshal(struct {
+			operation
+			Value interface{} `json:"value"`
+		}{operation(o), o.Value})
+	default:
+		return json.Marshal(struct {
+			operation
+			Value interface{} `json:"value,omitempty"`
+		}{operation: operation(o)})
+	}
+}
+
+// JSONPatch is a JSON Patch document per RFC 6902.
+type JSONPatch []JSONPatchOperation
+
+// MergePatch is a JSON Merge Patch document per RFC 7396, where nil values
+// remove members.
+type MergePatch map[string]interface{}
+
+// DiffJSONPatch returns a JSON Patch that changes the JSON encoding of from to
+// that of to.
+func DiffJSONPatch(from, to interface{}) (JSONPatch, error) {
+	a, err := jsonValue(from)
+	if err != nil {
+		return nil, err
+	}
+	b, err := jsonValue(to)
+	if err != nil {
+		return nil, err
+	}
+
+	patch := JSONPatch{}
+	diffJSONPatch(&patch, "", a, b)
+	return patch, nil
+}
+
+func diffJSONPatch(patch *JSONPatch, path string, a, b interface{}) {
+	if reflect.DeepEqual(a, b) {
+		return
+	}
+
+	switch a := a.(type) {
+	case map[string]interface{}:
+		b, ok := b.(map[string]interface{})
+		if !ok {
+			break
+		}
+		for _, key := range sortedKeys(a) {
+			if _, ok := b[key]; !ok {
+				*patch = append(*patch, JSONPatchOperation{Op: JSONPatchRemove, Path: path + "/" + escapePointer(key)})
+			}
+		}
+		for _, key := range sortedKeys(b) {
+			if _, ok := a[key]; !ok {
+				*patch = append(*patch, JSONPatchOperation{Op: JSONPatchAdd, Path: path + "/" + escapePointer(key), Value: b[key]})
+			} else {
+				diffJSONPatch(patch, path+"/"+escapePointer(key), a[key], b[key])
+			}
+		}
+		return
+	case []interface{}:
+		b, ok := b.([]interface{})
+		if !ok {
+			break
+		}
+		for i := 0; i < len(a) && i < len(b); i++ {
+			diffJSONPatch(patch, path+"/"+strconv.Itoa(i), a[i], b[i])
+		}
+		for i := len(a) - 1; i >= len(b); i-- {
+			*patch = append(*patch, JSONPatchOperation{Op: JSONPatchRemove, Path: path + "/" + strconv.Itoa(i)})
+		}
+		for i := len(a); i < len(b); i++ {
+			*patch = append(*patch, JSONPatchOperation{Op: JSONPatchAdd, Path: path + "/" + strconv.Itoa(i), Value: b[i]})
+		}
+		return
+	}
+
+	*patch = append(*patch, JSONPatchOperation{Op: JSONPatchReplace, Path: path, Value: b})
+}
+
+// DiffMergePatch returns a JSON Merge Patch that changes the JSON encoding of
+// from to that of to, which must both be JSON objects.
+//
+// Merge patches cannot set members to null, so null members of to are
+// removed.
+func DiffMergePatch(from, to interface{}) (MergePatch, error) {
+	a, err := jsonValue(from)
+	if err != nil {
+		return nil, err
+	}
+	b, err := jsonValue(to)
+	if err != nil {
+		return nil, err
+	}
+
+	aObject, ok := a.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("must provide JSON object, got %T", from)
+	}
+	bObject, ok := b.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("must provide JSON object, got %T", to)
+	}
+
+	return diffMergePatch(aObject, bObject), nil
+}
+
+func diffMergePatch(a, b map[string]interface{}) MergePatch {
+	patch := MergePatch{}
+	for key := range a {
+		if _, ok := b[key]; !ok {
+			patch[key] = nil
+		}
+	}
+	for key, bValue := range b {
+		aValue, ok := a[key]
+		switch {
+		case !ok:
+			patch[key] = bValue
+		case reflect.DeepEqual(aValue, bValue):
+		default:
+			aObject, aOK := aValue.(map[string]interface{})
+			bObject, bOK := bValue.(map[string]interface{})
+			if aOK && bOK {
+				patch[key] = map[string]interface{}(diffMergePatch(aObject, bObject))
+			} else {
+				patch[key] = bValue
+			}
+		}
+	}
+	return patch
+}
+
+// Apply applies the patch to the JSON document.
+func (o JSONPatch) Apply(doc []byte) ([]byte, error) {
+	v, err := decodeJSONValue(doc)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding document: %w", err)
+	}
+	// Encode the patch to normalize its values.
+	ops, err := jsonValue(o)
+	if err != nil {
+		return nil, err
+	}
+
+	opList, _ := ops.([]interface{})
+	for i, op := range opList {
+		op, _ := op.(map[string]interface{})
+		if v, err = applyJSONPatchOperation(v, op); err != nil {
+			return nil, fmt.Errorf("error applying operation %d: %w", i, err)
+		}
+	}
+
+	return json.Marshal(v)
+}
+
+func applyJSONPatchOperation(doc interface{}, op map[string]interface{}) (interface{}, error) {
+	opName, _ := op["op"].(string)
+	path, ok := op["path"].(string)
+	if !ok {
+		return nil, fmt.Errorf("must provide path")
+	}
+	tokens, err := parsePointer(path)
+	if err != nil {
+		return nil, err
+	}
+	value, hasValue := op["value"]
+
+	switch opName {
+	case JSONPatchAdd:
+		if !hasValue {
+			return nil, fmt.Errorf("must provide value")
+		}
+		return pointerAdd(doc, tokens, value)
+	case JSONPatchRemove:
+		doc, _, err = pointerRemove(doc, tokens)
+		return doc, err
+	case JSONPatchReplace:
+		if !hasValue {
+			return nil, fmt.Errorf("must provide value")
+		}
+		if doc, _, err = pointerRemove(doc, tokens); err != nil {
+			return nil, err
+		}
+		return pointerAdd(doc, tokens, value)
+	case JSONPatchMove, JSONPatchCopy:
+		from, ok := op["from"].(string)
+		if !ok {
+			return nil, fmt.Errorf("must provide from")
+		}
+		fromTokens, err := parsePointer(from)
+		if err != nil {
+			return nil, err
+		}
+		if opName == JSONPatchCopy {
+			value, err := pointerGet(doc, fromTokens)
+			if err != nil {
+				return nil, err
+			}
+			copied, err := jsonValue(value)
+			if err != nil {
+				return nil, err
+			}
+			return pointerAdd(doc, tokens, copied)
+		}
+		if strings.HasPrefix(path, from+"/") {
+			return nil, fmt.Errorf("cannot move %q into itself", from)
+		}
+		doc, value, err := pointerRemove(doc, fromTokens)
+		if err != nil {
+			return nil, err
+		}
+		return pointerAdd(doc, tokens, value)
+	case JSONPatchTest:
+		got, err := pointerGet(doc, tokens)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(got, value) {
+			return nil, fmt.Errorf("test failed at %q", path)
+		}
+		return doc, nil
+	default:
+		return nil, fmt.Errorf("unsupported operation %q", opName)
+	}
+}
+
+// Apply applies the patch to the JSON document.
+func (o MergePatch) Apply(doc []byte) ([]byte, error) {
+	v, err := decodeJSONValue(doc)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding document: %w", err)
+	}
+	patch, err := jsonValue(o)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(applyMergePatch(v, patch))
+}
+
+func applyMergePatch(target, patch interface{}) interface{} {
+	p, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	t, ok := target.(map[string]interface{})
+	if !ok {
+		t = map[string]interface{}{}
+	}
+	for key, value := range p {
+		if value == nil {
+			delete(t, key)
+		} else {
+			t[key] = applyMergePatch(t[key], value)
+		}
+	}
+	return t
+}
+
+// parsePointer returns the reference tokens of a JSON Pointer per RFC 6901.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid JSON pointer %q", pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+	}
+	return tokens, nil
+}
+
+func escapePointer(token string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(token)
+}
+
+func pointerGet(doc interface{}, tokens []string) (interface{}, error) {
+	for _, token := range tokens {
+		switch v := doc.(type) {
+		case map[string]interface{}:
+			value, ok := v[token]
+			if !ok {
+				return nil, fmt.Errorf("member %q not found", token)
+			}
+			doc = value
+		case []interface{}:
+			i, err := arrayIndex(token, len(v)-1)
+			if err != nil {
+				return nil, err
+			}
+			doc = v[i]
+		default:
+			return nil, fmt.Errorf("cannot get %q of %T", token, doc)
+		}
+	}
+	return doc, nil
+}
+
+// pointerAdd returns the document with the value added at the tokens.
+func pointerAdd(doc interface{}, tokens []string, value interface{}) (interface{}, error) {
+	if len(tokens) == 0 {
+		return value, nil
+	}
+
+	token := tokens[0]
+	switch v := doc.(type) {
+	case map[string]interface{}:
+		if len(tokens) == 1 {
+			v[token] = value
+			return v, nil
+		}
+		child, ok := v[token]
+		if !ok {
+			return nil, fmt.Errorf("member %q not found", token)
+		}
+		child, err := pointerAdd(child, tokens[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		v[token] = child
+		return v, nil
+	case []interface{}:
+		if len(tokens) == 1 {
+			i := len(v)
+			if token != "-" {
+				var err error
+				if i, err = arrayIndex(token, len(v)); err != nil {
+					return nil, err
+				}
+			}
+			v = append(v, nil)
+			copy(v[i+1:], v[i:])
+			v[i] = value
+			return v, nil
+		}
+		i, err := arrayIndex(token, len(v)-1)
+		if err != nil {
+			return nil, err
+		}
+		if v[i], err = pointerAdd(v[i], tokens[1:], value); err != nil {
+			return nil, err
+		}
+		return v, nil
+	default:
+		return nil, fmt.Errorf("cannot add %q to %T", token, doc)
+	}
+}
+
+// pointerRemove returns the document with the value at the tokens removed,
+// and the removed value.
+func pointerRemove(doc interface{}, tokens []string) (interface{}, interface{}, error) {
+	if len(tokens) == 0 {
+		return nil, doc, nil
+	}
+
+	token := tokens[0]
+	switch v := doc.(type) {
+	case map[string]interface{}:
+		child, ok := v[token]
+		if !ok {
+			return nil, nil, fmt.Errorf("member %q not found", token)
+		}
+		if len(tokens) == 1 {
+			delete(v, token)
+			return v, child, nil
+		}
+		child, removed, err := pointerRemove(child, tokens[1:])
+		if err != nil {
+			return nil, nil, err
+		}
+		v[token] = child
+		return v, removed, nil
+	case []interface{}:
+		i, err := arrayIndex(token, len(v)-1)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(tokens) == 1 {
+			removed := v[i]
+			return append(v[:i], v[i+1:]...), removed, nil
+		}
+		child, removed, err := pointerRemove(v[i], tokens[1:])
+		if err != nil {
+			return nil, nil, err
+		}
+		v[i] = child
+		return v, removed, nil
+	default:
+		return nil, nil, fmt.Errorf("cannot remove %q from %T", token, doc)
+	}
+}
+
+func arrayIndex(token string, max int) (int, error) {
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || i > max || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	return i, nil
+}
+
+// jsonValue returns the generic JSON value of the JSON encoding of v.
+func jsonValue(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding value: %w", err)
+	}
+	return decodeJSONValue(data)
+}
+
+func decodeJSONValue(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var v interface{}
+	if err := decoder.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// WithJSONPatch sets the request body of the Request to the JSON Patch, with
+// its "Content-Type".
+func (o *Request) WithJSONPatch(patch JSONPatch) *Request {
+	o.ensureHeader()
+
+	o.Header.Set("Content-Type", ContentTypeJSONPatch)
+	return o.WithRequestBody(patch)
+}
+
+// WithMergePatch sets the request body of the Request to the JSON Merge
+// Patch, with its "Content-Type".
+func (o *Request) WithMergePatch(patch MergePatch) *Request {
+	o.ensureHeader()
+
+	o.Header.Set("Content-Type", ContentTypeMergePatch)
+	return o.WithRequestBody(patch)
+}
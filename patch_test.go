@@ -0,0 +1,327 @@
+package http
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func assertJSONEqual(t *testing.T, name string, got []byte, want string) {
+	t.Helper()
+
+	var gotValue, wantValue interface{}
+	if err := json.Unmarshal(got, &gotValue); err != nil {
+		t.Fatalf("%s = %s, error = %v", name, got, err)
+	}
+	if err := json.Unmarshal([]byte(want), &wantValue); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(gotValue, wantValue) {
+		t.Errorf("%s = %s, want %s", name, got, want)
+	}
+}
+
+func TestJSONPatch_Apply(t *testing.T) {
+	// Examples from RFC 6902 Appendix A.
+	tests := []struct {
+		name    string
+		doc     string
+		patch   string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  "success add object member",
+			doc:   `{"foo":"bar"}`,
+			patch: `[{"op":"add","path":"/baz","value":"qux"}]`,
+			want:  `{"baz":"qux","foo":"bar"}`,
+		},
+		{
+			name:  "success add array element",
+			doc:   `{"foo":["bar","baz"]}`,
+			patch: `[{"op":"add","path":"/foo/1","value":"qux"}]`,
+			want:  `{"foo":["bar","qux","baz"]}`,
+		},
+		{
+			name:  "success remove object member",
+			doc:   `{"baz":"qux","foo":"bar"}`,
+			patch: `[{"op":"remove","path":"/baz"}]`,
+			want:  `{"foo":"bar"}`,
+		},
+		{
+			name:  "success remove array element",
+			doc:   `{"foo":["bar","qux","baz"]}`,
+			patch: `[{"op":"remove","path":"/foo/1"}]`,
+			want:  `{"foo":["bar","baz"]}`,
+		},
+		{
+			name:  "success replace",
+			doc:   `{"baz":"qux","foo":"bar"}`,
+			patch: `[{"op":"replace","path":"/baz","value":"boo"}]`,
+			want:  `{"baz":"boo","foo":"bar"}`,
+		},
+		{
+			name:  "success move value",
+			doc:   `{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`,
+			patch: `[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`,
+			want:  `{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`,
+		},
+		{
+			name:  "success move array element",
+			doc:   `{"foo":["all","grass","cows","eat"]}`,
+			patch: `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`,
+			want:  `{"foo":["all","cows","eat","grass"]}`,
+		},
+		{
+			name:  "success copy",
+			doc:   `{"foo":{"bar":1}}`,
+			patch: `[{"op":"copy","from":"/foo","path":"/baz"},{"op":"replace","path":"/baz/bar","value":2}]`,
+			want:  `{"foo":{"bar":1},"baz":{"bar":2}}`,
+		},
+		{
+			name:  "success test",
+			doc:   `{"baz":"qux","foo":["a",2,"c"]}`,
+			patch: `[{"op":"test","path":"/baz","value":"qux"},{"op":"test","path":"/foo/1","value":2}]`,
+			want:  `{"baz":"qux","foo":["a",2,"c"]}`,
+		},
+		{
+			name:  "success add nested member",
+			doc:   `{"foo":"bar"}`,
+			patch: `[{"op":"add","path":"/child","value":{"grandchild":{}}}]`,
+			want:  `{"foo":"bar","child":{"grandchild":{}}}`,
+		},
+		{
+			name:  "success escaped pointer",
+			doc:   `{"/":9,"~1":10}`,
+			patch: `[{"op":"test","path":"/~01","value":10},{"op":"remove","path":"/~1"}]`,
+			want:  `{"~1":10}`,
+		},
+		{
+			name:  "success append to array",
+			doc:   `{"foo":["bar"]}`,
+			patch: `[{"op":"add","path":"/foo/-","value":["abc","def"]}]`,
+			want:  `{"foo":["bar",["abc","def"]]}`,
+		},
+		{
+			name:    "error test failed",
+			doc:     `{"baz":"qux"}`,
+			patch:   `[{"op":"test","path":"/baz","value":"bar"}]`,
+			wantErr: true,
+		},
+		{
+			name:    "error add to nonexistent target",
+			doc:     `{"foo":"bar"}`,
+			patch:   `[{"op":"add","path":"/baz/bat","value":"qux"}]`,
+			wantErr: true,
+		},
+		{
+			name:    "error array index out of bounds",
+			doc:     `{"foo":["bar"]}`,
+			patch:   `[{"op":"add","path":"/foo/2","value":"qux"}]`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var patch JSONPatch
+			if err := json.Unmarshal([]byte(tt.patch), &patch); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := patch.Apply([]byte(tt.doc))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("JSONPatch.Apply() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr {
+				assertJSONEqual(t, "JSONPatch.Apply()", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONPatchOperation_MarshalJSON(t *testing.T) {
+	patch := JSONPatch{
+		{Op: JSONPatchAdd, Path: "/a", Value: nil},
+		{Op: JSONPatchRemove, Path: "/b"},
+		{Op: JSONPatchMove, From: "/c", Path: "/d"},
+	}
+	got, err := json.Marshal(patch)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	want := `[{"op":"add","path":"/a","value":null},{"op":"remove","path":"/b"},{"op":"move","path":"/d","from":"/c"}]`
+	if string(got) != want {
+		t.Errorf("json.Marshal() = %s, want %s", got, want)
+	}
+}
+
+func TestDiffJSONPatch(t *testing.T) {
+	type item struct {
+		Name  string            `json:"name"`
+		Tags  []string          `json:"tags"`
+		Attrs map[string]string `json:"attrs,omitempty"`
+	}
+	tests := []struct {
+		name string
+		from interface{}
+		to   interface{}
+		want string
+	}{
+		{
+			name: "success equal",
+			from: item{Name: "a"},
+			to:   item{Name: "a"},
+			want: `[]`,
+		},
+		{
+			name: "success struct",
+			from: item{Name: "a", Tags: []string{"x", "y", "z"}, Attrs: map[string]string{"a/b": "1", "c": "2"}},
+			to:   item{Name: "b", Tags: []string{"x", "w"}, Attrs: map[string]string{"c": "2", "d": "3"}},
+			want: `[
+				{"op":"remove","path":"/attrs/a~1b"},
+				{"op":"add","path":"/attrs/d","value":"3"},
+				{"op":"replace","path":"/name","value":"b"},
+				{"op":"replace","path":"/tags/1","value":"w"},
+				{"op":"remove","path":"/tags/2"}
+			]`,
+		},
+		{
+			name: "success array grows",
+			from: []int{1},
+			to:   []int{1, 2, 3},
+			want: `[{"op":"add","path":"/1","value":2},{"op":"add","path":"/2","value":3}]`,
+		},
+		{
+			name: "success root replaced",
+			from: "a",
+			to:   map[string]int{"b": 1},
+			want: `[{"op":"replace","path":"","value":{"b":1}}]`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DiffJSONPatch(tt.from, tt.to)
+			if err != nil {
+				t.Fatalf("DiffJSONPatch() error = %v", err)
+			}
+			data, err := json.Marshal(got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertJSONEqual(t, "DiffJSONPatch()", data, tt.want)
+
+			from, _ := json.Marshal(tt.from)
+			to, _ := json.Marshal(tt.to)
+			applied, err := got.Apply(from)
+			if err != nil {
+				t.Fatalf("JSONPatch.Apply() error = %v", err)
+			}
+			assertJSONEqual(t, "JSONPatch.Apply()", applied, string(to))
+		})
+	}
+}
+
+func TestMergePatch_Apply(t *testing.T) {
+	// Examples from RFC 7396 Appendix A.
+	tests := []struct {
+		doc   string
+		patch string
+		want  string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.patch, func(t *testing.T) {
+			var patch MergePatch
+			if err := json.Unmarshal([]byte(tt.patch), &patch); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := patch.Apply([]byte(tt.doc))
+			if err != nil {
+				t.Fatalf("MergePatch.Apply() error = %v", err)
+			}
+			assertJSONEqual(t, "MergePatch.Apply()", got, tt.want)
+		})
+	}
+}
+
+func TestDiffMergePatch(t *testing.T) {
+	from := map[string]interface{}{
+		"title":   "Goodbye!",
+		"author":  map[string]interface{}{"givenName": "John", "familyName": "Doe"},
+		"tags":    []string{"example", "sample"},
+		"content": "This will be unchanged",
+	}
+	to := map[string]interface{}{
+		"title":       "Hello!",
+		"author":      map[string]interface{}{"givenName": "John"},
+		"tags":        []string{"example"},
+		"content":     "This will be unchanged",
+		"phoneNumber": "+01-123-456-7890",
+	}
+
+	got, err := DiffMergePatch(from, to)
+	if err != nil {
+		t.Fatalf("DiffMergePatch() error = %v", err)
+	}
+	data, err := json.Marshal(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertJSONEqual(t, "DiffMergePatch()", data, `{
+		"title": "Hello!",
+		"phoneNumber": "+01-123-456-7890",
+		"author": {"familyName": null},
+		"tags": ["example"]
+	}`)
+
+	if _, err := DiffMergePatch([]int{1}, to); err == nil {
+		t.Errorf("DiffMergePatch() error = nil, want error for non-object")
+	}
+}
+
+func TestRequest_WithJSONPatch(t *testing.T) {
+	var contentTypes []string
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		contentTypes = append(contentTypes, r.Header.Get("Content-Type"))
+		bodies = append(bodies, string(body))
+	}))
+	defer server.Close()
+
+	base, err := NewRequest().WithMethod(http.MethodPatch).FromURLString(server.URL + "/items/a")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := base.Clone().WithJSONPatch(JSONPatch{{Op: JSONPatchReplace, Path: "/name", Value: "b"}}).Do(); err != nil {
+		t.Fatalf("Request.Do() error = %v", err)
+	}
+	if _, err := base.Clone().WithMergePatch(MergePatch{"name": "b", "old": nil}).Do(); err != nil {
+		t.Fatalf("Request.Do() error = %v", err)
+	}
+
+	wantContentTypes := []string{ContentTypeJSONPatch, ContentTypeMergePatch}
+	if !reflect.DeepEqual(contentTypes, wantContentTypes) {
+		t.Errorf("server got Content-Type %v, want %v", contentTypes, wantContentTypes)
+	}
+	wantBodies := []string{`[{"op":"replace","path":"/name","value":"b"}]`, `{"name":"b","old":null}`}
+	if !reflect.DeepEqual(bodies, wantBodies) {
+		t.Errorf("server got bodies %v, want %v", bodies, wantBodies)
+	}
+}
@@ -0,0 +1,285 @@
+package http
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RateLimiter is a token bucket rate limiter of requests, with a bucket for
+// each key, which is the host of the request by default. It is safe for
+// concurrent use.
+//
+// If Adaptive is set, the limiter also follows the "RateLimit-*" and
+// "X-RateLimit-*" headers of responses, and the "Retry-After" header of 429
+// responses, holding requests until the server's limit resets.
+type RateLimiter struct {
+	// Rate is the number of requests per second, or unlimited if zero.
+	Rate float64
+
+	// Burst is the number of requests that can be made at once, at least 1.
+	Burst int
+
+	// Key returns the key of the request's bucket, its host if nil.
+	Key func(req *http.Request) string
+
+	Adaptive bool
+
+	// Now returns the current time, time.Now if nil.
+	Now func() time.Time
+
+	mu      sync.Mutex
+	buckets map[string]*rateBucket
+}
+
+type rateBucket struct {
+	tokens    float64
+	last      time.Time
+	limit     int
+	remaining int
+	reset     time.Time
+	holdUntil time.Time
+}
+
+// RateLimiterState is the state of a bucket of a RateLimiter.
+type RateLimiterState struct {
+	Key    string
+	Tokens float64
+
+	// Limit, Remaining, and Reset are the latest values of the response
+	// headers, with -1 or zero if unknown.
+	Limit     int
+	Remaining int
+	Reset     time.Time
+
+	// HoldUntil is when requests are next allowed after the server's limit was
+	// exhausted.
+	HoldUntil time.Time
+}
+
+// Wait blocks until a request with the key is allowed, or the context is
+// done.
+func (o *RateLimiter) Wait(ctx context.Context, key string) error {
+	delay := o.reserve(key)
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		o.cancel(key)
+		return ctx.Err()
+	}
+}
+
+// Middleware limits the requests of a client.
+func (o *RateLimiter) Middleware(next http.RoundTripper) http.RoundTripper {
+	return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		key := o.key(req)
+		if err := o.Wait(req.Context(), key); err != nil {
+			return nil, err
+		}
+
+		resp, err := next.RoundTrip(req)
+		if err == nil && o.Adaptive {
+			o.Update(key, resp)
+		}
+		return resp, err
+	})
+}
+
+// Update adapts the bucket of the key to the rate limit headers of the
+// response.
+func (o *RateLimiter) Update(key string, resp *http.Response) {
+	now := o.now()
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	b := o.bucket(key, now)
+
+	if limit, ok := rateLimitHeader(resp.Header, "Limit"); ok {
+		b.limit = int(limit)
+	}
+	if reset, ok := rateLimitHeader(resp.Header, "Reset"); ok {
+		// Large values are Unix times rather than delays.
+		if reset > 1e9 {
+			b.reset = time.Unix(int64(reset), 0)
+		} else {
+			b.reset = now.Add(time.Duration(reset * float64(time.Second)))
+		}
+	}
+	if remaining, ok := rateLimitHeader(resp.Header, "Remaining"); ok {
+		b.remaining = int(remaining)
+		if b.remaining <= 0 && b.reset.After(now) {
+			b.holdUntil = b.reset
+		}
+		if float64(b.remaining) < b.tokens {
+			b.tokens = float64(b.remaining)
+		}
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable {
+		if until, ok := retryAfter(resp.Header, now); ok && until.After(b.holdUntil) {
+			b.holdUntil = until
+		}
+	}
+}
+
+// State returns the state of each bucket, sorted by key.
+func (o *RateLimiter) State() []RateLimiterState {
+	now := o.now()
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	states := make([]RateLimiterState, 0, len(o.buckets))
+	for key := range o.buckets {
+		b := o.bucket(key, now)
+		states = append(states, RateLimiterState{
+			Key:       key,
+			Tokens:    b.tokens,
+			Limit:     b.limit,
+			Remaining: b.remaining,
+			Reset:     b.reset,
+			HoldUntil: b.holdUntil,
+		})
+	}
+	sort.Slice(states, func(i, j int) bool {
+		return states[i].Key < states[j].Key
+	})
+
+	return states
+}
+
+// reserve takes a token from the bucket, and returns how long to wait before
+// using it.
+func (o *RateLimiter) reserve(key string) time.Duration {
+	now := o.now()
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	b := o.bucket(key, now)
+	b.tokens--
+
+	var delay time.Duration
+	if o.Rate > 0 && b.tokens < 0 {
+		delay = time.Duration(-b.tokens / o.Rate * float64(time.Second))
+	}
+	if hold := b.holdUntil.Sub(now); hold > delay {
+		delay = hold
+	}
+	return delay
+}
+
+// cancel returns the token of a reservation that was not used.
+func (o *RateLimiter) cancel(key string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if b, ok := o.buckets[key]; ok {
+		b.tokens = math.Min(b.tokens+1, o.burst())
+	}
+}
+
+// bucket returns the bucket of the key, refilled up to now.
+func (o *RateLimiter) bucket(key string, now time.Time) *rateBucket {
+	if o.buckets == nil {
+		o.buckets = map[string]*rateBucket{}
+	}
+
+	b, ok := o.buckets[key]
+	if !ok {
+		b = &rateBucket{
+			tokens:    o.burst(),
+			last:      now,
+			limit:     -1,
+			remaining: -1,
+		}
+		o.buckets[key] = b
+	}
+
+	if o.Rate > 0 && now.After(b.last) {
+		b.tokens = math.Min(b.tokens+now.Sub(b.last).Seconds()*o.Rate, o.burst())
+	} else if o.Rate <= 0 {
+		b.tokens = o.burst()
+	}
+	b.last = now
+
+	return b
+}
+
+func (o *RateLimiter) burst() float64 {
+	if o.Burst < 1 {
+		return 1
+	}
+	return float64(o.Burst)
+}
+
+func (o *RateLimiter) key(req *http.Request) string {
+	if o.Key != nil {
+		return o.Key(req)
+	}
+	return requestHost(req)
+}
+
+func (o *RateLimiter) now() time.Time {
+	if o.Now != nil {
+		return o.Now()
+	}
+	return time.Now()
+}
+
+// rateLimitHeader returns the first number of the "RateLimit-<name>" or
+// "X-RateLimit-<name>" header.
+func rateLimitHeader(header http.Header, name string) (float64, bool) {
+	value := header.Get("RateLimit-" + name)
+	if value == "" {
+		value = header.Get("X-RateLimit-" + name)
+	}
+	if value == "" {
+		return 0, false
+	}
+
+	if i := strings.IndexAny(value, ",;"); i >= 0 {
+		value = value[:i]
+	}
+	n, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
+
+// retryAfter returns the time of the "Retry-After" header, which is a delay
+// in seconds or an HTTP date.
+func retryAfter(header http.Header, now time.Time) (time.Time, bool) {
+	value := strings.TrimSpace(header.Get("Retry-After"))
+	if value == "" {
+		return time.Time{}, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return now.Add(time.Duration(seconds) * time.Second), true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return t, true
+	}
+	return time.Time{}, false
+}
+
+// WithRateLimiter limits the requests of the Request's client with the
+// RateLimiter.
+func (o *Request) WithRateLimiter(limiter *RateLimiter) *Request {
+	return o.WithMiddleware(limiter.Middleware)
+}
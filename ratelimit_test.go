@@ -0,0 +1,160 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter_reserve(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	o := &RateLimiter{
+		Rate:  2,
+		Burst: 2,
+		Now:   func() time.Time { return now },
+	}
+
+	tests := []struct {
+		name    string
+		advance time.Duration
+		want    time.Duration
+	}{
+		{name: "success burst 1", want: 0},
+		{name: "success burst 2", want: 0},
+		{name: "success delayed", want: 500 * time.Millisecond},
+		{name: "success delayed further", want: time.Second},
+		{name: "success refilled", advance: 2 * time.Second, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now = now.Add(tt.advance)
+			if got := o.reserve("a"); got != tt.want {
+				t.Errorf("RateLimiter.reserve() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if got := o.reserve("b"); got != 0 {
+		t.Errorf("RateLimiter.reserve() other key = %v, want 0", got)
+	}
+}
+
+func TestRateLimiter_Wait(t *testing.T) {
+	o := &RateLimiter{
+		Rate:  1,
+		Burst: 1,
+	}
+
+	if err := o.Wait(context.Background(), "a"); err != nil {
+		t.Fatalf("RateLimiter.Wait() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := o.Wait(ctx, "a"); err != context.DeadlineExceeded {
+		t.Errorf("RateLimiter.Wait() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	// The cancelled reservation is returned.
+	if got := o.State()[0].Tokens; got < -0.1 || got > 0.1 {
+		t.Errorf("RateLimiter.State() tokens = %v, want 0", got)
+	}
+}
+
+func TestRateLimiter_Update(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+
+	tests := []struct {
+		name          string
+		statusCode    int
+		header        http.Header
+		wantState     RateLimiterState
+		wantHoldDelay time.Duration
+	}{
+		{
+			name:       "success remaining",
+			statusCode: http.StatusOK,
+			header: http.Header{
+				"Ratelimit-Limit":     []string{"100, 100;w=60"},
+				"Ratelimit-Remaining": []string{"1"},
+				"Ratelimit-Reset":     []string{"30"},
+			},
+			wantState: RateLimiterState{Key: "a", Tokens: 1, Limit: 100, Remaining: 1, Reset: now.Add(30 * time.Second)},
+		},
+		{
+			name:       "success exhausted",
+			statusCode: http.StatusOK,
+			header: http.Header{
+				"X-Ratelimit-Limit":     []string{"10"},
+				"X-Ratelimit-Remaining": []string{"0"},
+				"X-Ratelimit-Reset":     []string{strconv.FormatInt(now.Add(time.Minute).Unix(), 10)},
+			},
+			wantState:     RateLimiterState{Key: "a", Tokens: 0, Limit: 10, Remaining: 0, Reset: now.Add(time.Minute), HoldUntil: now.Add(time.Minute)},
+			wantHoldDelay: time.Minute,
+		},
+		{
+			name:          "success retry after",
+			statusCode:    http.StatusTooManyRequests,
+			header:        http.Header{"Retry-After": []string{"5"}},
+			wantState:     RateLimiterState{Key: "a", Tokens: 5, Limit: -1, Remaining: -1, HoldUntil: now.Add(5 * time.Second)},
+			wantHoldDelay: 5 * time.Second,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := &RateLimiter{
+				Rate:     1,
+				Burst:    5,
+				Adaptive: true,
+				Now:      func() time.Time { return now },
+			}
+			o.Update("a", &http.Response{StatusCode: tt.statusCode, Header: tt.header})
+
+			got := o.State()
+			if len(got) != 1 || got[0] != tt.wantState {
+				t.Errorf("RateLimiter.State() = %+v, want %+v", got, tt.wantState)
+			}
+			if delay := o.reserve("a"); delay < tt.wantHoldDelay {
+				t.Errorf("RateLimiter.reserve() = %v, want at least %v", delay, tt.wantHoldDelay)
+			}
+		})
+	}
+}
+
+func TestRequest_WithRateLimiter(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+	}))
+	defer server.Close()
+
+	limiter := &RateLimiter{Rate: 20, Burst: 2}
+	base, err := NewRequest().WithMethod(http.MethodGet).WithRateLimiter(limiter).FromURLString(server.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		if _, err := base.Clone().Do(); err != nil {
+			t.Fatalf("Request.Do() error = %v", err)
+		}
+	}
+	// Two requests use the burst, and two wait 50ms each.
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("Request.Do() took %v, want at least 100ms", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := base.Clone().DoContext(ctx); err == nil {
+		t.Errorf("Request.DoContext() error = nil, want cancelled")
+	}
+	if got := atomic.LoadInt32(&calls); got != 4 {
+		t.Errorf("server called %d times, want 4", got)
+	}
+}
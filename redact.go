@@ -0,0 +1,157 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// Redacted replaces sensitive values.
+const Redacted = "REDACTED"
+
+// sensitiveHeaders are always redacted.
+var sensitiveHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"Cookie",
+	"Set-Cookie",
+}
+
+// Secret is a sensitive string that is redacted when formatted or encoded.
+type Secret string
+
+func (s Secret) String() string {
+	return Redacted
+}
+
+func (s Secret) GoString() string {
+	return `"` + Redacted + `"`
+}
+
+// MarshalText encodes the Secret as Redacted.
+func (s Secret) MarshalText() ([]byte, error) {
+	return []byte(Redacted), nil
+}
+
+// Sensitive is implemented by an Authenticator that sets headers or query
+// parameters other than "Authorization", so that they can be redacted.
+type Sensitive interface {
+	SensitiveNames() (header, query []string)
+}
+
+// RedactHeader returns a copy of the header with the values of sensitive
+// headers, and of the named headers, replaced by Redacted.
+func RedactHeader(header http.Header, names ...string) http.Header {
+	if header == nil {
+		return nil
+	}
+
+	h := header.Clone()
+	for _, list := range [][]string{sensitiveHeaders, names} {
+		for _, name := range list {
+			key := http.CanonicalHeaderKey(name)
+			for i := range h[key] {
+				h[key][i] = Redacted
+			}
+		}
+	}
+
+	return h
+}
+
+// RedactURL returns a copy of the URL with its password, and the values of the
+// named query parameters, replaced by Redacted.
+func RedactURL(u *url.URL, names ...string) *url.URL {
+	if u == nil {
+		return nil
+	}
+
+	c := *u
+	if _, ok := c.User.Password(); ok {
+		c.User = url.UserPassword(c.User.Username(), Redacted)
+	}
+	if len(names) > 0 && c.RawQuery != "" {
+		query := c.Query()
+		changed := false
+		for _, name := range names {
+			for i := range query[name] {
+				query[name][i] = Redacted
+				changed = true
+			}
+		}
+		if changed {
+			c.RawQuery = query.Encode()
+		}
+	}
+
+	return &c
+}
+
+// Redacted returns a copy of the Request with the values of sensitive headers
+// and query parameters, including those set by its authenticator, replaced by
+// Redacted. It is intended for logging.
+func (o *Request) Redacted() *Request {
+	c := o.Clone()
+	header, query := o.sensitiveNames()
+
+	c.Header = RedactHeader(o.Header, header...)
+	for _, name := range query {
+		for i := range c.Query[name] {
+			c.Query[name][i] = Redacted
+		}
+	}
+
+	return c
+}
+
+// sensitiveHeadersKey is the context key of the headers set by the
+// authenticator of a request, for middleware such as a Cache.
+type sensitiveHeadersKey struct{}
+
+// withSensitiveHeaders returns a context with the sensitive headers of the
+// Request's authenticator.
+func (o *Request) withSensitiveHeaders(ctx context.Context) context.Context {
+	header, _ := o.sensitiveNames()
+	if len(header) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, sensitiveHeadersKey{}, header)
+}
+
+// credentialHeaders returns the names of the headers of the request that hold
+// credentials, including those set by the authenticator of a Request.
+func credentialHeaders(req *http.Request) []string {
+	names := append([]string{}, sensitiveHeaders...)
+	if header, ok := req.Context().Value(sensitiveHeadersKey{}).([]string); ok {
+		names = append(names, header...)
+	}
+	return names
+}
+
+func (o *Request) sensitiveNames() (header, query []string) {
+	if s, ok := o.Auth.(Sensitive); ok {
+		return s.SensitiveNames()
+	}
+	return nil, nil
+}
+
+// redactError redacts the URL of a *url.Error returned by the client, which
+// may contain credentials added by the authenticator.
+func (o *Request) redactError(err error) error {
+	urlErr, ok := err.(*url.Error)
+	if !ok {
+		return err
+	}
+
+	u, parseErr := url.Parse(urlErr.URL)
+	if parseErr != nil {
+		return err
+	}
+	_, query := o.sensitiveNames()
+
+	return &url.Error{
+		Op:  urlErr.Op,
+		URL: RedactURL(u, query...).String(),
+		Err: urlErr.Err,
+	}
+}
@@ -7,13 +7,18 @@ import (
 )
 
 // Request is a HTTP request.
+//
+// RawPath is the optional encoded form of Path, as in url.URL, such as for
+// path variables bound with escaped slashes.
 type Request struct {
 	Client *http.Client
+	Auth   Authenticator
 
 	Method       string
 	Scheme       string
 	Host         string
 	Path         string
+	RawPath      string
 	Query        url.Values
 	Header       http.Header
 	RequestBody  interface{}
@@ -26,6 +31,26 @@ func (o *Request) Clear() *Request {
 	return o
 }
 
+// Clone returns a copy of the Request with its own query and header.
+//
+// The client, authenticator, request body, and response body are shared with
+// the copy.
+func (o *Request) Clone() *Request {
+	c := *o
+
+	if o.Query != nil {
+		c.Query = url.Values{}
+		for key, values := range o.Query {
+			c.Query[key] = append([]string(nil), values...)
+		}
+	}
+	if o.Header != nil {
+		c.Header = o.Header.Clone()
+	}
+
+	return &c
+}
+
 // WithClient sets the HTTP client of the Request.
 func (o *Request) WithClient(client *http.Client) *Request {
 	o.Client = client
@@ -64,9 +89,10 @@ func (o *Request) WithHost(host string) *Request {
 	return o
 }
 
-// WithPath sets the path of the Request.
+// WithPath sets the path of the Request, clearing its raw path.
 func (o *Request) WithPath(path string) *Request {
 	o.Path = path
+	o.RawPath = ""
 	return o
 }
 
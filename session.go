@@ -0,0 +1,348 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SessionVersion is the version of the file format written by Session.Save.
+const SessionVersion = 1
+
+// SessionCookie is a cookie of a Session with its attributes.
+type SessionCookie struct {
+	Name     string    `json:"name"`
+	Value    string    `json:"value"`
+	Domain   string    `json:"domain"`
+	Path     string    `json:"path"`
+	Expires  time.Time `json:"expires,omitempty"`
+	Secure   bool      `json:"secure,omitempty"`
+	HttpOnly bool      `json:"httpOnly,omitempty"`
+
+	// HostOnly is set if the cookie had no domain attribute, so that it is only
+	// sent to the host that set it, and not its subdomains.
+	HostOnly bool `json:"hostOnly,omitempty"`
+
+	Created time.Time `json:"created"`
+}
+
+// Session is a cookie jar that keeps the attributes of its cookies, so that it
+// can be saved to and loaded from a file. It is safe for concurrent use.
+//
+// Public suffixes are not checked, so a Session should only be used with
+// trusted hosts.
+type Session struct {
+	// Now returns the current time, time.Now if nil.
+	Now func() time.Time
+
+	mu      sync.Mutex
+	cookies map[string]*SessionCookie
+}
+
+type sessionFile struct {
+	Version int              `json:"version"`
+	Cookies []*SessionCookie `json:"cookies"`
+}
+
+// NewSession creates a new empty Session.
+func NewSession() *Session {
+	return &Session{
+		cookies: map[string]*SessionCookie{},
+	}
+}
+
+// LoadSession loads a Session saved to the file at path. A missing file loads
+// an empty Session.
+func LoadSession(path string) (*Session, error) {
+	o := NewSession()
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return o, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	file := &sessionFile{}
+	if err := json.Unmarshal(data, file); err != nil {
+		return nil, fmt.Errorf("error decoding session: %w", err)
+	}
+	if file.Version != SessionVersion {
+		return nil, fmt.Errorf("unsupported session version %d", file.Version)
+	}
+
+	now := o.now()
+	for _, c := range file.Cookies {
+		if !c.expired(now) {
+			o.cookies[c.key()] = c
+		}
+	}
+
+	return o, nil
+}
+
+// Save saves the unexpired cookies of the Session, including those without
+// an expiry, to the file at path, readable only by the current user.
+func (o *Session) Save(path string) error {
+	file := &sessionFile{
+		Version: SessionVersion,
+		Cookies: o.all(""),
+	}
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding session: %w", err)
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// SetCookies stores the cookies of a response from u.
+func (o *Session) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	host, err := cookieHost(u.Host)
+	if err != nil {
+		return
+	}
+	now := o.now()
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.cookies == nil {
+		o.cookies = map[string]*SessionCookie{}
+	}
+
+	for _, cookie := range cookies {
+		c := &SessionCookie{
+			Name:     cookie.Name,
+			Value:    cookie.Value,
+			Domain:   host,
+			Path:     cookie.Path,
+			Secure:   cookie.Secure,
+			HttpOnly: cookie.HttpOnly,
+			HostOnly: true,
+			Created:  now,
+		}
+
+		if cookie.Domain != "" {
+			domain := strings.ToLower(strings.TrimPrefix(cookie.Domain, "."))
+			if !domainMatch(host, domain) || (net.ParseIP(host) != nil && host != domain) {
+				continue
+			}
+			c.Domain = domain
+			c.HostOnly = false
+		}
+		if !strings.HasPrefix(c.Path, "/") {
+			c.Path = defaultCookiePath(u.Path)
+		}
+
+		switch {
+		case cookie.MaxAge < 0:
+			c.Expires = now
+		case cookie.MaxAge > 0:
+			c.Expires = now.Add(time.Duration(cookie.MaxAge) * time.Second)
+		case !cookie.Expires.IsZero():
+			c.Expires = cookie.Expires
+		}
+
+		key := c.key()
+		if c.expired(now) {
+			delete(o.cookies, key)
+			continue
+		}
+		if old, ok := o.cookies[key]; ok {
+			c.Created = old.Created
+		}
+		o.cookies[key] = c
+	}
+}
+
+// Cookies returns the cookies to send in a request to u.
+func (o *Session) Cookies(u *url.URL) []*http.Cookie {
+	host, err := cookieHost(u.Host)
+	if err != nil {
+		return nil
+	}
+	secure := u.Scheme == "https" || u.Scheme == "wss"
+	path := u.Path
+	if path == "" {
+		path = "/"
+	}
+
+	var matched []*SessionCookie
+	for _, c := range o.all(host) {
+		if c.Secure && !secure {
+			continue
+		}
+		if !pathMatch(path, c.Path) {
+			continue
+		}
+		matched = append(matched, c)
+	}
+
+	sort.SliceStable(matched, func(i, j int) bool {
+		return len(matched[i].Path) > len(matched[j].Path)
+	})
+
+	cookies := make([]*http.Cookie, len(matched))
+	for i, c := range matched {
+		cookies[i] = &http.Cookie{Name: c.Name, Value: c.Value}
+	}
+	return cookies
+}
+
+// CookiesForHost returns copies of the unexpired cookies that would be sent
+// to the host, regardless of path and scheme.
+func (o *Session) CookiesForHost(host string) []*SessionCookie {
+	host, err := cookieHost(host)
+	if err != nil {
+		return nil
+	}
+	return o.all(host)
+}
+
+// ClearHost removes the cookies that would be sent to the host.
+func (o *Session) ClearHost(host string) {
+	host, err := cookieHost(host)
+	if err != nil {
+		return
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	for key, c := range o.cookies {
+		if c.matchesHost(host) {
+			delete(o.cookies, key)
+		}
+	}
+}
+
+// Clear removes all cookies.
+func (o *Session) Clear() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.cookies = map[string]*SessionCookie{}
+}
+
+// all returns copies of the unexpired cookies for the host, or all of them if
+// host is empty, sorted by creation time. Expired cookies are removed.
+func (o *Session) all(host string) []*SessionCookie {
+	now := o.now()
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	var cookies []*SessionCookie
+	for key, c := range o.cookies {
+		if c.expired(now) {
+			delete(o.cookies, key)
+			continue
+		}
+		if host != "" && !c.matchesHost(host) {
+			continue
+		}
+		copied := *c
+		cookies = append(cookies, &copied)
+	}
+
+	sort.Slice(cookies, func(i, j int) bool {
+		if !cookies[i].Created.Equal(cookies[j].Created) {
+			return cookies[i].Created.Before(cookies[j].Created)
+		}
+		return cookies[i].key() < cookies[j].key()
+	})
+	return cookies
+}
+
+func (o *Session) now() time.Time {
+	if o.Now != nil {
+		return o.Now()
+	}
+	return time.Now()
+}
+
+func (c *SessionCookie) key() string {
+	return c.Domain + ";" + c.Path + ";" + c.Name
+}
+
+func (c *SessionCookie) expired(now time.Time) bool {
+	return !c.Expires.IsZero() && !now.Before(c.Expires)
+}
+
+func (c *SessionCookie) matchesHost(host string) bool {
+	if c.HostOnly {
+		return host == c.Domain
+	}
+	return domainMatch(host, c.Domain)
+}
+
+// WithSession sets the cookie jar of the Request's client to the Session.
+//
+// The client is copied, so that a shared client such as http.DefaultClient is
+// not changed, and clones of the Request share the Session.
+func (o *Request) WithSession(session *Session) *Request {
+	o.ensureClient()
+
+	client := *o.Client
+	client.Jar = session
+	o.Client = &client
+	return o
+}
+
+func cookieHost(host string) (string, error) {
+	if host == "" {
+		return "", fmt.Errorf("must provide host")
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+	return strings.ToLower(strings.TrimSuffix(host, ".")), nil
+}
+
+func domainMatch(host, domain string) bool {
+	return host == domain || (strings.HasSuffix(host, "."+domain) && net.ParseIP(host) == nil)
+}
+
+func pathMatch(path, cookiePath string) bool {
+	if path == cookiePath {
+		return true
+	}
+	if !strings.HasPrefix(path, cookiePath) {
+		return false
+	}
+	return strings.HasSuffix(cookiePath, "/") || path[len(cookiePath)] == '/'
+}
+
+func defaultCookiePath(path string) string {
+	i := strings.LastIndex(path, "/")
+	if i <= 0 {
+		return "/"
+	}
+	return path[:i]
+}
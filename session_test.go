@@ -0,0 +1,217 @@
+package http
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSession_Cookies(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+
+	type set struct {
+		ref     string
+		cookies []*http.Cookie
+	}
+	tests := []struct {
+		name string
+		sets []set
+		ref  string
+		want []string
+	}{
+		{
+			name: "success host only",
+			sets: []set{
+				{ref: "http://example.com/", cookies: []*http.Cookie{{Name: "a", Value: "1"}}},
+			},
+			ref:  "http://example.com/foo",
+			want: []string{"a=1"},
+		},
+		{
+			name: "success host only not sent to subdomain",
+			sets: []set{
+				{ref: "http://example.com/", cookies: []*http.Cookie{{Name: "a", Value: "1"}}},
+			},
+			ref:  "http://www.example.com/",
+			want: nil,
+		},
+		{
+			name: "success domain sent to subdomain",
+			sets: []set{
+				{ref: "http://example.com/", cookies: []*http.Cookie{{Name: "a", Value: "1", Domain: ".example.com"}}},
+			},
+			ref:  "http://api.example.com:8080/",
+			want: []string{"a=1"},
+		},
+		{
+			name: "success foreign domain rejected",
+			sets: []set{
+				{ref: "http://example.com/", cookies: []*http.Cookie{{Name: "a", Value: "1", Domain: "other.com"}}},
+			},
+			ref:  "http://other.com/",
+			want: nil,
+		},
+		{
+			name: "success path order and matching",
+			sets: []set{
+				{ref: "http://example.com/", cookies: []*http.Cookie{
+					{Name: "root", Value: "1", Path: "/"},
+					{Name: "api", Value: "2", Path: "/api"},
+					{Name: "apix", Value: "3", Path: "/apix"},
+				}},
+			},
+			ref:  "http://example.com/api/users",
+			want: []string{"api=2", "root=1"},
+		},
+		{
+			name: "success secure only over https",
+			sets: []set{
+				{ref: "https://example.com/", cookies: []*http.Cookie{{Name: "a", Value: "1", Secure: true}}},
+			},
+			ref:  "http://example.com/",
+			want: nil,
+		},
+		{
+			name: "success expired and deleted",
+			sets: []set{
+				{ref: "http://example.com/", cookies: []*http.Cookie{
+					{Name: "a", Value: "1"},
+					{Name: "b", Value: "2", Expires: now.Add(-time.Hour)},
+					{Name: "c", Value: "3", MaxAge: 60},
+				}},
+				{ref: "http://example.com/", cookies: []*http.Cookie{{Name: "a", MaxAge: -1}}},
+			},
+			ref:  "http://example.com/",
+			want: []string{"c=3"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := NewSession()
+			o.Now = func() time.Time { return now }
+			for _, s := range tt.sets {
+				u, _ := url.Parse(s.ref)
+				o.SetCookies(u, s.cookies)
+			}
+
+			u, _ := url.Parse(tt.ref)
+			var got []string
+			for _, c := range o.Cookies(u) {
+				got = append(got, c.String())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Session.Cookies() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSession_Save(t *testing.T) {
+	dir, err := ioutil.TempDir("", "session")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "session.json")
+
+	now := time.Now().Truncate(time.Second).UTC()
+	o := NewSession()
+	o.Now = func() time.Time { return now }
+
+	u, _ := url.Parse("https://example.com/login")
+	o.SetCookies(u, []*http.Cookie{
+		{Name: "session", Value: "abc", Path: "/", Secure: true, HttpOnly: true},
+		{Name: "pref", Value: "dark", Domain: "example.com", Expires: now.Add(time.Hour)},
+	})
+	if err := o.Save(path); err != nil {
+		t.Fatalf("Session.Save() error = %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("Session.Save() permissions = %v, want 0600", perm)
+	}
+
+	loaded, err := LoadSession(path)
+	if err != nil {
+		t.Fatalf("LoadSession() error = %v", err)
+	}
+	loaded.Now = o.Now
+
+	want := []*SessionCookie{
+		{Name: "pref", Value: "dark", Domain: "example.com", Path: "/", Expires: now.Add(time.Hour), Created: now},
+		{Name: "session", Value: "abc", Domain: "example.com", Path: "/", Secure: true, HttpOnly: true, HostOnly: true, Created: now},
+	}
+	got := loaded.CookiesForHost("example.com")
+	for _, c := range got {
+		c.Expires = c.Expires.UTC()
+		c.Created = c.Created.UTC()
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("LoadSession() cookies = %+v, want %+v", got, want)
+	}
+
+	if missing, err := LoadSession(filepath.Join(dir, "missing.json")); err != nil || len(missing.CookiesForHost("example.com")) != 0 {
+		t.Errorf("LoadSession() = %v, %v, want empty session", missing, err)
+	}
+}
+
+func TestSession_ClearHost(t *testing.T) {
+	o := NewSession()
+	for _, ref := range []string{"http://a.example.com/", "http://b.example.com/"} {
+		u, _ := url.Parse(ref)
+		o.SetCookies(u, []*http.Cookie{{Name: "a", Value: "1"}})
+	}
+
+	o.ClearHost("a.example.com:8080")
+	if got := o.CookiesForHost("a.example.com"); len(got) != 0 {
+		t.Errorf("Session.ClearHost() left %v", got)
+	}
+	if got := o.CookiesForHost("b.example.com"); len(got) != 1 {
+		t.Errorf("Session.ClearHost() removed other host, got %v", got)
+	}
+
+	o.Clear()
+	if got := o.CookiesForHost("b.example.com"); len(got) != 0 {
+		t.Errorf("Session.Clear() left %v", got)
+	}
+}
+
+func TestRequest_WithSession(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login":
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc", Path: "/"})
+		case "/me":
+			if c, err := r.Cookie("session"); err != nil || c.Value != "abc" {
+				w.WriteHeader(http.StatusUnauthorized)
+			}
+		}
+	}))
+	defer server.Close()
+
+	session := NewSession()
+	base, err := NewRequest().WithMethod(http.MethodGet).WithSession(session).FromURLString(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if http.DefaultClient.Jar != nil {
+		t.Errorf("Request.WithSession() changed http.DefaultClient")
+	}
+
+	if _, err := base.Clone().WithPath("/login").Do(); err != nil {
+		t.Fatalf("Request.Do() login error = %v", err)
+	}
+	if _, err := base.Clone().WithPath("/me").Do(); err != nil {
+		t.Errorf("Request.Do() error = %v", err)
+	}
+}
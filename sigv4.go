@@ -0,0 +1,327 @@
+package http
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	sigV4Algorithm  = "AWS4-HMAC-SHA256"
+	sigV4TimeFormat = "20060102T150405Z"
+
+	// UnsignedPayload is the payload hash of requests whose body is not
+	// signed.
+	UnsignedPayload = "UNSIGNED-PAYLOAD"
+)
+
+// sigV4IgnoredHeaders are not signed, since they may be changed in transit.
+var sigV4IgnoredHeaders = map[string]bool{
+	"authorization":   true,
+	"user-agent":      true,
+	"x-amzn-trace-id": true,
+	"expect":          true,
+}
+
+// SigV4 authenticates with AWS Signature Version 4.
+type SigV4 struct {
+	AccessKeyID     string
+	SecretAccessKey Secret
+	SessionToken    Secret
+
+	Region  string
+	Service string
+
+	// UnsignedPayload signs the request without hashing its body, for bodies
+	// that are streamed.
+	UnsignedPayload bool
+
+	// DisableURIPathEscaping signs the path as it is, rather than normalized
+	// and escaped a second time. S3 requires this.
+	DisableURIPathEscaping bool
+
+	// Now returns the signing time, time.Now if nil.
+	Now func() time.Time
+}
+
+// Authenticate signs the request, setting its "Authorization" and
+// "X-Amz-Date" headers.
+func (o *SigV4) Authenticate(req *http.Request) error {
+	now := o.now()
+
+	payloadHash := UnsignedPayload
+	if !o.UnsignedPayload {
+		var err error
+		if payloadHash, err = hashBody(req); err != nil {
+			return fmt.Errorf("error hashing body: %w", err)
+		}
+	}
+	if o.UnsignedPayload || o.Service == "s3" {
+		req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	}
+	req.Header.Set("X-Amz-Date", now.Format(sigV4TimeFormat))
+	if o.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", string(o.SessionToken))
+	}
+
+	signedHeaders, canonicalHeaders := o.canonicalHeaders(req)
+	signature := o.signature(now, o.canonicalRequest(req, req.URL.RawQuery, signedHeaders, canonicalHeaders, payloadHash))
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		sigV4Algorithm, o.AccessKeyID, o.scope(now), signedHeaders, signature))
+	return nil
+}
+
+// SensitiveNames returns the session token and signature.
+func (o *SigV4) SensitiveNames() (header, query []string) {
+	return []string{"X-Amz-Security-Token"}, []string{"X-Amz-Signature", "X-Amz-Security-Token"}
+}
+
+// Presign returns the URL of the request with its signature in the query,
+// valid for expiry. Only the host header is signed, and the body is unsigned.
+func (o *SigV4) Presign(req *http.Request, expiry time.Duration) (*url.URL, error) {
+	if expiry <= 0 || expiry > 7*24*time.Hour {
+		return nil, fmt.Errorf("expiry must be between 1 second and 7 days")
+	}
+	now := o.now()
+
+	u := *req.URL
+	query := u.Query()
+	query.Set("X-Amz-Algorithm", sigV4Algorithm)
+	query.Set("X-Amz-Credential", o.AccessKeyID+"/"+o.scope(now))
+	query.Set("X-Amz-Date", now.Format(sigV4TimeFormat))
+	query.Set("X-Amz-Expires", strconv.FormatInt(int64(expiry/time.Second), 10))
+	query.Set("X-Amz-SignedHeaders", "host")
+	if o.SessionToken != "" {
+		query.Set("X-Amz-Security-Token", string(o.SessionToken))
+	}
+	u.RawQuery = canonicalQuery(query.Encode())
+
+	host := "host:" + requestHost(req) + "\n"
+	signature := o.signature(now, o.canonicalRequest(req, u.RawQuery, "host", host, UnsignedPayload))
+	u.RawQuery += "&X-Amz-Signature=" + signature
+
+	return &u, nil
+}
+
+// Presign returns the URL of the Request signed with its authenticator, which
+// must be a *SigV4, valid for expiry.
+func (o *Request) Presign(expiry time.Duration) (*url.URL, error) {
+	signer, ok := o.Auth.(*SigV4)
+	if !ok {
+		return nil, fmt.Errorf("must provide SigV4 authenticator")
+	}
+
+	method := o.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	u, err := o.URL()
+	if err != nil {
+		return nil, fmt.Errorf("error building URL: %w", err)
+	}
+
+	req, err := http.NewRequest(method, u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating http request: %w", err)
+	}
+
+	return signer.Presign(req, expiry)
+}
+
+func (o *SigV4) now() time.Time {
+	if o.Now != nil {
+		return o.Now().UTC()
+	}
+	return time.Now().UTC()
+}
+
+func (o *SigV4) scope(t time.Time) string {
+	return strings.Join([]string{t.Format("20060102"), o.Region, o.Service, "aws4_request"}, "/")
+}
+
+func (o *SigV4) canonicalRequest(req *http.Request, rawQuery, signedHeaders, canonicalHeaders, payloadHash string) string {
+	return strings.Join([]string{
+		req.Method,
+		o.canonicalURI(req.URL),
+		canonicalQuery(rawQuery),
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+}
+
+func (o *SigV4) signature(t time.Time, canonicalRequest string) string {
+	hash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		sigV4Algorithm,
+		t.Format(sigV4TimeFormat),
+		o.scope(t),
+		hex.EncodeToString(hash[:]),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+string(o.SecretAccessKey)), t.Format("20060102"))
+	key = hmacSHA256(key, o.Region)
+	key = hmacSHA256(key, o.Service)
+	key = hmacSHA256(key, "aws4_request")
+
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+func (o *SigV4) canonicalURI(u *url.URL) string {
+	p := u.EscapedPath()
+	if p == "" {
+		return "/"
+	}
+	if o.DisableURIPathEscaping {
+		return p
+	}
+
+	cleaned := path.Clean(p)
+	if strings.HasSuffix(p, "/") && cleaned != "/" {
+		cleaned += "/"
+	}
+	return uriEncode(cleaned, false)
+}
+
+func (o *SigV4) canonicalHeaders(req *http.Request) (string, string) {
+	headers := map[string][]string{
+		"host": {requestHost(req)},
+	}
+	for key, values := range req.Header {
+		name := strings.ToLower(key)
+		if sigV4IgnoredHeaders[name] {
+			continue
+		}
+		headers[name] = append(headers[name], values...)
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		values := make([]string, len(headers[name]))
+		for i, value := range headers[name] {
+			values[i] = strings.Join(strings.Fields(value), " ")
+		}
+		b.WriteString(name + ":" + strings.Join(values, ",") + "\n")
+	}
+
+	return strings.Join(names, ";"), b.String()
+}
+
+// canonicalQuery encodes the query with its pairs sorted by key and value.
+func canonicalQuery(rawQuery string) string {
+	type pair struct{ key, value string }
+
+	var pairs []pair
+	for _, part := range strings.Split(rawQuery, "&") {
+		if part == "" {
+			continue
+		}
+		kv := strings.SplitN(part, "=", 2)
+		key, err := url.QueryUnescape(kv[0])
+		if err != nil {
+			key = kv[0]
+		}
+		value := ""
+		if len(kv) == 2 {
+			if value, err = url.QueryUnescape(kv[1]); err != nil {
+				value = kv[1]
+			}
+		}
+		pairs = append(pairs, pair{key: uriEncode(key, true), value: uriEncode(value, true)})
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].key != pairs[j].key {
+			return pairs[i].key < pairs[j].key
+		}
+		return pairs[i].value < pairs[j].value
+	})
+
+	parts := make([]string, len(pairs))
+	for i, p := range pairs {
+		parts[i] = p.key + "=" + p.value
+	}
+	return strings.Join(parts, "&")
+}
+
+// uriEncode percent-encodes all but the unreserved characters of RFC 3986,
+// and also "/" unless encodeSlash is set.
+func uriEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func requestHost(req *http.Request) string {
+	if req.Host != "" {
+		return req.Host
+	}
+	return req.URL.Host
+}
+
+// hashBody returns the hex SHA-256 of the request body.
+func hashBody(req *http.Request) (string, error) {
+	data, err := readBody(req)
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// readBody returns the request body, leaving it to be read again.
+func readBody(req *http.Request) ([]byte, error) {
+	switch {
+	case req.GetBody != nil:
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		defer body.Close()
+		return ioutil.ReadAll(body)
+	case req.Body != nil && req.Body != http.NoBody:
+		data, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+		req.Body = ioutil.NopCloser(bytes.NewReader(data))
+		return data, nil
+	default:
+		return nil, nil
+	}
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
@@ -0,0 +1,153 @@
+package http
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Storage stores the responses of a Cache by key. Implementations must be safe
+// for concurrent use.
+type Storage interface {
+	Get(key string) ([]byte, bool)
+	Set(key string, value []byte)
+	Delete(key string)
+}
+
+// MemoryStorage is a Storage in memory that evicts the least recently used
+// entries beyond its limits.
+type MemoryStorage struct {
+	// MaxEntries is the maximum number of entries, unlimited if zero.
+	MaxEntries int
+
+	// MaxBytes is the maximum total size of values, unlimited if zero.
+	MaxBytes int64
+
+	mu    sync.Mutex
+	size  int64
+	lru   *list.List
+	items map[string]*list.Element
+}
+
+type memoryEntry struct {
+	key   string
+	value []byte
+}
+
+// NewMemoryStorage creates a new MemoryStorage with a maximum number of
+// entries.
+func NewMemoryStorage(maxEntries int) *MemoryStorage {
+	return &MemoryStorage{MaxEntries: maxEntries}
+}
+
+// Get returns the value of the key.
+func (o *MemoryStorage) Get(key string) ([]byte, bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	e, ok := o.items[key]
+	if !ok {
+		return nil, false
+	}
+	o.lru.MoveToFront(e)
+	return e.Value.(*memoryEntry).value, true
+}
+
+// Set sets the value of the key, evicting entries beyond the limits.
+func (o *MemoryStorage) Set(key string, value []byte) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.items == nil {
+		o.lru = list.New()
+		o.items = map[string]*list.Element{}
+	}
+
+	if e, ok := o.items[key]; ok {
+		o.remove(e)
+	}
+	o.items[key] = o.lru.PushFront(&memoryEntry{key: key, value: value})
+	o.size += int64(len(value))
+
+	for o.lru.Len() > 0 && ((o.MaxEntries > 0 && o.lru.Len() > o.MaxEntries) || (o.MaxBytes > 0 && o.size > o.MaxBytes)) {
+		o.remove(o.lru.Back())
+	}
+}
+
+// Delete deletes the key.
+func (o *MemoryStorage) Delete(key string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if e, ok := o.items[key]; ok {
+		o.remove(e)
+	}
+}
+
+// Len returns the number of entries.
+func (o *MemoryStorage) Len() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.lru == nil {
+		return 0
+	}
+	return o.lru.Len()
+}
+
+func (o *MemoryStorage) remove(e *list.Element) {
+	entry := o.lru.Remove(e).(*memoryEntry)
+	delete(o.items, entry.key)
+	o.size -= int64(len(entry.value))
+}
+
+// DiskStorage is a Storage with a file for each entry in a directory, which is
+// created if needed. Failures to read or write files are treated as misses.
+type DiskStorage struct {
+	Dir string
+}
+
+// Get returns the value of the key.
+func (o *DiskStorage) Get(key string) ([]byte, bool) {
+	data, err := ioutil.ReadFile(o.path(key))
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+// Set sets the value of the key.
+func (o *DiskStorage) Set(key string, value []byte) {
+	if err := os.MkdirAll(o.Dir, 0700); err != nil {
+		return
+	}
+
+	tmp, err := ioutil.TempFile(o.Dir, ".tmp")
+	if err != nil {
+		return
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(value); err != nil {
+		tmp.Close()
+		return
+	}
+	if err := tmp.Close(); err != nil {
+		return
+	}
+	os.Rename(tmp.Name(), o.path(key))
+}
+
+// Delete deletes the key.
+func (o *DiskStorage) Delete(key string) {
+	os.Remove(o.path(key))
+}
+
+func (o *DiskStorage) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(o.Dir, hex.EncodeToString(sum[:]))
+}
@@ -0,0 +1,87 @@
+package http
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMemoryStorage(t *testing.T) {
+	tests := []struct {
+		name     string
+		storage  *MemoryStorage
+		wantKeys []string
+		wantGone []string
+	}{
+		{
+			name:     "success max entries",
+			storage:  NewMemoryStorage(2),
+			wantKeys: []string{"a", "c"},
+			wantGone: []string{"b"},
+		},
+		{
+			name:     "success max bytes",
+			storage:  &MemoryStorage{MaxBytes: 5},
+			wantKeys: []string{"a", "c"},
+			wantGone: []string{"b"},
+		},
+		{
+			name:     "success unlimited",
+			storage:  &MemoryStorage{},
+			wantKeys: []string{"a", "b", "c"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := tt.storage
+			o.Set("a", []byte("aa"))
+			o.Set("b", []byte("bb"))
+			// Reading a makes b the least recently used.
+			o.Get("a")
+			o.Set("c", []byte("cc"))
+
+			for _, key := range tt.wantKeys {
+				if _, ok := o.Get(key); !ok {
+					t.Errorf("MemoryStorage.Get(%q) missing", key)
+				}
+			}
+			for _, key := range tt.wantGone {
+				if _, ok := o.Get(key); ok {
+					t.Errorf("MemoryStorage.Get(%q) not evicted", key)
+				}
+			}
+			if got := o.Len(); got != len(tt.wantKeys) {
+				t.Errorf("MemoryStorage.Len() = %v, want %v", got, len(tt.wantKeys))
+			}
+
+			o.Delete("a")
+			if _, ok := o.Get("a"); ok {
+				t.Errorf("MemoryStorage.Get() after Delete() found")
+			}
+		})
+	}
+}
+
+func TestDiskStorage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	o := &DiskStorage{Dir: filepath.Join(dir, "responses")}
+	if _, ok := o.Get("GET http://example.com/"); ok {
+		t.Errorf("DiskStorage.Get() found before Set()")
+	}
+
+	o.Set("GET http://example.com/", []byte("value"))
+	if got, ok := o.Get("GET http://example.com/"); !ok || string(got) != "value" {
+		t.Errorf("DiskStorage.Get() = %q, %v, want %q", got, ok, "value")
+	}
+
+	o.Delete("GET http://example.com/")
+	if _, ok := o.Get("GET http://example.com/"); ok {
+		t.Errorf("DiskStorage.Get() found after Delete()")
+	}
+}
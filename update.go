@@ -0,0 +1,125 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+const defaultUpdateAttempts = 3
+
+// ConflictError represents an update that failed its precondition on every
+// attempt, because the resource kept changing.
+type ConflictError struct {
+	Attempts int
+}
+
+func (o *ConflictError) Error() string {
+	return fmt.Sprintf("resource changed during update after %d attempts", o.Attempts)
+}
+
+// UpdateOptions are options to use when updating a resource.
+type UpdateOptions struct {
+	// Method is the method that sends the update, PUT if empty. PUT sends the
+	// updated value, and PATCH sends a JSON Merge Patch of the changes.
+	Method string
+
+	// MaxAttempts is the number of attempts, 3 if zero.
+	MaxAttempts int
+
+	// Options are the options of each request.
+	Options []*DoOptions
+}
+
+// Update reads the resource of the Request into v, which must be a pointer,
+// calls mutate to change it, and sends it back with an "If-Match" of the ETag
+// that was read. If the resource changed in between, reported by a 412
+// response, the update is attempted again, and a *ConflictError is returned
+// once the attempts run out.
+//
+// The resource must have a strong ETag, since a weak ETag never matches an
+// "If-Match".
+//
+// Request bodies default to a "Content-Type" of "application/json", and
+// response bodies to an "Accept" of "application/json". The response to the
+// update is decoded into the response body of the Request, if set.
+func (o *Request) Update(ctx context.Context, v interface{}, mutate func() error, options *UpdateOptions) (*http.Response, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return nil, fmt.Errorf("must provide non-nil pointer, got %T", v)
+	}
+	if options == nil {
+		options = &UpdateOptions{}
+	}
+	method := options.Method
+	if method == "" {
+		method = http.MethodPut
+	}
+	maxAttempts := options.MaxAttempts
+	if maxAttempts < 1 {
+		maxAttempts = defaultUpdateAttempts
+	}
+
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		rv.Elem().Set(reflect.Zero(rv.Elem().Type()))
+
+		get := o.Clone().WithMethod(http.MethodGet).WithRequestBody(nil).WithResponseBody(v)
+		get.ensureHeader()
+		if get.Header.Get("Accept") == "" {
+			get.Header.Set("Accept", "application/json")
+		}
+		resp, err := get.DoContext(ctx, options.Options...)
+		if resp != nil {
+			resp.Body.Close()
+		}
+		if err != nil {
+			return resp, fmt.Errorf("error getting resource: %w", err)
+		}
+
+		etag := resp.Header.Get("ETag")
+		if etag == "" {
+			return resp, fmt.Errorf("resource has no ETag")
+		}
+		if strings.HasPrefix(etag, "W/") {
+			return resp, fmt.Errorf("resource has weak ETag %s, which cannot be used with If-Match", etag)
+		}
+
+		original, err := json.Marshal(v)
+		if err != nil {
+			return nil, fmt.Errorf("error encoding resource: %w", err)
+		}
+
+		if err := mutate(); err != nil {
+			return nil, err
+		}
+
+		update := o.Clone().WithMethod(method).WithRequestBody(v)
+		if method == http.MethodPatch {
+			patch, err := DiffMergePatch(json.RawMessage(original), v)
+			if err != nil {
+				return nil, fmt.Errorf("error creating merge patch: %w", err)
+			}
+			update.WithMergePatch(patch)
+		}
+		update.ensureHeader()
+		if update.Header.Get("Content-Type") == "" {
+			update.Header.Set("Content-Type", "application/json")
+		}
+		if update.ResponseBody != nil && update.Header.Get("Accept") == "" {
+			update.Header.Set("Accept", "application/json")
+		}
+		update.Header.Set("If-Match", etag)
+
+		resp, err = update.DoContext(ctx, options.Options...)
+		if resp != nil && resp.StatusCode == http.StatusPreconditionFailed {
+			resp.Body.Close()
+			continue
+		}
+		return resp, err
+	}
+
+	return nil, &ConflictError{Attempts: maxAttempts}
+}
@@ -0,0 +1,178 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestRequest_Update(t *testing.T) {
+	type item struct {
+		Name  string   `json:"name"`
+		Count int      `json:"count"`
+		Tags  []string `json:"tags,omitempty"`
+	}
+	tests := []struct {
+		name      string
+		options   *UpdateOptions
+		conflicts int
+		etag      string
+		noETag    bool
+		want      item
+		wantCalls int
+		wantErr   error
+	}{
+		{
+			name:      "success",
+			want:      item{Name: "a", Count: 2, Tags: []string{"stale"}},
+			wantCalls: 2,
+		},
+		{
+			name:      "success after conflict",
+			options:   &UpdateOptions{Method: http.MethodPatch},
+			conflicts: 2,
+			want:      item{Name: "a", Count: 4},
+			wantCalls: 6,
+		},
+		{
+			name:      "error conflict",
+			options:   &UpdateOptions{MaxAttempts: 2},
+			conflicts: 2,
+			want:      item{Name: "a", Count: 3},
+			wantCalls: 4,
+			wantErr:   &ConflictError{},
+		},
+		{
+			name:      "error weak etag",
+			etag:      `W/"1"`,
+			want:      item{Name: "a", Count: 1, Tags: []string{"stale"}},
+			wantCalls: 1,
+			wantErr:   errors.New(""),
+		},
+		{
+			name:      "error no etag",
+			noETag:    true,
+			want:      item{Name: "a", Count: 1, Tags: []string{"stale"}},
+			wantCalls: 1,
+			wantErr:   errors.New(""),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			stored := item{Name: "a", Count: 1, Tags: []string{"stale"}}
+			version := 1
+			calls := 0
+			conflicts := tt.conflicts
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				defer mu.Unlock()
+				calls++
+
+				etag := fmt.Sprintf(`"%d"`, version)
+				switch r.Method {
+				case http.MethodGet:
+					if r.Header.Get("Accept") != "application/json" {
+						t.Errorf("GET Accept = %q", r.Header.Get("Accept"))
+					}
+					if tt.etag != "" {
+						w.Header().Set("ETag", tt.etag)
+					} else if !tt.noETag {
+						w.Header().Set("ETag", etag)
+					}
+					json.NewEncoder(w).Encode(stored)
+					// Another writer changes the resource after it is read.
+					if conflicts > 0 {
+						conflicts--
+						stored.Count++
+						stored.Tags = nil
+						version++
+					}
+				default:
+					if r.Header.Get("If-Match") != etag {
+						w.WriteHeader(http.StatusPreconditionFailed)
+						return
+					}
+					body, _ := ioutil.ReadAll(r.Body)
+					if r.Method == http.MethodPatch {
+						if r.Header.Get("Content-Type") != ContentTypeMergePatch {
+							t.Errorf("%s Content-Type = %q", r.Method, r.Header.Get("Content-Type"))
+						}
+						var patch MergePatch
+						if err := json.Unmarshal(body, &patch); err != nil || len(patch) != 1 {
+							t.Errorf("PATCH body = %s, want merge patch of count", body)
+						}
+						doc, _ := json.Marshal(stored)
+						body, _ = patch.Apply(doc)
+					} else if r.Header.Get("Content-Type") != "application/json" {
+						t.Errorf("%s Content-Type = %q", r.Method, r.Header.Get("Content-Type"))
+					}
+					var updated item
+					json.Unmarshal(body, &updated)
+					stored = updated
+					version++
+				}
+			}))
+			defer server.Close()
+
+			o, err := NewRequest().FromURLString(server.URL + "/items/a")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var v item
+			resp, err := o.Update(context.Background(), &v, func() error {
+				v.Count++
+				return nil
+			}, tt.options)
+			if resp != nil {
+				resp.Body.Close()
+			}
+			if (err != nil) != (tt.wantErr != nil) {
+				t.Fatalf("Request.Update() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var conflictErr *ConflictError
+			if _, ok := tt.wantErr.(*ConflictError); ok && !errors.As(err, &conflictErr) {
+				t.Errorf("Request.Update() error = %v, want %T", err, conflictErr)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if !reflect.DeepEqual(stored, tt.want) {
+				t.Errorf("server resource = %+v, want %+v", stored, tt.want)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("server called %d times, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestRequest_Update_mutateError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"1"`)
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	o, err := NewRequest().FromURLString(server.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	errMutate := errors.New("invalid")
+	var v map[string]interface{}
+	if _, err := o.Update(context.Background(), &v, func() error { return errMutate }, nil); err != errMutate {
+		t.Errorf("Request.Update() error = %v, want %v", err, errMutate)
+	}
+	if _, err := o.Update(context.Background(), v, func() error { return nil }, nil); err == nil {
+		t.Errorf("Request.Update() error = nil, want error for non-pointer")
+	}
+}
@@ -15,6 +15,7 @@ func (o *Request) FromURL(u *url.URL) *Request {
 	}
 	if u.Path != "" {
 		o.WithPath(u.Path)
+		o.RawPath = u.RawPath
 	}
 	if len(u.Query()) > 0 {
 		o.WithQuery(u.Query())
@@ -50,6 +51,7 @@ func (o *Request) URL() (*url.URL, error) {
 		Scheme:   o.Scheme,
 		Host:     o.Host,
 		Path:     o.Path,
+		RawPath:  o.RawPath,
 		RawQuery: o.Query.Encode(),
 	}, nil
 }
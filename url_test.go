@@ -137,6 +137,7 @@ func TestRequest_URL(t *testing.T) {
 		Scheme       string
 		Host         string
 		Path         string
+		RawPath      string
 		Query        url.Values
 		Header       http.Header
 		RequestBody  interface{}
@@ -180,6 +181,21 @@ func TestRequest_URL(t *testing.T) {
 				}.Encode(),
 			},
 		},
+		{
+			name: "success with raw path",
+			fields: fields{
+				Scheme:  "http",
+				Host:    "www.host.com",
+				Path:    "/api/v1/users/a/b",
+				RawPath: "/api/v1/users/a%2Fb",
+			},
+			want: &url.URL{
+				Scheme:  "http",
+				Host:    "www.host.com",
+				Path:    "/api/v1/users/a/b",
+				RawPath: "/api/v1/users/a%2Fb",
+			},
+		},
 		{
 			name: "error no scheme",
 			fields: fields{
@@ -213,6 +229,7 @@ func TestRequest_URL(t *testing.T) {
 				Scheme:       tt.fields.Scheme,
 				Host:         tt.fields.Host,
 				Path:         tt.fields.Path,
+				RawPath:      tt.fields.RawPath,
 				Query:        tt.fields.Query,
 				Header:       tt.fields.Header,
 				RequestBody:  tt.fields.RequestBody,